@@ -0,0 +1,82 @@
+//go:build js && wasm
+// +build js,wasm
+
+package api
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/MHSarmadi/Umbra/Client/crypto"
+	"github.com/MHSarmadi/Umbra/Client/tools"
+	"golang.org/x/crypto/argon2"
+)
+
+func DeriveSoulKey() {
+	js.Global().Set("DeriveSoulKey", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: password: string, version: number, salt: uint8array, memory_kib: number, iterations: number, parallelism: number
+		// return: Promise<Uint8Array> which is the 64-byte soul key; its first 32 bytes encipher the soul
+		if len(args) < 2 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 2 parameters are required: password, version")
+				return nil
+			}))
+		}
+
+		password := args[0].String()
+		version := args[1].Int()
+		var (
+			salt                                []byte
+			memory_kib, iterations, parallelism int
+		)
+		if version != 0 {
+			if len(args) < 6 {
+				return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+					reject := promArgs[1]
+					reject.Invoke("6 parameters are required for Argon2id: password, version, salt, memory_kib, iterations, parallelism")
+					return nil
+				}))
+			}
+			var err error
+			if salt, err = tools.JsValueToByteSlice(args[2]); err != nil {
+				return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+					reject := promArgs[1]
+					reject.Invoke("Invalid salt: " + err.Error())
+					return nil
+				}))
+			}
+			memory_kib, iterations, parallelism = args[3].Int(), args[4].Int(), args[5].Int()
+		}
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				var soul_key []byte
+				if version == 0 {
+					digest := crypto.Sum([]byte(password))
+					soul_key = digest[:]
+				} else {
+					if len(salt) != 16 || memory_kib <= 0 || iterations <= 0 || parallelism <= 0 || parallelism > 255 {
+						reject.Invoke("Invalid soul KDF parameters")
+						return
+					}
+					soul_key = argon2.IDKey([]byte(password), salt, uint32(iterations), uint32(memory_kib), uint8(parallelism), 64)
+				}
+
+				result := js.Global().Get("Uint8Array").New(len(soul_key))
+				js.CopyBytesToJS(result, soul_key)
+				resolve.Invoke(result)
+			}()
+			return nil
+		}))
+	}))
+}
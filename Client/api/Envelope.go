@@ -0,0 +1,219 @@
+//go:build js && wasm
+// +build js,wasm
+
+package api
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Client/crypto"
+	"github.com/MHSarmadi/Umbra/Client/tools"
+)
+
+const envelopeNonceSize = 16
+
+func SealEnvelope() {
+	js.Global().Set("SealEnvelope", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: soul: uint8array, server_x_pubkey: uint8array, session_id: uint8array, method: string, path: string, body: string | uint8array
+		// return: Promise<{envelope: string, nonce: string}>
+		if len(args) < 6 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 6 parameters are required: soul, server_x_pubkey, session_id, method, path, body")
+				return nil
+			}))
+		}
+		soul, err := tools.JsValueToByteSlice(args[0])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid soul: " + err.Error())
+				return nil
+			}))
+		}
+		server_x_pubkey, err := tools.JsValueToByteSlice(args[1])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid server_x_pubkey: " + err.Error())
+				return nil
+			}))
+		}
+		session_id, err := tools.JsValueToByteSlice(args[2])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid session_id: " + err.Error())
+				return nil
+			}))
+		}
+		method := args[3].String()
+		path := args[4].String()
+		var body []byte
+		if args[5].Type() == js.TypeString {
+			body = []byte(args[5].String())
+		} else if body, err = tools.JsValueToByteSlice(args[5]); err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid body: " + err.Error())
+				return nil
+			}))
+		}
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				shared_secret, err := crypto.ComputeSharedSecret(soul, server_x_pubkey)
+				if err != nil {
+					reject.Invoke("Failed to compute shared secret: " + err.Error())
+					return
+				}
+				shared_key := crypto.KDF(shared_secret, "@SESSION-SHARED-KEY", 32)
+
+				var nonce [envelopeNonceSize]byte
+				if _, err := rand.Read(nonce[:]); err != nil {
+					reject.Invoke("Could not read entropy for envelope nonce")
+					return
+				}
+				var timestamp [8]byte
+				binary.BigEndian.PutUint64(timestamp[:], uint64(time.Now().UnixMilli()))
+
+				mixin := make([]byte, 0, len(session_id)+len(nonce)+len(timestamp)+len(method)+1+len(path))
+				mixin = append(mixin, session_id...)
+				mixin = append(mixin, nonce[:]...)
+				mixin = append(mixin, timestamp[:]...)
+				mixin = append(mixin, method...)
+				mixin = append(mixin, ' ')
+				mixin = append(mixin, path...)
+
+				payload_ciphered, payload_salt, payload_tag := crypto.MACE_Encrypt_MIXIN_AEAD(shared_key, body, mixin, "@REQUEST-ENVELOPE", 4, false)
+				payload := append(payload_salt, payload_tag...)
+				payload = append(payload, payload_ciphered...)
+				signature := crypto.Sign(soul, append(mixin, payload...))
+
+				envelope, err := json.Marshal(map[string]string{
+					"session_id":              b64(session_id),
+					"nonce":                   b64(nonce[:]),
+					"timestamp_unix_millisec": b64(timestamp[:]),
+					"payload":                 b64(payload),
+					"signature":               b64(signature),
+				})
+				if err != nil {
+					reject.Invoke("Failed to marshal envelope: " + err.Error())
+					return
+				}
+
+				result := js.Global().Get("Object").New()
+				result.Set("envelope", string(envelope))
+				result.Set("nonce", b64(nonce[:]))
+				resolve.Invoke(result)
+			}()
+			return nil
+		}))
+	}))
+}
+
+func OpenEnvelope() {
+	js.Global().Set("OpenEnvelope", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: soul: uint8array, server_ed_pubkey: uint8array, server_x_pubkey: uint8array, session_id: uint8array, nonce: base64, payload: base64, signature: base64, binary?: boolean
+		// return: Promise<string|Uint8Array> which is the deciphered response body, as bytes when binary is set
+		if len(args) < 7 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 7 parameters are required: soul, server_ed_pubkey, server_x_pubkey, session_id, nonce, payload, signature")
+				return nil
+			}))
+		}
+		var (
+			byteArgs [4][]byte
+			err      error
+		)
+		for i, name := range []string{"soul", "server_ed_pubkey", "server_x_pubkey", "session_id"} {
+			if byteArgs[i], err = tools.JsValueToByteSlice(args[i]); err != nil {
+				return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+					reject := promArgs[1]
+					reject.Invoke("Invalid " + name + ": " + err.Error())
+					return nil
+				}))
+			}
+		}
+		soul, server_ed_pubkey, server_x_pubkey, session_id := byteArgs[0], byteArgs[1], byteArgs[2], byteArgs[3]
+		var b64Args [3][]byte
+		for i, name := range []string{"nonce", "payload", "signature"} {
+			if b64Args[i], err = db64(args[4+i].String()); err != nil {
+				return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+					reject := promArgs[1]
+					reject.Invoke("Invalid " + name + " base64 encoding: " + err.Error())
+					return nil
+				}))
+			}
+		}
+		nonce, payload, signature := b64Args[0], b64Args[1], b64Args[2]
+		binary := len(args) > 7 && args[7].Truthy()
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				if !crypto.Verify(server_ed_pubkey, payload, signature) {
+					reject.Invoke("Invalid signature over payload")
+					return
+				}
+				if len(payload) < 12+16+64 {
+					reject.Invoke("Invalid payload length")
+					return
+				}
+
+				shared_secret, err := crypto.ComputeSharedSecret(soul, server_x_pubkey)
+				if err != nil {
+					reject.Invoke("Failed to compute shared secret: " + err.Error())
+					return
+				}
+				shared_key := crypto.KDF(shared_secret, "@SESSION-SHARED-KEY", 32)
+
+				mixin := append(append([]byte(nil), session_id...), nonce...)
+				payload_salt := payload[:12]
+				payload_tag := payload[12 : 12+16]
+				payload_ciphered := payload[12+16:]
+				payload_deciphered, valid, err := crypto.MACE_Decrypt_MIXIN_AEAD(shared_key, payload_ciphered, mixin, payload_salt, payload_tag, "@RESPONSE-ENVELOPE", 4)
+				if !valid {
+					reject.Invoke("AEAD failed during deciphering payload.")
+					return
+				}
+				if err != nil {
+					reject.Invoke("Failed to decipher payload.")
+					return
+				}
+
+				if binary {
+					result := js.Global().Get("Uint8Array").New(len(payload_deciphered))
+					js.CopyBytesToJS(result, payload_deciphered)
+					resolve.Invoke(result)
+					return
+				}
+				resolve.Invoke(string(payload_deciphered))
+			}()
+			return nil
+		}))
+	}))
+}
@@ -0,0 +1,158 @@
+//go:build js && wasm
+// +build js,wasm
+
+package api
+
+import (
+	"crypto/rand"
+	"fmt"
+	"syscall/js"
+
+	"github.com/MHSarmadi/Umbra/Client/crypto"
+	"github.com/MHSarmadi/Umbra/Client/tools"
+)
+
+// The group secret is wrapped to a member with a throwaway X25519 soul, so the
+// server only ever stores the ephemeral public key next to the ciphertext.
+// The member's own public key is mixed in to pin the wrap to that member.
+
+func WrapGroupSecret() {
+	js.Global().Set("WrapGroupSecret", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: group_secret: uint8array, member_x_pubkey: uint8array
+		// return: Promise<{x_pub_key, cipher, salt, tag}> all base64, the "secret" of /group/create and /group/members/add
+		if len(args) < 2 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 2 parameters are required: group_secret, member_x_pubkey")
+				return nil
+			}))
+		}
+
+		group_secret, err := tools.JsValueToByteSlice(args[0])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid group_secret: " + err.Error())
+				return nil
+			}))
+		}
+		member_x_pubkey, err := tools.JsValueToByteSlice(args[1])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid member_x_pubkey: " + err.Error())
+				return nil
+			}))
+		}
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				if len(member_x_pubkey) != 32 {
+					reject.Invoke("Invalid member X25519 public key length: expected 32 bytes")
+					return
+				}
+
+				ephemeral_soul := make([]byte, 32)
+				if _, err := rand.Read(ephemeral_soul); err != nil {
+					reject.Invoke("Could not generate ephemeral soul: " + err.Error())
+					return
+				}
+				ephemeral_x_pubkey, err := crypto.DeriveX25519PubKey(ephemeral_soul)
+				if err != nil {
+					reject.Invoke("Could not derive ephemeral X25519 public key: " + err.Error())
+					return
+				}
+				shared_secret, err := crypto.ComputeSharedSecret(ephemeral_soul, member_x_pubkey)
+				if err != nil {
+					reject.Invoke("Could not compute shared secret: " + err.Error())
+					return
+				}
+				wrap_key := crypto.KDF(shared_secret, "@GROUP-SECRET-WRAP", 32)
+
+				cipher, salt, tag := crypto.MACE_Encrypt_MIXIN_AEAD(wrap_key, group_secret, member_x_pubkey, "@GROUP-SECRET", 4, false)
+				resolve.Invoke(map[string]any{
+					"x_pub_key": b64(ephemeral_x_pubkey),
+					"cipher":    b64(cipher),
+					"salt":      b64(salt),
+					"tag":       b64(tag),
+				})
+			}()
+			return nil
+		}))
+	}))
+}
+
+func UnwrapGroupSecret() {
+	js.Global().Set("UnwrapGroupSecret", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: user_soul: uint8array, x_pub_key: uint8array, cipher: uint8array, salt: uint8array, tag: uint8array
+		// return: Promise<Uint8Array> which is the group secret
+		if len(args) < 5 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 5 parameters are required: user_soul, x_pub_key, cipher, salt, tag")
+				return nil
+			}))
+		}
+
+		values := make([][]byte, 5)
+		for i, name := range []string{"user_soul", "x_pub_key", "cipher", "salt", "tag"} {
+			var err error
+			if values[i], err = tools.JsValueToByteSlice(args[i]); err != nil {
+				return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+					reject := promArgs[1]
+					reject.Invoke("Invalid " + name + ": " + err.Error())
+					return nil
+				}))
+			}
+		}
+		user_soul, ephemeral_x_pubkey, cipher, salt, tag := values[0], values[1], values[2], values[3], values[4]
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				user_x_pubkey, err := crypto.DeriveX25519PubKey(user_soul)
+				if err != nil {
+					reject.Invoke("Could not derive X25519 public key: " + err.Error())
+					return
+				}
+				shared_secret, err := crypto.ComputeSharedSecret(user_soul, ephemeral_x_pubkey)
+				if err != nil {
+					reject.Invoke("Could not compute shared secret: " + err.Error())
+					return
+				}
+				wrap_key := crypto.KDF(shared_secret, "@GROUP-SECRET-WRAP", 32)
+
+				group_secret, valid, err := crypto.MACE_Decrypt_MIXIN_AEAD(wrap_key, cipher, user_x_pubkey, salt, tag, "@GROUP-SECRET", 4)
+				if err != nil {
+					reject.Invoke("Could not unwrap group secret: " + err.Error())
+					return
+				} else if !valid {
+					reject.Invoke("Group secret was not wrapped to this user")
+					return
+				}
+
+				result := js.Global().Get("Uint8Array").New(len(group_secret))
+				js.CopyBytesToJS(result, group_secret)
+				resolve.Invoke(result)
+			}()
+			return nil
+		}))
+	}))
+}
@@ -11,11 +11,13 @@ import (
 
 	"github.com/MHSarmadi/Umbra/Client/crypto"
 	"github.com/MHSarmadi/Umbra/Client/tools"
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"google.golang.org/protobuf/proto"
 )
 
 func IntroduceServer() {
 	js.Global().Set("IntroduceServer", js.FuncOf(func(this js.Value, args []js.Value) any {
-		// expected args: soul, server_ed_pubkey: base64, server_x_pubkey: base64, server_x_pubkey_sign: base64, payload: base64, signature: base64
+		// expected args: soul, server_ed_pubkey: base64, server_x_pubkey: base64, server_x_pubkey_sign: base64, payload: base64, signature: base64, encoding?: "json" | "protobuf"
 		if len(args) < 6 {
 			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
 				reject := promArgs[1]
@@ -71,6 +73,7 @@ func IntroduceServer() {
 				return nil
 			}))
 		}
+		asProto := len(args) > 6 && args[6].Type() == js.TypeString && args[6].String() == "protobuf"
 		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
 			resolve := promArgs[0]
 			reject := promArgs[1]
@@ -116,7 +119,7 @@ func IntroduceServer() {
 					return
 				}
 
-				// 4. parse JSON
+				// 4. parse JSON or ProtoBuf
 				type SessionInitRawPayload struct {
 					SessionUUID               string         `json:"session_id"`
 					CaptchaChallenge          string         `json:"captcha_challenge"`
@@ -127,7 +130,26 @@ func IntroduceServer() {
 					SessionTokenCipherKeySalt string         `json:"session_token_cipher_key_salt"`
 				}
 				var payloadData SessionInitRawPayload
-				if err := json.Unmarshal(payload_deciphered, &payloadData); err != nil {
+				if asProto {
+					var payloadPB umbrapb.SessionInitPayload
+					if err := proto.Unmarshal(payload_deciphered, &payloadPB); err != nil {
+						reject.Invoke("Failed to parse payload ProtoBuf: " + err.Error())
+						return
+					}
+					payloadData = SessionInitRawPayload{
+						SessionUUID:      b64(payloadPB.GetSessionId()),
+						CaptchaChallenge: b64(payloadPB.GetCaptchaChallenge()),
+						PoWChallenge:     b64(payloadPB.GetPowChallenge()),
+						PowParams: map[string]any{
+							"memory_mb":   payloadPB.GetPowParams().GetMemoryMb(),
+							"iterations":  payloadPB.GetPowParams().GetIterations(),
+							"parallelism": payloadPB.GetPowParams().GetParallelism(),
+						},
+						PoWSalt:                   b64(payloadPB.GetPowSalt()),
+						SessionToken:              b64(payloadPB.GetSessionTokenCiphered()),
+						SessionTokenCipherKeySalt: b64(payloadPB.GetSessionTokenCipherKeySalt()),
+					}
+				} else if err := json.Unmarshal(payload_deciphered, &payloadData); err != nil {
 					reject.Invoke("Failed to parse payload JSON: " + err.Error())
 					return
 				}
@@ -0,0 +1,73 @@
+//go:build js && wasm
+// +build js,wasm
+
+package api
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/MHSarmadi/Umbra/Client/crypto"
+	"github.com/MHSarmadi/Umbra/Client/tools"
+)
+
+func ProveSessionToken() {
+	js.Global().Set("ProveSessionToken", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: session_token: uint8array, session_id: uint8array, activation_nonce: uint8array
+		// return: Promise<string> which is the base64 activation proof
+		if len(args) < 3 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 3 parameters are required: session_token, session_id, activation_nonce")
+				return nil
+			}))
+		}
+
+		session_token, err := tools.JsValueToByteSlice(args[0])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid session_token: " + err.Error())
+				return nil
+			}))
+		}
+		session_id, err := tools.JsValueToByteSlice(args[1])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid session_id: " + err.Error())
+				return nil
+			}))
+		}
+		activation_nonce, err := tools.JsValueToByteSlice(args[2])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid activation_nonce: " + err.Error())
+				return nil
+			}))
+		}
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				if len(session_token) != 24 {
+					reject.Invoke("Invalid session token length: expected 24 bytes")
+					return
+				}
+
+				proof := crypto.MAC(session_token, append(session_id, activation_nonce...), "@SESSION-ACTIVATION")
+				resolve.Invoke(b64(proof[:]))
+			}()
+			return nil
+		}))
+	}))
+}
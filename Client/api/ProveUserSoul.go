@@ -0,0 +1,65 @@
+//go:build js && wasm
+// +build js,wasm
+
+package api
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/MHSarmadi/Umbra/Client/crypto"
+	"github.com/MHSarmadi/Umbra/Client/tools"
+)
+
+func ProveUserSoul() {
+	js.Global().Set("ProveUserSoul", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: user_soul: uint8array, session_id: uint8array
+		// return: Promise<string> which is the base64 signature for /user/register and /user/login/proof
+		if len(args) < 2 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 2 parameters are required: user_soul, session_id")
+				return nil
+			}))
+		}
+
+		user_soul, err := tools.JsValueToByteSlice(args[0])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid user_soul: " + err.Error())
+				return nil
+			}))
+		}
+		session_id, err := tools.JsValueToByteSlice(args[1])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid session_id: " + err.Error())
+				return nil
+			}))
+		}
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				if len(user_soul) != 32 {
+					reject.Invoke("Invalid user soul length: expected 32 bytes")
+					return
+				}
+
+				signature := crypto.Sign(user_soul, append([]byte("@USER-SOUL-PROOF-"), session_id...))
+				resolve.Invoke(b64(signature))
+			}()
+			return nil
+		}))
+	}))
+}
@@ -0,0 +1,80 @@
+//go:build js && wasm
+// +build js,wasm
+
+package api
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"syscall/js"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Client/crypto"
+	"github.com/MHSarmadi/Umbra/Client/tools"
+)
+
+const chatMessageNonceSize = 16
+
+func SignChatMessage() {
+	js.Global().Set("SignChatMessage", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: user_soul: uint8array, group_uuid: uint8array, x_pub_key: uint8array, payload: uint8array
+		// return: Promise<{sender_signature: string, nonce: string, sent_at_unix_millisec: string}>, all base64,
+		// which go into the chat message next to the signed fields
+		if len(args) < 4 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 4 parameters are required: user_soul, group_uuid, x_pub_key, payload")
+				return nil
+			}))
+		}
+
+		values := make([][]byte, 4)
+		for i, name := range []string{"user_soul", "group_uuid", "x_pub_key", "payload"} {
+			var err error
+			if values[i], err = tools.JsValueToByteSlice(args[i]); err != nil {
+				return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+					reject := promArgs[1]
+					reject.Invoke("Invalid " + name + ": " + err.Error())
+					return nil
+				}))
+			}
+		}
+		user_soul, group_uuid, x_pub_key, payload := values[0], values[1], values[2], values[3]
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				var nonce [chatMessageNonceSize]byte
+				if _, err := rand.Read(nonce[:]); err != nil {
+					reject.Invoke("Could not read entropy for chat message nonce")
+					return
+				}
+				var sent_at [8]byte
+				binary.BigEndian.PutUint64(sent_at[:], uint64(time.Now().UnixMilli()))
+
+				signed := make([]byte, 0, len("@GROUP-MESSAGE-")+len(group_uuid)+len(x_pub_key)+len(nonce)+len(sent_at)+len(payload))
+				signed = append(signed, "@GROUP-MESSAGE-"...)
+				signed = append(signed, group_uuid...)
+				signed = append(signed, x_pub_key...)
+				signed = append(signed, nonce[:]...)
+				signed = append(signed, sent_at[:]...)
+				signed = append(signed, payload...)
+				resolve.Invoke(js.ValueOf(map[string]any{
+					"sender_signature":      b64(crypto.Sign(user_soul, signed)),
+					"nonce":                 b64(nonce[:]),
+					"sent_at_unix_millisec": b64(sent_at[:]),
+				}))
+			}()
+			return nil
+		}))
+	}))
+}
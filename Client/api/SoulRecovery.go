@@ -0,0 +1,131 @@
+//go:build js && wasm
+// +build js,wasm
+
+package api
+
+import (
+	"crypto/rand"
+	"fmt"
+	"syscall/js"
+
+	"github.com/MHSarmadi/Umbra/Client/crypto"
+	"github.com/MHSarmadi/Umbra/Client/tools"
+)
+
+func NewSoulRecovery() {
+	js.Global().Set("NewSoulRecovery", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: soul_key: uint8array
+		// return: Promise<{recovery_key, soul_recovery, soul_recovery_salt, soul_recovery_tag}> all base64; recovery_key is shown to the user once
+		if len(args) < 1 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 1 parameter is required: soul_key")
+				return nil
+			}))
+		}
+
+		soul_key, err := tools.JsValueToByteSlice(args[0])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid soul_key: " + err.Error())
+				return nil
+			}))
+		}
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				if len(soul_key) != 64 {
+					reject.Invoke("Invalid soul key length: expected 64 bytes")
+					return
+				}
+
+				recovery_key := make([]byte, 32)
+				if _, err := rand.Read(recovery_key); err != nil {
+					reject.Invoke("Could not generate recovery key: " + err.Error())
+					return
+				}
+				true_recovery_key := crypto.Sum(recovery_key)
+				recovery_cipher, recovery_salt, recovery_tag := crypto.MACE_Encrypt_AEAD(true_recovery_key[:32], soul_key, "@SOUL-ENCRYPTION-@RECOVERY-KEY", 16, false)
+
+				resolve.Invoke(map[string]any{
+					"recovery_key":       b64(recovery_key),
+					"soul_recovery":      b64(recovery_cipher),
+					"soul_recovery_salt": b64(recovery_salt),
+					"soul_recovery_tag":  b64(recovery_tag),
+				})
+			}()
+			return nil
+		}))
+	}))
+}
+
+func OpenSoulRecovery() {
+	js.Global().Set("OpenSoulRecovery", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: recovery_key: string (base64), soul_recovery: uint8array, soul_recovery_salt: uint8array, soul_recovery_tag: uint8array
+		// return: Promise<Uint8Array> which is the 64-byte soul key
+		if len(args) < 4 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 4 parameters are required: recovery_key, soul_recovery, soul_recovery_salt, soul_recovery_tag")
+				return nil
+			}))
+		}
+
+		recovery_key, err := db64(args[0].String())
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid recovery_key: " + err.Error())
+				return nil
+			}))
+		}
+		blobs := make([][]byte, 3)
+		for i, name := range []string{"soul_recovery", "soul_recovery_salt", "soul_recovery_tag"} {
+			if blobs[i], err = tools.JsValueToByteSlice(args[i+1]); err != nil {
+				return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+					reject := promArgs[1]
+					reject.Invoke("Invalid " + name + ": " + err.Error())
+					return nil
+				}))
+			}
+		}
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				true_recovery_key := crypto.Sum(recovery_key)
+				soul_key, valid, err := crypto.MACE_Decrypt_AEAD(true_recovery_key[:32], blobs[0], blobs[1], blobs[2], "@SOUL-ENCRYPTION-@RECOVERY-KEY", 16)
+				if err != nil {
+					reject.Invoke("Could not open recovery blob: " + err.Error())
+					return
+				} else if !valid {
+					reject.Invoke("Wrong recovery key")
+					return
+				}
+
+				result := js.Global().Get("Uint8Array").New(len(soul_key))
+				js.CopyBytesToJS(result, soul_key)
+				resolve.Invoke(result)
+			}()
+			return nil
+		}))
+	}))
+}
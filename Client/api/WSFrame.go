@@ -0,0 +1,192 @@
+//go:build js && wasm
+// +build js,wasm
+
+package api
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall/js"
+
+	"github.com/MHSarmadi/Umbra/Client/crypto"
+	"github.com/MHSarmadi/Umbra/Client/tools"
+)
+
+const (
+	wsDirectionClientToServer = "@WS-CLIENT-TO-SERVER"
+	wsDirectionServerToClient = "@WS-SERVER-TO-CLIENT"
+)
+
+func wsDirectionKey(soul, server_x_pubkey []byte, direction string) ([]byte, error) {
+	shared_secret, err := crypto.ComputeSharedSecret(soul, server_x_pubkey)
+	if err != nil {
+		return nil, err
+	}
+	shared_key := crypto.KDF(shared_secret, "@SESSION-SHARED-KEY", 32)
+	return crypto.KDF(shared_key, direction, 32), nil
+}
+
+// wsFrameMixin binds a frame to its connection through the nonce of the
+// envelope that upgraded it; the server refuses envelope nonces it has seen.
+func wsFrameMixin(session_id, conn_nonce []byte, direction string, seq []byte) []byte {
+	mixin := make([]byte, 0, len(session_id)+len(conn_nonce)+len(direction)+len(seq))
+	mixin = append(mixin, session_id...)
+	mixin = append(mixin, conn_nonce...)
+	mixin = append(mixin, direction...)
+	mixin = append(mixin, seq...)
+	return mixin
+}
+
+func SealWSFrame() {
+	js.Global().Set("SealWSFrame", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: soul: uint8array, server_x_pubkey: uint8array, session_id: uint8array, connection_nonce: base64, seq: number, payload: string
+		// connection_nonce is the nonce SealEnvelope returned for the /ws upgrade request.
+		// return: Promise<Uint8Array> which is the binary frame to send
+		if len(args) < 6 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 6 parameters are required: soul, server_x_pubkey, session_id, connection_nonce, seq, payload")
+				return nil
+			}))
+		}
+		var (
+			byteArgs [3][]byte
+			err      error
+		)
+		for i, name := range []string{"soul", "server_x_pubkey", "session_id"} {
+			if byteArgs[i], err = tools.JsValueToByteSlice(args[i]); err != nil {
+				return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+					reject := promArgs[1]
+					reject.Invoke("Invalid " + name + ": " + err.Error())
+					return nil
+				}))
+			}
+		}
+		soul, server_x_pubkey, session_id := byteArgs[0], byteArgs[1], byteArgs[2]
+		conn_nonce, err := db64(args[3].String())
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid connection_nonce base64 encoding: " + err.Error())
+				return nil
+			}))
+		}
+		seq := uint64(args[4].Int())
+		payload := []byte(args[5].String())
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				key, err := wsDirectionKey(soul, server_x_pubkey, wsDirectionClientToServer)
+				if err != nil {
+					reject.Invoke("Failed to compute shared secret: " + err.Error())
+					return
+				}
+				frame := make([]byte, 8, 8+12+16+len(payload)+64)
+				binary.BigEndian.PutUint64(frame, seq)
+				cipher, salt, tag := crypto.MACE_Encrypt_MIXIN_AEAD(key, payload, wsFrameMixin(session_id, conn_nonce, wsDirectionClientToServer, frame[:8]), "@WS-FRAME", 2, false)
+				frame = append(frame, salt...)
+				frame = append(frame, tag...)
+				frame = append(frame, cipher...)
+
+				result := js.Global().Get("Uint8Array").New(len(frame))
+				js.CopyBytesToJS(result, frame)
+				resolve.Invoke(result)
+			}()
+			return nil
+		}))
+	}))
+}
+
+func OpenWSFrame() {
+	js.Global().Set("OpenWSFrame", js.FuncOf(func(this js.Value, args []js.Value) any {
+		// expected args: soul: uint8array, server_x_pubkey: uint8array, session_id: uint8array, connection_nonce: base64, expected_seq: number, frame: uint8array
+		// connection_nonce is the nonce SealEnvelope returned for the /ws upgrade request.
+		// return: Promise<string> which is the deciphered payload
+		if len(args) < 6 {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("At least 6 parameters are required: soul, server_x_pubkey, session_id, connection_nonce, expected_seq, frame")
+				return nil
+			}))
+		}
+		var (
+			byteArgs [3][]byte
+			err      error
+		)
+		for i, name := range []string{"soul", "server_x_pubkey", "session_id"} {
+			if byteArgs[i], err = tools.JsValueToByteSlice(args[i]); err != nil {
+				return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+					reject := promArgs[1]
+					reject.Invoke("Invalid " + name + ": " + err.Error())
+					return nil
+				}))
+			}
+		}
+		soul, server_x_pubkey, session_id := byteArgs[0], byteArgs[1], byteArgs[2]
+		conn_nonce, err := db64(args[3].String())
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid connection_nonce base64 encoding: " + err.Error())
+				return nil
+			}))
+		}
+		frame, err := tools.JsValueToByteSlice(args[5])
+		if err != nil {
+			return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+				reject := promArgs[1]
+				reject.Invoke("Invalid frame: " + err.Error())
+				return nil
+			}))
+		}
+		expected_seq := uint64(args[4].Int())
+
+		return js.Global().Get("Promise").New(js.FuncOf(func(_ js.Value, promArgs []js.Value) any {
+			resolve := promArgs[0]
+			reject := promArgs[1]
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(fmt.Sprintf("Panic occurred: %v", r))
+					}
+				}()
+
+				if len(frame) < 8+12+16+64 {
+					reject.Invoke("Invalid frame length")
+					return
+				}
+				if binary.BigEndian.Uint64(frame[:8]) != expected_seq {
+					reject.Invoke("Frame out of sequence")
+					return
+				}
+				key, err := wsDirectionKey(soul, server_x_pubkey, wsDirectionServerToClient)
+				if err != nil {
+					reject.Invoke("Failed to compute shared secret: " + err.Error())
+					return
+				}
+				payload, valid, err := crypto.MACE_Decrypt_MIXIN_AEAD(key, frame[8+12+16:], wsFrameMixin(session_id, conn_nonce, wsDirectionServerToClient, frame[:8]), frame[8:8+12], frame[8+12:8+12+16], "@WS-FRAME", 2)
+				if !valid {
+					reject.Invoke("AEAD failed during deciphering frame.")
+					return
+				}
+				if err != nil {
+					reject.Invoke("Failed to decipher frame.")
+					return
+				}
+
+				resolve.Invoke(string(payload))
+			}()
+			return nil
+		}))
+	}))
+}
@@ -78,6 +78,10 @@ declare global {
 		// expected args: captcha_challenge_numeric, session_token_ciphered, session_id
 		// return: Promise<string> which is the decipehred session_token
 		CheckoutCaptcha?: (captcha_solution_numeric: number, session_token_ciphered: Uint8Array<ArrayBuffer>, session_token_cipher_key_salt: Uint8Array<ArrayBuffer>, session_id: Uint8Array<ArrayBuffer>) => Promise<string>;
+
+		// expected args: session_token, session_id, activation_nonce
+		// return: Promise<string> which is the base64 activation proof for /session/activate
+		ProveSessionToken?: (session_token: Uint8Array<ArrayBuffer>, session_id: Uint8Array<ArrayBuffer>, activation_nonce: Uint8Array<ArrayBuffer>) => Promise<string>;
 	}
 }
 
@@ -3,11 +3,15 @@ module github.com/MHSarmadi/Umbra/Client
 go 1.25.4
 
 require (
+	github.com/MHSarmadi/Umbra/Proto v0.0.0
 	github.com/zeebo/blake3 v0.2.4
 	golang.org/x/crypto v0.47.0
+	google.golang.org/protobuf v1.36.9
 )
 
 require (
 	github.com/klauspost/cpuid/v2 v2.0.12 // indirect
 	golang.org/x/sys v0.40.0 // indirect
 )
+
+replace github.com/MHSarmadi/Umbra/Proto => ../Proto
@@ -32,5 +32,29 @@ func main() {
 
 	api.CheckoutCaptcha()
 
+	api.ProveSessionToken()
+
+	api.ProveUserSoul()
+
+	api.DeriveSoulKey()
+
+	api.NewSoulRecovery()
+
+	api.OpenSoulRecovery()
+
+	api.WrapGroupSecret()
+
+	api.UnwrapGroupSecret()
+
+	api.SignChatMessage()
+
+	api.SealEnvelope()
+
+	api.OpenEnvelope()
+
+	api.SealWSFrame()
+
+	api.OpenWSFrame()
+
 	select {}
 }
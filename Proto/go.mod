@@ -0,0 +1,5 @@
+module github.com/MHSarmadi/Umbra/Proto
+
+go 1.24.5
+
+require google.golang.org/protobuf v1.36.9
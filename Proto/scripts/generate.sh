@@ -0,0 +1,14 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+ROOT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
+
+echo "[+] Generating Umbra protobuf types"
+
+cd "$ROOT_DIR"
+protoc \
+	--go_out=. \
+	--go_opt=paths=source_relative \
+	umbrapb/*.proto
+
+echo "[✓] Protobuf generation complete"
@@ -0,0 +1,595 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: umbrapb/chat.proto
+
+package umbrapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ChatMessage is a group message. Senders fill group_uuid, x_pub_key,
+// payload, nonce, sent_at_unix_millisec and sender_signature; the server
+// assigns the rest when it stores it.
+type ChatMessage struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Uuid                  []byte                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	GroupUuid             []byte                 `protobuf:"bytes,2,opt,name=group_uuid,json=groupUuid,proto3" json:"group_uuid,omitempty"`
+	XPubKey               []byte                 `protobuf:"bytes,3,opt,name=x_pub_key,json=xPubKey,proto3" json:"x_pub_key,omitempty"`
+	Payload               []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	SenderUuid            []byte                 `protobuf:"bytes,5,opt,name=sender_uuid,json=senderUuid,proto3" json:"sender_uuid,omitempty"`
+	SenderSignature       []byte                 `protobuf:"bytes,6,opt,name=sender_signature,json=senderSignature,proto3" json:"sender_signature,omitempty"`
+	CreatedAtUnixMillisec int64                  `protobuf:"varint,7,opt,name=created_at_unix_millisec,json=createdAtUnixMillisec,proto3" json:"created_at_unix_millisec,omitempty"`
+	// 16 random bytes, signed with the rest; the server refuses a nonce the
+	// sender already used in the group.
+	Nonce []byte `protobuf:"bytes,8,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// When the sender signed the message; it must be within minutes of the
+	// server's clock.
+	SentAtUnixMillisec int64 `protobuf:"varint,9,opt,name=sent_at_unix_millisec,json=sentAtUnixMillisec,proto3" json:"sent_at_unix_millisec,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_umbrapb_chat_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_chat_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_umbrapb_chat_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ChatMessage) GetUuid() []byte {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetGroupUuid() []byte {
+	if x != nil {
+		return x.GroupUuid
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetXPubKey() []byte {
+	if x != nil {
+		return x.XPubKey
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetSenderUuid() []byte {
+	if x != nil {
+		return x.SenderUuid
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetSenderSignature() []byte {
+	if x != nil {
+		return x.SenderSignature
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetCreatedAtUnixMillisec() int64 {
+	if x != nil {
+		return x.CreatedAtUnixMillisec
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetSentAtUnixMillisec() int64 {
+	if x != nil {
+		return x.SentAtUnixMillisec
+	}
+	return 0
+}
+
+type Ping struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ping) Reset() {
+	*x = Ping{}
+	mi := &file_umbrapb_chat_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ping) ProtoMessage() {}
+
+func (x *Ping) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_chat_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ping.ProtoReflect.Descriptor instead.
+func (*Ping) Descriptor() ([]byte, []int) {
+	return file_umbrapb_chat_proto_rawDescGZIP(), []int{1}
+}
+
+type Pong struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pong) Reset() {
+	*x = Pong{}
+	mi := &file_umbrapb_chat_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pong) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pong) ProtoMessage() {}
+
+func (x *Pong) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_chat_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pong.ProtoReflect.Descriptor instead.
+func (*Pong) Descriptor() ([]byte, []int) {
+	return file_umbrapb_chat_proto_rawDescGZIP(), []int{2}
+}
+
+// WSMessage is the plaintext carried inside every encrypted websocket frame.
+type WSMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*WSMessage_Ping
+	//	*WSMessage_Pong
+	//	*WSMessage_ChatMessage
+	Body          isWSMessage_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSMessage) Reset() {
+	*x = WSMessage{}
+	mi := &file_umbrapb_chat_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSMessage) ProtoMessage() {}
+
+func (x *WSMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_chat_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSMessage.ProtoReflect.Descriptor instead.
+func (*WSMessage) Descriptor() ([]byte, []int) {
+	return file_umbrapb_chat_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WSMessage) GetBody() isWSMessage_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *WSMessage) GetPing() *Ping {
+	if x != nil {
+		if x, ok := x.Body.(*WSMessage_Ping); ok {
+			return x.Ping
+		}
+	}
+	return nil
+}
+
+func (x *WSMessage) GetPong() *Pong {
+	if x != nil {
+		if x, ok := x.Body.(*WSMessage_Pong); ok {
+			return x.Pong
+		}
+	}
+	return nil
+}
+
+func (x *WSMessage) GetChatMessage() *ChatMessage {
+	if x != nil {
+		if x, ok := x.Body.(*WSMessage_ChatMessage); ok {
+			return x.ChatMessage
+		}
+	}
+	return nil
+}
+
+type isWSMessage_Body interface {
+	isWSMessage_Body()
+}
+
+type WSMessage_Ping struct {
+	Ping *Ping `protobuf:"bytes,1,opt,name=ping,proto3,oneof"`
+}
+
+type WSMessage_Pong struct {
+	Pong *Pong `protobuf:"bytes,2,opt,name=pong,proto3,oneof"`
+}
+
+type WSMessage_ChatMessage struct {
+	ChatMessage *ChatMessage `protobuf:"bytes,3,opt,name=chat_message,json=chatMessage,proto3,oneof"`
+}
+
+func (*WSMessage_Ping) isWSMessage_Body() {}
+
+func (*WSMessage_Pong) isWSMessage_Body() {}
+
+func (*WSMessage_ChatMessage) isWSMessage_Body() {}
+
+type ChatMessageSendResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       *ChatMessage           `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessageSendResponse) Reset() {
+	*x = ChatMessageSendResponse{}
+	mi := &file_umbrapb_chat_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessageSendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessageSendResponse) ProtoMessage() {}
+
+func (x *ChatMessageSendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_chat_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessageSendResponse.ProtoReflect.Descriptor instead.
+func (*ChatMessageSendResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_chat_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ChatMessageSendResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ChatMessageSendResponse) GetMessage() *ChatMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+// ChatHistoryRequest pages a group's history. Set after to read forward from
+// a cursor, before to read back from one, or neither for the newest page.
+type ChatHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupUuid     []byte                 `protobuf:"bytes,1,opt,name=group_uuid,json=groupUuid,proto3" json:"group_uuid,omitempty"`
+	Before        []byte                 `protobuf:"bytes,2,opt,name=before,proto3" json:"before,omitempty"`
+	After         []byte                 `protobuf:"bytes,3,opt,name=after,proto3" json:"after,omitempty"`
+	Limit         uint32                 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatHistoryRequest) Reset() {
+	*x = ChatHistoryRequest{}
+	mi := &file_umbrapb_chat_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatHistoryRequest) ProtoMessage() {}
+
+func (x *ChatHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_chat_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatHistoryRequest.ProtoReflect.Descriptor instead.
+func (*ChatHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_chat_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ChatHistoryRequest) GetGroupUuid() []byte {
+	if x != nil {
+		return x.GroupUuid
+	}
+	return nil
+}
+
+func (x *ChatHistoryRequest) GetBefore() []byte {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *ChatHistoryRequest) GetAfter() []byte {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+func (x *ChatHistoryRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ChatHistoryResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// Oldest first.
+	Messages []*ChatMessage `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"`
+	// Cursor to continue in the same direction; empty once there is no more.
+	NextCursor    []byte `protobuf:"bytes,3,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatHistoryResponse) Reset() {
+	*x = ChatHistoryResponse{}
+	mi := &file_umbrapb_chat_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatHistoryResponse) ProtoMessage() {}
+
+func (x *ChatHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_chat_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatHistoryResponse.ProtoReflect.Descriptor instead.
+func (*ChatHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_chat_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ChatHistoryResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ChatHistoryResponse) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *ChatHistoryResponse) GetNextCursor() []byte {
+	if x != nil {
+		return x.NextCursor
+	}
+	return nil
+}
+
+var File_umbrapb_chat_proto protoreflect.FileDescriptor
+
+const file_umbrapb_chat_proto_rawDesc = "" +
+	"\n" +
+	"\x12umbrapb/chat.proto\x12\bumbra.v1\"\xc4\x02\n" +
+	"\vChatMessage\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\fR\x04uuid\x12\x1d\n" +
+	"\n" +
+	"group_uuid\x18\x02 \x01(\fR\tgroupUuid\x12\x1a\n" +
+	"\tx_pub_key\x18\x03 \x01(\fR\axPubKey\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x12\x1f\n" +
+	"\vsender_uuid\x18\x05 \x01(\fR\n" +
+	"senderUuid\x12)\n" +
+	"\x10sender_signature\x18\x06 \x01(\fR\x0fsenderSignature\x127\n" +
+	"\x18created_at_unix_millisec\x18\a \x01(\x03R\x15createdAtUnixMillisec\x12\x14\n" +
+	"\x05nonce\x18\b \x01(\fR\x05nonce\x121\n" +
+	"\x15sent_at_unix_millisec\x18\t \x01(\x03R\x12sentAtUnixMillisec\"\x06\n" +
+	"\x04Ping\"\x06\n" +
+	"\x04Pong\"\x9b\x01\n" +
+	"\tWSMessage\x12$\n" +
+	"\x04ping\x18\x01 \x01(\v2\x0e.umbra.v1.PingH\x00R\x04ping\x12$\n" +
+	"\x04pong\x18\x02 \x01(\v2\x0e.umbra.v1.PongH\x00R\x04pong\x12:\n" +
+	"\fchat_message\x18\x03 \x01(\v2\x15.umbra.v1.ChatMessageH\x00R\vchatMessageB\x06\n" +
+	"\x04body\"b\n" +
+	"\x17ChatMessageSendResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12/\n" +
+	"\amessage\x18\x02 \x01(\v2\x15.umbra.v1.ChatMessageR\amessage\"w\n" +
+	"\x12ChatHistoryRequest\x12\x1d\n" +
+	"\n" +
+	"group_uuid\x18\x01 \x01(\fR\tgroupUuid\x12\x16\n" +
+	"\x06before\x18\x02 \x01(\fR\x06before\x12\x14\n" +
+	"\x05after\x18\x03 \x01(\fR\x05after\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\rR\x05limit\"\x81\x01\n" +
+	"\x13ChatHistoryResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x121\n" +
+	"\bmessages\x18\x02 \x03(\v2\x15.umbra.v1.ChatMessageR\bmessages\x12\x1f\n" +
+	"\vnext_cursor\x18\x03 \x01(\fR\n" +
+	"nextCursorB*Z(github.com/MHSarmadi/Umbra/Proto/umbrapbb\x06proto3"
+
+var (
+	file_umbrapb_chat_proto_rawDescOnce sync.Once
+	file_umbrapb_chat_proto_rawDescData []byte
+)
+
+func file_umbrapb_chat_proto_rawDescGZIP() []byte {
+	file_umbrapb_chat_proto_rawDescOnce.Do(func() {
+		file_umbrapb_chat_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_umbrapb_chat_proto_rawDesc), len(file_umbrapb_chat_proto_rawDesc)))
+	})
+	return file_umbrapb_chat_proto_rawDescData
+}
+
+var file_umbrapb_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_umbrapb_chat_proto_goTypes = []any{
+	(*ChatMessage)(nil),             // 0: umbra.v1.ChatMessage
+	(*Ping)(nil),                    // 1: umbra.v1.Ping
+	(*Pong)(nil),                    // 2: umbra.v1.Pong
+	(*WSMessage)(nil),               // 3: umbra.v1.WSMessage
+	(*ChatMessageSendResponse)(nil), // 4: umbra.v1.ChatMessageSendResponse
+	(*ChatHistoryRequest)(nil),      // 5: umbra.v1.ChatHistoryRequest
+	(*ChatHistoryResponse)(nil),     // 6: umbra.v1.ChatHistoryResponse
+}
+var file_umbrapb_chat_proto_depIdxs = []int32{
+	1, // 0: umbra.v1.WSMessage.ping:type_name -> umbra.v1.Ping
+	2, // 1: umbra.v1.WSMessage.pong:type_name -> umbra.v1.Pong
+	0, // 2: umbra.v1.WSMessage.chat_message:type_name -> umbra.v1.ChatMessage
+	0, // 3: umbra.v1.ChatMessageSendResponse.message:type_name -> umbra.v1.ChatMessage
+	0, // 4: umbra.v1.ChatHistoryResponse.messages:type_name -> umbra.v1.ChatMessage
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_umbrapb_chat_proto_init() }
+func file_umbrapb_chat_proto_init() {
+	if File_umbrapb_chat_proto != nil {
+		return
+	}
+	file_umbrapb_chat_proto_msgTypes[3].OneofWrappers = []any{
+		(*WSMessage_Ping)(nil),
+		(*WSMessage_Pong)(nil),
+		(*WSMessage_ChatMessage)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_umbrapb_chat_proto_rawDesc), len(file_umbrapb_chat_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_umbrapb_chat_proto_goTypes,
+		DependencyIndexes: file_umbrapb_chat_proto_depIdxs,
+		MessageInfos:      file_umbrapb_chat_proto_msgTypes,
+	}.Build()
+	File_umbrapb_chat_proto = out.File
+	file_umbrapb_chat_proto_goTypes = nil
+	file_umbrapb_chat_proto_depIdxs = nil
+}
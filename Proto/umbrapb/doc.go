@@ -0,0 +1,4 @@
+// Package umbrapb holds the proto3 wire schema shared by the Umbra Server and
+// the WASM Client. Regenerate with ../scripts/generate.sh after editing a
+// .proto file.
+package umbrapb
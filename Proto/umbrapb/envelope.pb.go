@@ -0,0 +1,227 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: umbrapb/envelope.proto
+
+package umbrapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Envelope wraps every post-handshake request. The payload is sealed under the
+// session shared key and the whole envelope is signed by the client session key.
+type Envelope struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	SessionId             []byte                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Nonce                 []byte                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	TimestampUnixMillisec uint64                 `protobuf:"varint,3,opt,name=timestamp_unix_millisec,json=timestampUnixMillisec,proto3" json:"timestamp_unix_millisec,omitempty"`
+	// MACE-AEAD sealed request body: salt (12) || tag (16) || cipher.
+	Payload       []byte `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	Signature     []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_umbrapb_envelope_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_envelope_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_umbrapb_envelope_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Envelope) GetSessionId() []byte {
+	if x != nil {
+		return x.SessionId
+	}
+	return nil
+}
+
+func (x *Envelope) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *Envelope) GetTimestampUnixMillisec() uint64 {
+	if x != nil {
+		return x.TimestampUnixMillisec
+	}
+	return 0
+}
+
+func (x *Envelope) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Envelope) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+type EnvelopeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
+	Signature     []byte                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnvelopeResponse) Reset() {
+	*x = EnvelopeResponse{}
+	mi := &file_umbrapb_envelope_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnvelopeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnvelopeResponse) ProtoMessage() {}
+
+func (x *EnvelopeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_envelope_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnvelopeResponse.ProtoReflect.Descriptor instead.
+func (*EnvelopeResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_envelope_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EnvelopeResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *EnvelopeResponse) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *EnvelopeResponse) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+var File_umbrapb_envelope_proto protoreflect.FileDescriptor
+
+const file_umbrapb_envelope_proto_rawDesc = "" +
+	"\n" +
+	"\x16umbrapb/envelope.proto\x12\bumbra.v1\"\xaf\x01\n" +
+	"\bEnvelope\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\fR\tsessionId\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\fR\x05nonce\x126\n" +
+	"\x17timestamp_unix_millisec\x18\x03 \x01(\x04R\x15timestampUnixMillisec\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x12\x1c\n" +
+	"\tsignature\x18\x05 \x01(\fR\tsignature\"b\n" +
+	"\x10EnvelopeResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\apayload\x18\x02 \x01(\fR\apayload\x12\x1c\n" +
+	"\tsignature\x18\x03 \x01(\fR\tsignatureB*Z(github.com/MHSarmadi/Umbra/Proto/umbrapbb\x06proto3"
+
+var (
+	file_umbrapb_envelope_proto_rawDescOnce sync.Once
+	file_umbrapb_envelope_proto_rawDescData []byte
+)
+
+func file_umbrapb_envelope_proto_rawDescGZIP() []byte {
+	file_umbrapb_envelope_proto_rawDescOnce.Do(func() {
+		file_umbrapb_envelope_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_umbrapb_envelope_proto_rawDesc), len(file_umbrapb_envelope_proto_rawDesc)))
+	})
+	return file_umbrapb_envelope_proto_rawDescData
+}
+
+var file_umbrapb_envelope_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_umbrapb_envelope_proto_goTypes = []any{
+	(*Envelope)(nil),         // 0: umbra.v1.Envelope
+	(*EnvelopeResponse)(nil), // 1: umbra.v1.EnvelopeResponse
+}
+var file_umbrapb_envelope_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_umbrapb_envelope_proto_init() }
+func file_umbrapb_envelope_proto_init() {
+	if File_umbrapb_envelope_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_umbrapb_envelope_proto_rawDesc), len(file_umbrapb_envelope_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_umbrapb_envelope_proto_goTypes,
+		DependencyIndexes: file_umbrapb_envelope_proto_depIdxs,
+		MessageInfos:      file_umbrapb_envelope_proto_msgTypes,
+	}.Build()
+	File_umbrapb_envelope_proto = out.File
+	file_umbrapb_envelope_proto_goTypes = nil
+	file_umbrapb_envelope_proto_depIdxs = nil
+}
@@ -0,0 +1,660 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: umbrapb/group.proto
+
+package umbrapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// WrappedGroupSecret is the group secret sealed on the client to one member's
+// X25519 key, using the ephemeral x_pub_key.
+type WrappedGroupSecret struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	XPubKey       []byte                 `protobuf:"bytes,1,opt,name=x_pub_key,json=xPubKey,proto3" json:"x_pub_key,omitempty"`
+	Cipher        []byte                 `protobuf:"bytes,2,opt,name=cipher,proto3" json:"cipher,omitempty"`
+	Salt          []byte                 `protobuf:"bytes,3,opt,name=salt,proto3" json:"salt,omitempty"`
+	Tag           []byte                 `protobuf:"bytes,4,opt,name=tag,proto3" json:"tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WrappedGroupSecret) Reset() {
+	*x = WrappedGroupSecret{}
+	mi := &file_umbrapb_group_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WrappedGroupSecret) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WrappedGroupSecret) ProtoMessage() {}
+
+func (x *WrappedGroupSecret) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_group_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WrappedGroupSecret.ProtoReflect.Descriptor instead.
+func (*WrappedGroupSecret) Descriptor() ([]byte, []int) {
+	return file_umbrapb_group_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WrappedGroupSecret) GetXPubKey() []byte {
+	if x != nil {
+		return x.XPubKey
+	}
+	return nil
+}
+
+func (x *WrappedGroupSecret) GetCipher() []byte {
+	if x != nil {
+		return x.Cipher
+	}
+	return nil
+}
+
+func (x *WrappedGroupSecret) GetSalt() []byte {
+	if x != nil {
+		return x.Salt
+	}
+	return nil
+}
+
+func (x *WrappedGroupSecret) GetTag() []byte {
+	if x != nil {
+		return x.Tag
+	}
+	return nil
+}
+
+type Group struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	Uuid                      []byte                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	XPubKey                   []byte                 `protobuf:"bytes,2,opt,name=x_pub_key,json=xPubKey,proto3" json:"x_pub_key,omitempty"`
+	EPubKey                   []byte                 `protobuf:"bytes,3,opt,name=e_pub_key,json=ePubKey,proto3" json:"e_pub_key,omitempty"`
+	EncipheredEntranceKey     []byte                 `protobuf:"bytes,4,opt,name=enciphered_entrance_key,json=encipheredEntranceKey,proto3" json:"enciphered_entrance_key,omitempty"`
+	EncipheredEntranceKeySalt []byte                 `protobuf:"bytes,5,opt,name=enciphered_entrance_key_salt,json=encipheredEntranceKeySalt,proto3" json:"enciphered_entrance_key_salt,omitempty"`
+	EncipheredEntranceKeyTag  []byte                 `protobuf:"bytes,6,opt,name=enciphered_entrance_key_tag,json=encipheredEntranceKeyTag,proto3" json:"enciphered_entrance_key_tag,omitempty"`
+	CreatorUuid               []byte                 `protobuf:"bytes,7,opt,name=creator_uuid,json=creatorUuid,proto3" json:"creator_uuid,omitempty"`
+	CreatedAtUnixMillisec     int64                  `protobuf:"varint,8,opt,name=created_at_unix_millisec,json=createdAtUnixMillisec,proto3" json:"created_at_unix_millisec,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *Group) Reset() {
+	*x = Group{}
+	mi := &file_umbrapb_group_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Group) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Group) ProtoMessage() {}
+
+func (x *Group) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_group_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Group.ProtoReflect.Descriptor instead.
+func (*Group) Descriptor() ([]byte, []int) {
+	return file_umbrapb_group_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Group) GetUuid() []byte {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+func (x *Group) GetXPubKey() []byte {
+	if x != nil {
+		return x.XPubKey
+	}
+	return nil
+}
+
+func (x *Group) GetEPubKey() []byte {
+	if x != nil {
+		return x.EPubKey
+	}
+	return nil
+}
+
+func (x *Group) GetEncipheredEntranceKey() []byte {
+	if x != nil {
+		return x.EncipheredEntranceKey
+	}
+	return nil
+}
+
+func (x *Group) GetEncipheredEntranceKeySalt() []byte {
+	if x != nil {
+		return x.EncipheredEntranceKeySalt
+	}
+	return nil
+}
+
+func (x *Group) GetEncipheredEntranceKeyTag() []byte {
+	if x != nil {
+		return x.EncipheredEntranceKeyTag
+	}
+	return nil
+}
+
+func (x *Group) GetCreatorUuid() []byte {
+	if x != nil {
+		return x.CreatorUuid
+	}
+	return nil
+}
+
+func (x *Group) GetCreatedAtUnixMillisec() int64 {
+	if x != nil {
+		return x.CreatedAtUnixMillisec
+	}
+	return 0
+}
+
+type GroupMembership struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         *Group                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	Secret        *WrappedGroupSecret    `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupMembership) Reset() {
+	*x = GroupMembership{}
+	mi := &file_umbrapb_group_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupMembership) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupMembership) ProtoMessage() {}
+
+func (x *GroupMembership) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_group_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupMembership.ProtoReflect.Descriptor instead.
+func (*GroupMembership) Descriptor() ([]byte, []int) {
+	return file_umbrapb_group_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GroupMembership) GetGroup() *Group {
+	if x != nil {
+		return x.Group
+	}
+	return nil
+}
+
+func (x *GroupMembership) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *GroupMembership) GetSecret() *WrappedGroupSecret {
+	if x != nil {
+		return x.Secret
+	}
+	return nil
+}
+
+type GroupCreateRequest struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	XPubKey                   []byte                 `protobuf:"bytes,1,opt,name=x_pub_key,json=xPubKey,proto3" json:"x_pub_key,omitempty"`
+	EPubKey                   []byte                 `protobuf:"bytes,2,opt,name=e_pub_key,json=ePubKey,proto3" json:"e_pub_key,omitempty"`
+	EncipheredEntranceKey     []byte                 `protobuf:"bytes,3,opt,name=enciphered_entrance_key,json=encipheredEntranceKey,proto3" json:"enciphered_entrance_key,omitempty"`
+	EncipheredEntranceKeySalt []byte                 `protobuf:"bytes,4,opt,name=enciphered_entrance_key_salt,json=encipheredEntranceKeySalt,proto3" json:"enciphered_entrance_key_salt,omitempty"`
+	EncipheredEntranceKeyTag  []byte                 `protobuf:"bytes,5,opt,name=enciphered_entrance_key_tag,json=encipheredEntranceKeyTag,proto3" json:"enciphered_entrance_key_tag,omitempty"`
+	// The group secret wrapped to the creator's own X25519 key.
+	Secret        *WrappedGroupSecret `protobuf:"bytes,6,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupCreateRequest) Reset() {
+	*x = GroupCreateRequest{}
+	mi := &file_umbrapb_group_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupCreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupCreateRequest) ProtoMessage() {}
+
+func (x *GroupCreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_group_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupCreateRequest.ProtoReflect.Descriptor instead.
+func (*GroupCreateRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_group_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GroupCreateRequest) GetXPubKey() []byte {
+	if x != nil {
+		return x.XPubKey
+	}
+	return nil
+}
+
+func (x *GroupCreateRequest) GetEPubKey() []byte {
+	if x != nil {
+		return x.EPubKey
+	}
+	return nil
+}
+
+func (x *GroupCreateRequest) GetEncipheredEntranceKey() []byte {
+	if x != nil {
+		return x.EncipheredEntranceKey
+	}
+	return nil
+}
+
+func (x *GroupCreateRequest) GetEncipheredEntranceKeySalt() []byte {
+	if x != nil {
+		return x.EncipheredEntranceKeySalt
+	}
+	return nil
+}
+
+func (x *GroupCreateRequest) GetEncipheredEntranceKeyTag() []byte {
+	if x != nil {
+		return x.EncipheredEntranceKeyTag
+	}
+	return nil
+}
+
+func (x *GroupCreateRequest) GetSecret() *WrappedGroupSecret {
+	if x != nil {
+		return x.Secret
+	}
+	return nil
+}
+
+type GroupCreateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Uuid          []byte                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupCreateResponse) Reset() {
+	*x = GroupCreateResponse{}
+	mi := &file_umbrapb_group_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupCreateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupCreateResponse) ProtoMessage() {}
+
+func (x *GroupCreateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_group_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupCreateResponse.ProtoReflect.Descriptor instead.
+func (*GroupCreateResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_group_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GroupCreateResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GroupCreateResponse) GetUuid() []byte {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+type GroupListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Groups        []*GroupMembership     `protobuf:"bytes,2,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupListResponse) Reset() {
+	*x = GroupListResponse{}
+	mi := &file_umbrapb_group_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupListResponse) ProtoMessage() {}
+
+func (x *GroupListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_group_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupListResponse.ProtoReflect.Descriptor instead.
+func (*GroupListResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_group_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GroupListResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GroupListResponse) GetGroups() []*GroupMembership {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type GroupMemberAddRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	GroupUuid []byte                 `protobuf:"bytes,1,opt,name=group_uuid,json=groupUuid,proto3" json:"group_uuid,omitempty"`
+	UserUuid  []byte                 `protobuf:"bytes,2,opt,name=user_uuid,json=userUuid,proto3" json:"user_uuid,omitempty"`
+	// The group secret wrapped to the new member's X25519 key.
+	Secret        *WrappedGroupSecret `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupMemberAddRequest) Reset() {
+	*x = GroupMemberAddRequest{}
+	mi := &file_umbrapb_group_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupMemberAddRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupMemberAddRequest) ProtoMessage() {}
+
+func (x *GroupMemberAddRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_group_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupMemberAddRequest.ProtoReflect.Descriptor instead.
+func (*GroupMemberAddRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_group_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GroupMemberAddRequest) GetGroupUuid() []byte {
+	if x != nil {
+		return x.GroupUuid
+	}
+	return nil
+}
+
+func (x *GroupMemberAddRequest) GetUserUuid() []byte {
+	if x != nil {
+		return x.UserUuid
+	}
+	return nil
+}
+
+func (x *GroupMemberAddRequest) GetSecret() *WrappedGroupSecret {
+	if x != nil {
+		return x.Secret
+	}
+	return nil
+}
+
+type GroupMemberRemoveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupUuid     []byte                 `protobuf:"bytes,1,opt,name=group_uuid,json=groupUuid,proto3" json:"group_uuid,omitempty"`
+	UserUuid      []byte                 `protobuf:"bytes,2,opt,name=user_uuid,json=userUuid,proto3" json:"user_uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupMemberRemoveRequest) Reset() {
+	*x = GroupMemberRemoveRequest{}
+	mi := &file_umbrapb_group_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupMemberRemoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupMemberRemoveRequest) ProtoMessage() {}
+
+func (x *GroupMemberRemoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_group_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupMemberRemoveRequest.ProtoReflect.Descriptor instead.
+func (*GroupMemberRemoveRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_group_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GroupMemberRemoveRequest) GetGroupUuid() []byte {
+	if x != nil {
+		return x.GroupUuid
+	}
+	return nil
+}
+
+func (x *GroupMemberRemoveRequest) GetUserUuid() []byte {
+	if x != nil {
+		return x.UserUuid
+	}
+	return nil
+}
+
+var File_umbrapb_group_proto protoreflect.FileDescriptor
+
+const file_umbrapb_group_proto_rawDesc = "" +
+	"\n" +
+	"\x13umbrapb/group.proto\x12\bumbra.v1\"n\n" +
+	"\x12WrappedGroupSecret\x12\x1a\n" +
+	"\tx_pub_key\x18\x01 \x01(\fR\axPubKey\x12\x16\n" +
+	"\x06cipher\x18\x02 \x01(\fR\x06cipher\x12\x12\n" +
+	"\x04salt\x18\x03 \x01(\fR\x04salt\x12\x10\n" +
+	"\x03tag\x18\x04 \x01(\fR\x03tag\"\xe7\x02\n" +
+	"\x05Group\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\fR\x04uuid\x12\x1a\n" +
+	"\tx_pub_key\x18\x02 \x01(\fR\axPubKey\x12\x1a\n" +
+	"\te_pub_key\x18\x03 \x01(\fR\aePubKey\x126\n" +
+	"\x17enciphered_entrance_key\x18\x04 \x01(\fR\x15encipheredEntranceKey\x12?\n" +
+	"\x1cenciphered_entrance_key_salt\x18\x05 \x01(\fR\x19encipheredEntranceKeySalt\x12=\n" +
+	"\x1benciphered_entrance_key_tag\x18\x06 \x01(\fR\x18encipheredEntranceKeyTag\x12!\n" +
+	"\fcreator_uuid\x18\a \x01(\fR\vcreatorUuid\x127\n" +
+	"\x18created_at_unix_millisec\x18\b \x01(\x03R\x15createdAtUnixMillisec\"\x82\x01\n" +
+	"\x0fGroupMembership\x12%\n" +
+	"\x05group\x18\x01 \x01(\v2\x0f.umbra.v1.GroupR\x05group\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x124\n" +
+	"\x06secret\x18\x03 \x01(\v2\x1c.umbra.v1.WrappedGroupSecretR\x06secret\"\xba\x02\n" +
+	"\x12GroupCreateRequest\x12\x1a\n" +
+	"\tx_pub_key\x18\x01 \x01(\fR\axPubKey\x12\x1a\n" +
+	"\te_pub_key\x18\x02 \x01(\fR\aePubKey\x126\n" +
+	"\x17enciphered_entrance_key\x18\x03 \x01(\fR\x15encipheredEntranceKey\x12?\n" +
+	"\x1cenciphered_entrance_key_salt\x18\x04 \x01(\fR\x19encipheredEntranceKeySalt\x12=\n" +
+	"\x1benciphered_entrance_key_tag\x18\x05 \x01(\fR\x18encipheredEntranceKeyTag\x124\n" +
+	"\x06secret\x18\x06 \x01(\v2\x1c.umbra.v1.WrappedGroupSecretR\x06secret\"A\n" +
+	"\x13GroupCreateResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\fR\x04uuid\"^\n" +
+	"\x11GroupListResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x121\n" +
+	"\x06groups\x18\x02 \x03(\v2\x19.umbra.v1.GroupMembershipR\x06groups\"\x89\x01\n" +
+	"\x15GroupMemberAddRequest\x12\x1d\n" +
+	"\n" +
+	"group_uuid\x18\x01 \x01(\fR\tgroupUuid\x12\x1b\n" +
+	"\tuser_uuid\x18\x02 \x01(\fR\buserUuid\x124\n" +
+	"\x06secret\x18\x03 \x01(\v2\x1c.umbra.v1.WrappedGroupSecretR\x06secret\"V\n" +
+	"\x18GroupMemberRemoveRequest\x12\x1d\n" +
+	"\n" +
+	"group_uuid\x18\x01 \x01(\fR\tgroupUuid\x12\x1b\n" +
+	"\tuser_uuid\x18\x02 \x01(\fR\buserUuidB*Z(github.com/MHSarmadi/Umbra/Proto/umbrapbb\x06proto3"
+
+var (
+	file_umbrapb_group_proto_rawDescOnce sync.Once
+	file_umbrapb_group_proto_rawDescData []byte
+)
+
+func file_umbrapb_group_proto_rawDescGZIP() []byte {
+	file_umbrapb_group_proto_rawDescOnce.Do(func() {
+		file_umbrapb_group_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_umbrapb_group_proto_rawDesc), len(file_umbrapb_group_proto_rawDesc)))
+	})
+	return file_umbrapb_group_proto_rawDescData
+}
+
+var file_umbrapb_group_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_umbrapb_group_proto_goTypes = []any{
+	(*WrappedGroupSecret)(nil),       // 0: umbra.v1.WrappedGroupSecret
+	(*Group)(nil),                    // 1: umbra.v1.Group
+	(*GroupMembership)(nil),          // 2: umbra.v1.GroupMembership
+	(*GroupCreateRequest)(nil),       // 3: umbra.v1.GroupCreateRequest
+	(*GroupCreateResponse)(nil),      // 4: umbra.v1.GroupCreateResponse
+	(*GroupListResponse)(nil),        // 5: umbra.v1.GroupListResponse
+	(*GroupMemberAddRequest)(nil),    // 6: umbra.v1.GroupMemberAddRequest
+	(*GroupMemberRemoveRequest)(nil), // 7: umbra.v1.GroupMemberRemoveRequest
+}
+var file_umbrapb_group_proto_depIdxs = []int32{
+	1, // 0: umbra.v1.GroupMembership.group:type_name -> umbra.v1.Group
+	0, // 1: umbra.v1.GroupMembership.secret:type_name -> umbra.v1.WrappedGroupSecret
+	0, // 2: umbra.v1.GroupCreateRequest.secret:type_name -> umbra.v1.WrappedGroupSecret
+	2, // 3: umbra.v1.GroupListResponse.groups:type_name -> umbra.v1.GroupMembership
+	0, // 4: umbra.v1.GroupMemberAddRequest.secret:type_name -> umbra.v1.WrappedGroupSecret
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_umbrapb_group_proto_init() }
+func file_umbrapb_group_proto_init() {
+	if File_umbrapb_group_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_umbrapb_group_proto_rawDesc), len(file_umbrapb_group_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_umbrapb_group_proto_goTypes,
+		DependencyIndexes: file_umbrapb_group_proto_depIdxs,
+		MessageInfos:      file_umbrapb_group_proto_msgTypes,
+	}.Build()
+	File_umbrapb_group_proto = out.File
+	file_umbrapb_group_proto_goTypes = nil
+	file_umbrapb_group_proto_depIdxs = nil
+}
@@ -0,0 +1,686 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: umbrapb/session.proto
+
+package umbrapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SessionInitRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ClientEdPubkey    []byte                 `protobuf:"bytes,1,opt,name=client_ed_pubkey,json=clientEdPubkey,proto3" json:"client_ed_pubkey,omitempty"`
+	ClientXPubkey     []byte                 `protobuf:"bytes,2,opt,name=client_x_pubkey,json=clientXPubkey,proto3" json:"client_x_pubkey,omitempty"`
+	ClientXPubkeySign []byte                 `protobuf:"bytes,3,opt,name=client_x_pubkey_sign,json=clientXPubkeySign,proto3" json:"client_x_pubkey_sign,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *SessionInitRequest) Reset() {
+	*x = SessionInitRequest{}
+	mi := &file_umbrapb_session_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInitRequest) ProtoMessage() {}
+
+func (x *SessionInitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_session_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInitRequest.ProtoReflect.Descriptor instead.
+func (*SessionInitRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_session_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SessionInitRequest) GetClientEdPubkey() []byte {
+	if x != nil {
+		return x.ClientEdPubkey
+	}
+	return nil
+}
+
+func (x *SessionInitRequest) GetClientXPubkey() []byte {
+	if x != nil {
+		return x.ClientXPubkey
+	}
+	return nil
+}
+
+func (x *SessionInitRequest) GetClientXPubkeySign() []byte {
+	if x != nil {
+		return x.ClientXPubkeySign
+	}
+	return nil
+}
+
+type SessionInitResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Status            string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	ServerEdPubkey    []byte                 `protobuf:"bytes,2,opt,name=server_ed_pubkey,json=serverEdPubkey,proto3" json:"server_ed_pubkey,omitempty"`
+	ServerXPubkey     []byte                 `protobuf:"bytes,3,opt,name=server_x_pubkey,json=serverXPubkey,proto3" json:"server_x_pubkey,omitempty"`
+	ServerXPubkeySign []byte                 `protobuf:"bytes,4,opt,name=server_x_pubkey_sign,json=serverXPubkeySign,proto3" json:"server_x_pubkey_sign,omitempty"`
+	// MACE-AEAD sealed SessionInitPayload: salt (12) || tag (16) || cipher.
+	Payload            []byte `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	Signature          []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+	ExpiryUnixMillisec uint64 `protobuf:"varint,7,opt,name=expiry_unix_millisec,json=expiryUnixMillisec,proto3" json:"expiry_unix_millisec,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *SessionInitResponse) Reset() {
+	*x = SessionInitResponse{}
+	mi := &file_umbrapb_session_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInitResponse) ProtoMessage() {}
+
+func (x *SessionInitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_session_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInitResponse.ProtoReflect.Descriptor instead.
+func (*SessionInitResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_session_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SessionInitResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SessionInitResponse) GetServerEdPubkey() []byte {
+	if x != nil {
+		return x.ServerEdPubkey
+	}
+	return nil
+}
+
+func (x *SessionInitResponse) GetServerXPubkey() []byte {
+	if x != nil {
+		return x.ServerXPubkey
+	}
+	return nil
+}
+
+func (x *SessionInitResponse) GetServerXPubkeySign() []byte {
+	if x != nil {
+		return x.ServerXPubkeySign
+	}
+	return nil
+}
+
+func (x *SessionInitResponse) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *SessionInitResponse) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *SessionInitResponse) GetExpiryUnixMillisec() uint64 {
+	if x != nil {
+		return x.ExpiryUnixMillisec
+	}
+	return 0
+}
+
+type PoWParams struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MemoryMb      uint32                 `protobuf:"varint,1,opt,name=memory_mb,json=memoryMb,proto3" json:"memory_mb,omitempty"`
+	Iterations    uint32                 `protobuf:"varint,2,opt,name=iterations,proto3" json:"iterations,omitempty"`
+	Parallelism   uint32                 `protobuf:"varint,3,opt,name=parallelism,proto3" json:"parallelism,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PoWParams) Reset() {
+	*x = PoWParams{}
+	mi := &file_umbrapb_session_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PoWParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoWParams) ProtoMessage() {}
+
+func (x *PoWParams) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_session_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoWParams.ProtoReflect.Descriptor instead.
+func (*PoWParams) Descriptor() ([]byte, []int) {
+	return file_umbrapb_session_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PoWParams) GetMemoryMb() uint32 {
+	if x != nil {
+		return x.MemoryMb
+	}
+	return 0
+}
+
+func (x *PoWParams) GetIterations() uint32 {
+	if x != nil {
+		return x.Iterations
+	}
+	return 0
+}
+
+func (x *PoWParams) GetParallelism() uint32 {
+	if x != nil {
+		return x.Parallelism
+	}
+	return 0
+}
+
+type SessionInitPayload struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId                 []byte                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	CaptchaChallenge          []byte                 `protobuf:"bytes,2,opt,name=captcha_challenge,json=captchaChallenge,proto3" json:"captcha_challenge,omitempty"`
+	PowChallenge              []byte                 `protobuf:"bytes,3,opt,name=pow_challenge,json=powChallenge,proto3" json:"pow_challenge,omitempty"`
+	PowParams                 *PoWParams             `protobuf:"bytes,4,opt,name=pow_params,json=powParams,proto3" json:"pow_params,omitempty"`
+	PowSalt                   []byte                 `protobuf:"bytes,5,opt,name=pow_salt,json=powSalt,proto3" json:"pow_salt,omitempty"`
+	SessionTokenCiphered      []byte                 `protobuf:"bytes,6,opt,name=session_token_ciphered,json=sessionTokenCiphered,proto3" json:"session_token_ciphered,omitempty"`
+	SessionTokenCipherKeySalt []byte                 `protobuf:"bytes,7,opt,name=session_token_cipher_key_salt,json=sessionTokenCipherKeySalt,proto3" json:"session_token_cipher_key_salt,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *SessionInitPayload) Reset() {
+	*x = SessionInitPayload{}
+	mi := &file_umbrapb_session_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInitPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInitPayload) ProtoMessage() {}
+
+func (x *SessionInitPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_session_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInitPayload.ProtoReflect.Descriptor instead.
+func (*SessionInitPayload) Descriptor() ([]byte, []int) {
+	return file_umbrapb_session_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SessionInitPayload) GetSessionId() []byte {
+	if x != nil {
+		return x.SessionId
+	}
+	return nil
+}
+
+func (x *SessionInitPayload) GetCaptchaChallenge() []byte {
+	if x != nil {
+		return x.CaptchaChallenge
+	}
+	return nil
+}
+
+func (x *SessionInitPayload) GetPowChallenge() []byte {
+	if x != nil {
+		return x.PowChallenge
+	}
+	return nil
+}
+
+func (x *SessionInitPayload) GetPowParams() *PoWParams {
+	if x != nil {
+		return x.PowParams
+	}
+	return nil
+}
+
+func (x *SessionInitPayload) GetPowSalt() []byte {
+	if x != nil {
+		return x.PowSalt
+	}
+	return nil
+}
+
+func (x *SessionInitPayload) GetSessionTokenCiphered() []byte {
+	if x != nil {
+		return x.SessionTokenCiphered
+	}
+	return nil
+}
+
+func (x *SessionInitPayload) GetSessionTokenCipherKeySalt() []byte {
+	if x != nil {
+		return x.SessionTokenCipherKeySalt
+	}
+	return nil
+}
+
+type SessionPoWRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     []byte                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Nonce         []byte                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionPoWRequest) Reset() {
+	*x = SessionPoWRequest{}
+	mi := &file_umbrapb_session_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionPoWRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionPoWRequest) ProtoMessage() {}
+
+func (x *SessionPoWRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_session_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionPoWRequest.ProtoReflect.Descriptor instead.
+func (*SessionPoWRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_session_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SessionPoWRequest) GetSessionId() []byte {
+	if x != nil {
+		return x.SessionId
+	}
+	return nil
+}
+
+func (x *SessionPoWRequest) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+type SessionPoWResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Status          string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	ActivationNonce []byte                 `protobuf:"bytes,2,opt,name=activation_nonce,json=activationNonce,proto3" json:"activation_nonce,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SessionPoWResponse) Reset() {
+	*x = SessionPoWResponse{}
+	mi := &file_umbrapb_session_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionPoWResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionPoWResponse) ProtoMessage() {}
+
+func (x *SessionPoWResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_session_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionPoWResponse.ProtoReflect.Descriptor instead.
+func (*SessionPoWResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_session_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SessionPoWResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SessionPoWResponse) GetActivationNonce() []byte {
+	if x != nil {
+		return x.ActivationNonce
+	}
+	return nil
+}
+
+type SessionActivateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     []byte                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Proof         []byte                 `protobuf:"bytes,2,opt,name=proof,proto3" json:"proof,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionActivateRequest) Reset() {
+	*x = SessionActivateRequest{}
+	mi := &file_umbrapb_session_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionActivateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionActivateRequest) ProtoMessage() {}
+
+func (x *SessionActivateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_session_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionActivateRequest.ProtoReflect.Descriptor instead.
+func (*SessionActivateRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_session_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SessionActivateRequest) GetSessionId() []byte {
+	if x != nil {
+		return x.SessionId
+	}
+	return nil
+}
+
+func (x *SessionActivateRequest) GetProof() []byte {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_umbrapb_session_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_session_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_session_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StatusResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type SessionPingResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	State              string                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	ExpiryUnixMillisec uint64                 `protobuf:"varint,2,opt,name=expiry_unix_millisec,json=expiryUnixMillisec,proto3" json:"expiry_unix_millisec,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *SessionPingResponse) Reset() {
+	*x = SessionPingResponse{}
+	mi := &file_umbrapb_session_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionPingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionPingResponse) ProtoMessage() {}
+
+func (x *SessionPingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_session_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionPingResponse.ProtoReflect.Descriptor instead.
+func (*SessionPingResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_session_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SessionPingResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *SessionPingResponse) GetExpiryUnixMillisec() uint64 {
+	if x != nil {
+		return x.ExpiryUnixMillisec
+	}
+	return 0
+}
+
+var File_umbrapb_session_proto protoreflect.FileDescriptor
+
+const file_umbrapb_session_proto_rawDesc = "" +
+	"\n" +
+	"\x15umbrapb/session.proto\x12\bumbra.v1\"\x97\x01\n" +
+	"\x12SessionInitRequest\x12(\n" +
+	"\x10client_ed_pubkey\x18\x01 \x01(\fR\x0eclientEdPubkey\x12&\n" +
+	"\x0fclient_x_pubkey\x18\x02 \x01(\fR\rclientXPubkey\x12/\n" +
+	"\x14client_x_pubkey_sign\x18\x03 \x01(\fR\x11clientXPubkeySign\"\x9a\x02\n" +
+	"\x13SessionInitResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12(\n" +
+	"\x10server_ed_pubkey\x18\x02 \x01(\fR\x0eserverEdPubkey\x12&\n" +
+	"\x0fserver_x_pubkey\x18\x03 \x01(\fR\rserverXPubkey\x12/\n" +
+	"\x14server_x_pubkey_sign\x18\x04 \x01(\fR\x11serverXPubkeySign\x12\x18\n" +
+	"\apayload\x18\x05 \x01(\fR\apayload\x12\x1c\n" +
+	"\tsignature\x18\x06 \x01(\fR\tsignature\x120\n" +
+	"\x14expiry_unix_millisec\x18\a \x01(\x04R\x12expiryUnixMillisec\"j\n" +
+	"\tPoWParams\x12\x1b\n" +
+	"\tmemory_mb\x18\x01 \x01(\rR\bmemoryMb\x12\x1e\n" +
+	"\n" +
+	"iterations\x18\x02 \x01(\rR\n" +
+	"iterations\x12 \n" +
+	"\vparallelism\x18\x03 \x01(\rR\vparallelism\"\xcc\x02\n" +
+	"\x12SessionInitPayload\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\fR\tsessionId\x12+\n" +
+	"\x11captcha_challenge\x18\x02 \x01(\fR\x10captchaChallenge\x12#\n" +
+	"\rpow_challenge\x18\x03 \x01(\fR\fpowChallenge\x122\n" +
+	"\n" +
+	"pow_params\x18\x04 \x01(\v2\x13.umbra.v1.PoWParamsR\tpowParams\x12\x19\n" +
+	"\bpow_salt\x18\x05 \x01(\fR\apowSalt\x124\n" +
+	"\x16session_token_ciphered\x18\x06 \x01(\fR\x14sessionTokenCiphered\x12@\n" +
+	"\x1dsession_token_cipher_key_salt\x18\a \x01(\fR\x19sessionTokenCipherKeySalt\"H\n" +
+	"\x11SessionPoWRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\fR\tsessionId\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\fR\x05nonce\"W\n" +
+	"\x12SessionPoWResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12)\n" +
+	"\x10activation_nonce\x18\x02 \x01(\fR\x0factivationNonce\"M\n" +
+	"\x16SessionActivateRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\fR\tsessionId\x12\x14\n" +
+	"\x05proof\x18\x02 \x01(\fR\x05proof\"(\n" +
+	"\x0eStatusResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"]\n" +
+	"\x13SessionPingResponse\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state\x120\n" +
+	"\x14expiry_unix_millisec\x18\x02 \x01(\x04R\x12expiryUnixMillisecB*Z(github.com/MHSarmadi/Umbra/Proto/umbrapbb\x06proto3"
+
+var (
+	file_umbrapb_session_proto_rawDescOnce sync.Once
+	file_umbrapb_session_proto_rawDescData []byte
+)
+
+func file_umbrapb_session_proto_rawDescGZIP() []byte {
+	file_umbrapb_session_proto_rawDescOnce.Do(func() {
+		file_umbrapb_session_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_umbrapb_session_proto_rawDesc), len(file_umbrapb_session_proto_rawDesc)))
+	})
+	return file_umbrapb_session_proto_rawDescData
+}
+
+var file_umbrapb_session_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_umbrapb_session_proto_goTypes = []any{
+	(*SessionInitRequest)(nil),     // 0: umbra.v1.SessionInitRequest
+	(*SessionInitResponse)(nil),    // 1: umbra.v1.SessionInitResponse
+	(*PoWParams)(nil),              // 2: umbra.v1.PoWParams
+	(*SessionInitPayload)(nil),     // 3: umbra.v1.SessionInitPayload
+	(*SessionPoWRequest)(nil),      // 4: umbra.v1.SessionPoWRequest
+	(*SessionPoWResponse)(nil),     // 5: umbra.v1.SessionPoWResponse
+	(*SessionActivateRequest)(nil), // 6: umbra.v1.SessionActivateRequest
+	(*StatusResponse)(nil),         // 7: umbra.v1.StatusResponse
+	(*SessionPingResponse)(nil),    // 8: umbra.v1.SessionPingResponse
+}
+var file_umbrapb_session_proto_depIdxs = []int32{
+	2, // 0: umbra.v1.SessionInitPayload.pow_params:type_name -> umbra.v1.PoWParams
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_umbrapb_session_proto_init() }
+func file_umbrapb_session_proto_init() {
+	if File_umbrapb_session_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_umbrapb_session_proto_rawDesc), len(file_umbrapb_session_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_umbrapb_session_proto_goTypes,
+		DependencyIndexes: file_umbrapb_session_proto_depIdxs,
+		MessageInfos:      file_umbrapb_session_proto_msgTypes,
+	}.Build()
+	File_umbrapb_session_proto = out.File
+	file_umbrapb_session_proto_goTypes = nil
+	file_umbrapb_session_proto_depIdxs = nil
+}
@@ -0,0 +1,1244 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: umbrapb/user.proto
+
+package umbrapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type UserRegisterRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Username           string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	XPubKey            []byte                 `protobuf:"bytes,2,opt,name=x_pub_key,json=xPubKey,proto3" json:"x_pub_key,omitempty"`
+	EPubKey            []byte                 `protobuf:"bytes,3,opt,name=e_pub_key,json=ePubKey,proto3" json:"e_pub_key,omitempty"`
+	EncipheredSoul     []byte                 `protobuf:"bytes,4,opt,name=enciphered_soul,json=encipheredSoul,proto3" json:"enciphered_soul,omitempty"`
+	EncipheredSoulSalt []byte                 `protobuf:"bytes,5,opt,name=enciphered_soul_salt,json=encipheredSoulSalt,proto3" json:"enciphered_soul_salt,omitempty"`
+	EncipheredSoulTag  []byte                 `protobuf:"bytes,6,opt,name=enciphered_soul_tag,json=encipheredSoulTag,proto3" json:"enciphered_soul_tag,omitempty"`
+	SoulRecovery       []byte                 `protobuf:"bytes,7,opt,name=soul_recovery,json=soulRecovery,proto3" json:"soul_recovery,omitempty"`
+	SoulRecoverySalt   []byte                 `protobuf:"bytes,8,opt,name=soul_recovery_salt,json=soulRecoverySalt,proto3" json:"soul_recovery_salt,omitempty"`
+	SoulRecoveryTag    []byte                 `protobuf:"bytes,9,opt,name=soul_recovery_tag,json=soulRecoveryTag,proto3" json:"soul_recovery_tag,omitempty"`
+	// Ed25519 signature by the user soul over the session proof message.
+	Signature     []byte   `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`
+	SoulKdf       *SoulKDF `protobuf:"bytes,11,opt,name=soul_kdf,json=soulKdf,proto3" json:"soul_kdf,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserRegisterRequest) Reset() {
+	*x = UserRegisterRequest{}
+	mi := &file_umbrapb_user_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserRegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRegisterRequest) ProtoMessage() {}
+
+func (x *UserRegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRegisterRequest.ProtoReflect.Descriptor instead.
+func (*UserRegisterRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *UserRegisterRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserRegisterRequest) GetXPubKey() []byte {
+	if x != nil {
+		return x.XPubKey
+	}
+	return nil
+}
+
+func (x *UserRegisterRequest) GetEPubKey() []byte {
+	if x != nil {
+		return x.EPubKey
+	}
+	return nil
+}
+
+func (x *UserRegisterRequest) GetEncipheredSoul() []byte {
+	if x != nil {
+		return x.EncipheredSoul
+	}
+	return nil
+}
+
+func (x *UserRegisterRequest) GetEncipheredSoulSalt() []byte {
+	if x != nil {
+		return x.EncipheredSoulSalt
+	}
+	return nil
+}
+
+func (x *UserRegisterRequest) GetEncipheredSoulTag() []byte {
+	if x != nil {
+		return x.EncipheredSoulTag
+	}
+	return nil
+}
+
+func (x *UserRegisterRequest) GetSoulRecovery() []byte {
+	if x != nil {
+		return x.SoulRecovery
+	}
+	return nil
+}
+
+func (x *UserRegisterRequest) GetSoulRecoverySalt() []byte {
+	if x != nil {
+		return x.SoulRecoverySalt
+	}
+	return nil
+}
+
+func (x *UserRegisterRequest) GetSoulRecoveryTag() []byte {
+	if x != nil {
+		return x.SoulRecoveryTag
+	}
+	return nil
+}
+
+func (x *UserRegisterRequest) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *UserRegisterRequest) GetSoulKdf() *SoulKDF {
+	if x != nil {
+		return x.SoulKdf
+	}
+	return nil
+}
+
+// SoulKDF is how the soul key is derived from the password. Version 0 is the
+// legacy single BLAKE3 hash; later versions are Argon2id with these costs.
+type SoulKDF struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       uint32                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Salt          []byte                 `protobuf:"bytes,2,opt,name=salt,proto3" json:"salt,omitempty"`
+	MemoryKib     uint32                 `protobuf:"varint,3,opt,name=memory_kib,json=memoryKib,proto3" json:"memory_kib,omitempty"`
+	Iterations    uint32                 `protobuf:"varint,4,opt,name=iterations,proto3" json:"iterations,omitempty"`
+	Parallelism   uint32                 `protobuf:"varint,5,opt,name=parallelism,proto3" json:"parallelism,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SoulKDF) Reset() {
+	*x = SoulKDF{}
+	mi := &file_umbrapb_user_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SoulKDF) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SoulKDF) ProtoMessage() {}
+
+func (x *SoulKDF) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SoulKDF.ProtoReflect.Descriptor instead.
+func (*SoulKDF) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SoulKDF) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SoulKDF) GetSalt() []byte {
+	if x != nil {
+		return x.Salt
+	}
+	return nil
+}
+
+func (x *SoulKDF) GetMemoryKib() uint32 {
+	if x != nil {
+		return x.MemoryKib
+	}
+	return 0
+}
+
+func (x *SoulKDF) GetIterations() uint32 {
+	if x != nil {
+		return x.Iterations
+	}
+	return 0
+}
+
+func (x *SoulKDF) GetParallelism() uint32 {
+	if x != nil {
+		return x.Parallelism
+	}
+	return 0
+}
+
+type UserRegisterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Uuid          []byte                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserRegisterResponse) Reset() {
+	*x = UserRegisterResponse{}
+	mi := &file_umbrapb_user_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserRegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRegisterResponse) ProtoMessage() {}
+
+func (x *UserRegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRegisterResponse.ProtoReflect.Descriptor instead.
+func (*UserRegisterResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UserRegisterResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UserRegisterResponse) GetUuid() []byte {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+type UserLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserLoginRequest) Reset() {
+	*x = UserLoginRequest{}
+	mi := &file_umbrapb_user_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserLoginRequest) ProtoMessage() {}
+
+func (x *UserLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserLoginRequest.ProtoReflect.Descriptor instead.
+func (*UserLoginRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UserLoginRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type UserLoginResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Status             string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Uuid               []byte                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	EncipheredSoul     []byte                 `protobuf:"bytes,3,opt,name=enciphered_soul,json=encipheredSoul,proto3" json:"enciphered_soul,omitempty"`
+	EncipheredSoulSalt []byte                 `protobuf:"bytes,4,opt,name=enciphered_soul_salt,json=encipheredSoulSalt,proto3" json:"enciphered_soul_salt,omitempty"`
+	EncipheredSoulTag  []byte                 `protobuf:"bytes,5,opt,name=enciphered_soul_tag,json=encipheredSoulTag,proto3" json:"enciphered_soul_tag,omitempty"`
+	SoulKdf            *SoulKDF               `protobuf:"bytes,6,opt,name=soul_kdf,json=soulKdf,proto3" json:"soul_kdf,omitempty"`
+	// Set when soul_kdf is older than the server's current version. The client
+	// must re-encipher the soul through /user/soul after logging in.
+	UpgradeRequired bool `protobuf:"varint,7,opt,name=upgrade_required,json=upgradeRequired,proto3" json:"upgrade_required,omitempty"`
+	// Parameters to re-encipher under when upgrade_required is set.
+	UpgradeKdf    *SoulKDF `protobuf:"bytes,8,opt,name=upgrade_kdf,json=upgradeKdf,proto3" json:"upgrade_kdf,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserLoginResponse) Reset() {
+	*x = UserLoginResponse{}
+	mi := &file_umbrapb_user_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserLoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserLoginResponse) ProtoMessage() {}
+
+func (x *UserLoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserLoginResponse.ProtoReflect.Descriptor instead.
+func (*UserLoginResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UserLoginResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UserLoginResponse) GetUuid() []byte {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+func (x *UserLoginResponse) GetEncipheredSoul() []byte {
+	if x != nil {
+		return x.EncipheredSoul
+	}
+	return nil
+}
+
+func (x *UserLoginResponse) GetEncipheredSoulSalt() []byte {
+	if x != nil {
+		return x.EncipheredSoulSalt
+	}
+	return nil
+}
+
+func (x *UserLoginResponse) GetEncipheredSoulTag() []byte {
+	if x != nil {
+		return x.EncipheredSoulTag
+	}
+	return nil
+}
+
+func (x *UserLoginResponse) GetSoulKdf() *SoulKDF {
+	if x != nil {
+		return x.SoulKdf
+	}
+	return nil
+}
+
+func (x *UserLoginResponse) GetUpgradeRequired() bool {
+	if x != nil {
+		return x.UpgradeRequired
+	}
+	return false
+}
+
+func (x *UserLoginResponse) GetUpgradeKdf() *SoulKDF {
+	if x != nil {
+		return x.UpgradeKdf
+	}
+	return nil
+}
+
+type UserLoginProofRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Username string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	// Ed25519 signature by the decrypted user soul over the session proof message.
+	Signature     []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserLoginProofRequest) Reset() {
+	*x = UserLoginProofRequest{}
+	mi := &file_umbrapb_user_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserLoginProofRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserLoginProofRequest) ProtoMessage() {}
+
+func (x *UserLoginProofRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserLoginProofRequest.ProtoReflect.Descriptor instead.
+func (*UserLoginProofRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UserLoginProofRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserLoginProofRequest) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+type UserKDFResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// Current soul KDF with a fresh salt, to derive a new soul key under.
+	SoulKdf       *SoulKDF `protobuf:"bytes,2,opt,name=soul_kdf,json=soulKdf,proto3" json:"soul_kdf,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserKDFResponse) Reset() {
+	*x = UserKDFResponse{}
+	mi := &file_umbrapb_user_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserKDFResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserKDFResponse) ProtoMessage() {}
+
+func (x *UserKDFResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserKDFResponse.ProtoReflect.Descriptor instead.
+func (*UserKDFResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UserKDFResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UserKDFResponse) GetSoulKdf() *SoulKDF {
+	if x != nil {
+		return x.SoulKdf
+	}
+	return nil
+}
+
+// UserSoulRequest re-enciphers the soul of the user bound to the session,
+// typically to move it to the current soul KDF.
+type UserSoulRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	EncipheredSoul     []byte                 `protobuf:"bytes,1,opt,name=enciphered_soul,json=encipheredSoul,proto3" json:"enciphered_soul,omitempty"`
+	EncipheredSoulSalt []byte                 `protobuf:"bytes,2,opt,name=enciphered_soul_salt,json=encipheredSoulSalt,proto3" json:"enciphered_soul_salt,omitempty"`
+	EncipheredSoulTag  []byte                 `protobuf:"bytes,3,opt,name=enciphered_soul_tag,json=encipheredSoulTag,proto3" json:"enciphered_soul_tag,omitempty"`
+	SoulRecovery       []byte                 `protobuf:"bytes,4,opt,name=soul_recovery,json=soulRecovery,proto3" json:"soul_recovery,omitempty"`
+	SoulRecoverySalt   []byte                 `protobuf:"bytes,5,opt,name=soul_recovery_salt,json=soulRecoverySalt,proto3" json:"soul_recovery_salt,omitempty"`
+	SoulRecoveryTag    []byte                 `protobuf:"bytes,6,opt,name=soul_recovery_tag,json=soulRecoveryTag,proto3" json:"soul_recovery_tag,omitempty"`
+	SoulKdf            *SoulKDF               `protobuf:"bytes,7,opt,name=soul_kdf,json=soulKdf,proto3" json:"soul_kdf,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *UserSoulRequest) Reset() {
+	*x = UserSoulRequest{}
+	mi := &file_umbrapb_user_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserSoulRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserSoulRequest) ProtoMessage() {}
+
+func (x *UserSoulRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserSoulRequest.ProtoReflect.Descriptor instead.
+func (*UserSoulRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UserSoulRequest) GetEncipheredSoul() []byte {
+	if x != nil {
+		return x.EncipheredSoul
+	}
+	return nil
+}
+
+func (x *UserSoulRequest) GetEncipheredSoulSalt() []byte {
+	if x != nil {
+		return x.EncipheredSoulSalt
+	}
+	return nil
+}
+
+func (x *UserSoulRequest) GetEncipheredSoulTag() []byte {
+	if x != nil {
+		return x.EncipheredSoulTag
+	}
+	return nil
+}
+
+func (x *UserSoulRequest) GetSoulRecovery() []byte {
+	if x != nil {
+		return x.SoulRecovery
+	}
+	return nil
+}
+
+func (x *UserSoulRequest) GetSoulRecoverySalt() []byte {
+	if x != nil {
+		return x.SoulRecoverySalt
+	}
+	return nil
+}
+
+func (x *UserSoulRequest) GetSoulRecoveryTag() []byte {
+	if x != nil {
+		return x.SoulRecoveryTag
+	}
+	return nil
+}
+
+func (x *UserSoulRequest) GetSoulKdf() *SoulKDF {
+	if x != nil {
+		return x.SoulKdf
+	}
+	return nil
+}
+
+type UserRecoveryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserRecoveryRequest) Reset() {
+	*x = UserRecoveryRequest{}
+	mi := &file_umbrapb_user_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserRecoveryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRecoveryRequest) ProtoMessage() {}
+
+func (x *UserRecoveryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRecoveryRequest.ProtoReflect.Descriptor instead.
+func (*UserRecoveryRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UserRecoveryRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+// UserRecoveryResponse carries everything needed to get the soul back with a
+// recovery key: the recovery blob wraps the soul key, which deciphers the
+// enciphered soul.
+type UserRecoveryResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Status             string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Uuid               []byte                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	EncipheredSoul     []byte                 `protobuf:"bytes,3,opt,name=enciphered_soul,json=encipheredSoul,proto3" json:"enciphered_soul,omitempty"`
+	EncipheredSoulSalt []byte                 `protobuf:"bytes,4,opt,name=enciphered_soul_salt,json=encipheredSoulSalt,proto3" json:"enciphered_soul_salt,omitempty"`
+	EncipheredSoulTag  []byte                 `protobuf:"bytes,5,opt,name=enciphered_soul_tag,json=encipheredSoulTag,proto3" json:"enciphered_soul_tag,omitempty"`
+	SoulRecovery       []byte                 `protobuf:"bytes,6,opt,name=soul_recovery,json=soulRecovery,proto3" json:"soul_recovery,omitempty"`
+	SoulRecoverySalt   []byte                 `protobuf:"bytes,7,opt,name=soul_recovery_salt,json=soulRecoverySalt,proto3" json:"soul_recovery_salt,omitempty"`
+	SoulRecoveryTag    []byte                 `protobuf:"bytes,8,opt,name=soul_recovery_tag,json=soulRecoveryTag,proto3" json:"soul_recovery_tag,omitempty"`
+	// Current soul KDF with a fresh salt, to derive the new password's key.
+	NewSoulKdf    *SoulKDF `protobuf:"bytes,9,opt,name=new_soul_kdf,json=newSoulKdf,proto3" json:"new_soul_kdf,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserRecoveryResponse) Reset() {
+	*x = UserRecoveryResponse{}
+	mi := &file_umbrapb_user_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserRecoveryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRecoveryResponse) ProtoMessage() {}
+
+func (x *UserRecoveryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRecoveryResponse.ProtoReflect.Descriptor instead.
+func (*UserRecoveryResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UserRecoveryResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UserRecoveryResponse) GetUuid() []byte {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+func (x *UserRecoveryResponse) GetEncipheredSoul() []byte {
+	if x != nil {
+		return x.EncipheredSoul
+	}
+	return nil
+}
+
+func (x *UserRecoveryResponse) GetEncipheredSoulSalt() []byte {
+	if x != nil {
+		return x.EncipheredSoulSalt
+	}
+	return nil
+}
+
+func (x *UserRecoveryResponse) GetEncipheredSoulTag() []byte {
+	if x != nil {
+		return x.EncipheredSoulTag
+	}
+	return nil
+}
+
+func (x *UserRecoveryResponse) GetSoulRecovery() []byte {
+	if x != nil {
+		return x.SoulRecovery
+	}
+	return nil
+}
+
+func (x *UserRecoveryResponse) GetSoulRecoverySalt() []byte {
+	if x != nil {
+		return x.SoulRecoverySalt
+	}
+	return nil
+}
+
+func (x *UserRecoveryResponse) GetSoulRecoveryTag() []byte {
+	if x != nil {
+		return x.SoulRecoveryTag
+	}
+	return nil
+}
+
+func (x *UserRecoveryResponse) GetNewSoulKdf() *SoulKDF {
+	if x != nil {
+		return x.NewSoulKdf
+	}
+	return nil
+}
+
+// UserRecoveryResetRequest re-enrolls the recovered soul under a new password
+// and a fresh recovery blob.
+type UserRecoveryResetRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Username string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	// Ed25519 signature by the recovered user soul over the session proof message.
+	Signature          []byte   `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	EncipheredSoul     []byte   `protobuf:"bytes,3,opt,name=enciphered_soul,json=encipheredSoul,proto3" json:"enciphered_soul,omitempty"`
+	EncipheredSoulSalt []byte   `protobuf:"bytes,4,opt,name=enciphered_soul_salt,json=encipheredSoulSalt,proto3" json:"enciphered_soul_salt,omitempty"`
+	EncipheredSoulTag  []byte   `protobuf:"bytes,5,opt,name=enciphered_soul_tag,json=encipheredSoulTag,proto3" json:"enciphered_soul_tag,omitempty"`
+	SoulRecovery       []byte   `protobuf:"bytes,6,opt,name=soul_recovery,json=soulRecovery,proto3" json:"soul_recovery,omitempty"`
+	SoulRecoverySalt   []byte   `protobuf:"bytes,7,opt,name=soul_recovery_salt,json=soulRecoverySalt,proto3" json:"soul_recovery_salt,omitempty"`
+	SoulRecoveryTag    []byte   `protobuf:"bytes,8,opt,name=soul_recovery_tag,json=soulRecoveryTag,proto3" json:"soul_recovery_tag,omitempty"`
+	SoulKdf            *SoulKDF `protobuf:"bytes,9,opt,name=soul_kdf,json=soulKdf,proto3" json:"soul_kdf,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *UserRecoveryResetRequest) Reset() {
+	*x = UserRecoveryResetRequest{}
+	mi := &file_umbrapb_user_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserRecoveryResetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRecoveryResetRequest) ProtoMessage() {}
+
+func (x *UserRecoveryResetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRecoveryResetRequest.ProtoReflect.Descriptor instead.
+func (*UserRecoveryResetRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UserRecoveryResetRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserRecoveryResetRequest) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetRequest) GetEncipheredSoul() []byte {
+	if x != nil {
+		return x.EncipheredSoul
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetRequest) GetEncipheredSoulSalt() []byte {
+	if x != nil {
+		return x.EncipheredSoulSalt
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetRequest) GetEncipheredSoulTag() []byte {
+	if x != nil {
+		return x.EncipheredSoulTag
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetRequest) GetSoulRecovery() []byte {
+	if x != nil {
+		return x.SoulRecovery
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetRequest) GetSoulRecoverySalt() []byte {
+	if x != nil {
+		return x.SoulRecoverySalt
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetRequest) GetSoulRecoveryTag() []byte {
+	if x != nil {
+		return x.SoulRecoveryTag
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetRequest) GetSoulKdf() *SoulKDF {
+	if x != nil {
+		return x.SoulKdf
+	}
+	return nil
+}
+
+type UserRecoveryResetResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Uuid   []byte                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	// The recovery blob now on file; the client checks it matches what it sent
+	// before discarding the old recovery key.
+	SoulRecovery     []byte `protobuf:"bytes,3,opt,name=soul_recovery,json=soulRecovery,proto3" json:"soul_recovery,omitempty"`
+	SoulRecoverySalt []byte `protobuf:"bytes,4,opt,name=soul_recovery_salt,json=soulRecoverySalt,proto3" json:"soul_recovery_salt,omitempty"`
+	SoulRecoveryTag  []byte `protobuf:"bytes,5,opt,name=soul_recovery_tag,json=soulRecoveryTag,proto3" json:"soul_recovery_tag,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UserRecoveryResetResponse) Reset() {
+	*x = UserRecoveryResetResponse{}
+	mi := &file_umbrapb_user_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserRecoveryResetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRecoveryResetResponse) ProtoMessage() {}
+
+func (x *UserRecoveryResetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRecoveryResetResponse.ProtoReflect.Descriptor instead.
+func (*UserRecoveryResetResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UserRecoveryResetResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UserRecoveryResetResponse) GetUuid() []byte {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetResponse) GetSoulRecovery() []byte {
+	if x != nil {
+		return x.SoulRecovery
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetResponse) GetSoulRecoverySalt() []byte {
+	if x != nil {
+		return x.SoulRecoverySalt
+	}
+	return nil
+}
+
+func (x *UserRecoveryResetResponse) GetSoulRecoveryTag() []byte {
+	if x != nil {
+		return x.SoulRecoveryTag
+	}
+	return nil
+}
+
+type UserLookupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserLookupRequest) Reset() {
+	*x = UserLookupRequest{}
+	mi := &file_umbrapb_user_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserLookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserLookupRequest) ProtoMessage() {}
+
+func (x *UserLookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserLookupRequest.ProtoReflect.Descriptor instead.
+func (*UserLookupRequest) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UserLookupRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+// UserLookupResponse carries the public keys of a user, e.g. to wrap a group
+// secret to them.
+type UserLookupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Uuid          []byte                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	XPubKey       []byte                 `protobuf:"bytes,3,opt,name=x_pub_key,json=xPubKey,proto3" json:"x_pub_key,omitempty"`
+	EPubKey       []byte                 `protobuf:"bytes,4,opt,name=e_pub_key,json=ePubKey,proto3" json:"e_pub_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserLookupResponse) Reset() {
+	*x = UserLookupResponse{}
+	mi := &file_umbrapb_user_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserLookupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserLookupResponse) ProtoMessage() {}
+
+func (x *UserLookupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_umbrapb_user_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserLookupResponse.ProtoReflect.Descriptor instead.
+func (*UserLookupResponse) Descriptor() ([]byte, []int) {
+	return file_umbrapb_user_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UserLookupResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UserLookupResponse) GetUuid() []byte {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+func (x *UserLookupResponse) GetXPubKey() []byte {
+	if x != nil {
+		return x.XPubKey
+	}
+	return nil
+}
+
+func (x *UserLookupResponse) GetEPubKey() []byte {
+	if x != nil {
+		return x.EPubKey
+	}
+	return nil
+}
+
+var File_umbrapb_user_proto protoreflect.FileDescriptor
+
+const file_umbrapb_user_proto_rawDesc = "" +
+	"\n" +
+	"\x12umbrapb/user.proto\x12\bumbra.v1\"\xbf\x03\n" +
+	"\x13UserRegisterRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
+	"\tx_pub_key\x18\x02 \x01(\fR\axPubKey\x12\x1a\n" +
+	"\te_pub_key\x18\x03 \x01(\fR\aePubKey\x12'\n" +
+	"\x0fenciphered_soul\x18\x04 \x01(\fR\x0eencipheredSoul\x120\n" +
+	"\x14enciphered_soul_salt\x18\x05 \x01(\fR\x12encipheredSoulSalt\x12.\n" +
+	"\x13enciphered_soul_tag\x18\x06 \x01(\fR\x11encipheredSoulTag\x12#\n" +
+	"\rsoul_recovery\x18\a \x01(\fR\fsoulRecovery\x12,\n" +
+	"\x12soul_recovery_salt\x18\b \x01(\fR\x10soulRecoverySalt\x12*\n" +
+	"\x11soul_recovery_tag\x18\t \x01(\fR\x0fsoulRecoveryTag\x12\x1c\n" +
+	"\tsignature\x18\n" +
+	" \x01(\fR\tsignature\x12,\n" +
+	"\bsoul_kdf\x18\v \x01(\v2\x11.umbra.v1.SoulKDFR\asoulKdf\"\x98\x01\n" +
+	"\aSoulKDF\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\rR\aversion\x12\x12\n" +
+	"\x04salt\x18\x02 \x01(\fR\x04salt\x12\x1d\n" +
+	"\n" +
+	"memory_kib\x18\x03 \x01(\rR\tmemoryKib\x12\x1e\n" +
+	"\n" +
+	"iterations\x18\x04 \x01(\rR\n" +
+	"iterations\x12 \n" +
+	"\vparallelism\x18\x05 \x01(\rR\vparallelism\"B\n" +
+	"\x14UserRegisterResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\fR\x04uuid\".\n" +
+	"\x10UserLoginRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\"\xd7\x02\n" +
+	"\x11UserLoginResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\fR\x04uuid\x12'\n" +
+	"\x0fenciphered_soul\x18\x03 \x01(\fR\x0eencipheredSoul\x120\n" +
+	"\x14enciphered_soul_salt\x18\x04 \x01(\fR\x12encipheredSoulSalt\x12.\n" +
+	"\x13enciphered_soul_tag\x18\x05 \x01(\fR\x11encipheredSoulTag\x12,\n" +
+	"\bsoul_kdf\x18\x06 \x01(\v2\x11.umbra.v1.SoulKDFR\asoulKdf\x12)\n" +
+	"\x10upgrade_required\x18\a \x01(\bR\x0fupgradeRequired\x122\n" +
+	"\vupgrade_kdf\x18\b \x01(\v2\x11.umbra.v1.SoulKDFR\n" +
+	"upgradeKdf\"Q\n" +
+	"\x15UserLoginProofRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignature\"W\n" +
+	"\x0fUserKDFResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12,\n" +
+	"\bsoul_kdf\x18\x02 \x01(\v2\x11.umbra.v1.SoulKDFR\asoulKdf\"\xc9\x02\n" +
+	"\x0fUserSoulRequest\x12'\n" +
+	"\x0fenciphered_soul\x18\x01 \x01(\fR\x0eencipheredSoul\x120\n" +
+	"\x14enciphered_soul_salt\x18\x02 \x01(\fR\x12encipheredSoulSalt\x12.\n" +
+	"\x13enciphered_soul_tag\x18\x03 \x01(\fR\x11encipheredSoulTag\x12#\n" +
+	"\rsoul_recovery\x18\x04 \x01(\fR\fsoulRecovery\x12,\n" +
+	"\x12soul_recovery_salt\x18\x05 \x01(\fR\x10soulRecoverySalt\x12*\n" +
+	"\x11soul_recovery_tag\x18\x06 \x01(\fR\x0fsoulRecoveryTag\x12,\n" +
+	"\bsoul_kdf\x18\a \x01(\v2\x11.umbra.v1.SoulKDFR\asoulKdf\"1\n" +
+	"\x13UserRecoveryRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\"\x81\x03\n" +
+	"\x14UserRecoveryResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\fR\x04uuid\x12'\n" +
+	"\x0fenciphered_soul\x18\x03 \x01(\fR\x0eencipheredSoul\x120\n" +
+	"\x14enciphered_soul_salt\x18\x04 \x01(\fR\x12encipheredSoulSalt\x12.\n" +
+	"\x13enciphered_soul_tag\x18\x05 \x01(\fR\x11encipheredSoulTag\x12#\n" +
+	"\rsoul_recovery\x18\x06 \x01(\fR\fsoulRecovery\x12,\n" +
+	"\x12soul_recovery_salt\x18\a \x01(\fR\x10soulRecoverySalt\x12*\n" +
+	"\x11soul_recovery_tag\x18\b \x01(\fR\x0fsoulRecoveryTag\x123\n" +
+	"\fnew_soul_kdf\x18\t \x01(\v2\x11.umbra.v1.SoulKDFR\n" +
+	"newSoulKdf\"\x8c\x03\n" +
+	"\x18UserRecoveryResetRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignature\x12'\n" +
+	"\x0fenciphered_soul\x18\x03 \x01(\fR\x0eencipheredSoul\x120\n" +
+	"\x14enciphered_soul_salt\x18\x04 \x01(\fR\x12encipheredSoulSalt\x12.\n" +
+	"\x13enciphered_soul_tag\x18\x05 \x01(\fR\x11encipheredSoulTag\x12#\n" +
+	"\rsoul_recovery\x18\x06 \x01(\fR\fsoulRecovery\x12,\n" +
+	"\x12soul_recovery_salt\x18\a \x01(\fR\x10soulRecoverySalt\x12*\n" +
+	"\x11soul_recovery_tag\x18\b \x01(\fR\x0fsoulRecoveryTag\x12,\n" +
+	"\bsoul_kdf\x18\t \x01(\v2\x11.umbra.v1.SoulKDFR\asoulKdf\"\xc6\x01\n" +
+	"\x19UserRecoveryResetResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\fR\x04uuid\x12#\n" +
+	"\rsoul_recovery\x18\x03 \x01(\fR\fsoulRecovery\x12,\n" +
+	"\x12soul_recovery_salt\x18\x04 \x01(\fR\x10soulRecoverySalt\x12*\n" +
+	"\x11soul_recovery_tag\x18\x05 \x01(\fR\x0fsoulRecoveryTag\"/\n" +
+	"\x11UserLookupRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\"x\n" +
+	"\x12UserLookupResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\fR\x04uuid\x12\x1a\n" +
+	"\tx_pub_key\x18\x03 \x01(\fR\axPubKey\x12\x1a\n" +
+	"\te_pub_key\x18\x04 \x01(\fR\aePubKeyB*Z(github.com/MHSarmadi/Umbra/Proto/umbrapbb\x06proto3"
+
+var (
+	file_umbrapb_user_proto_rawDescOnce sync.Once
+	file_umbrapb_user_proto_rawDescData []byte
+)
+
+func file_umbrapb_user_proto_rawDescGZIP() []byte {
+	file_umbrapb_user_proto_rawDescOnce.Do(func() {
+		file_umbrapb_user_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_umbrapb_user_proto_rawDesc), len(file_umbrapb_user_proto_rawDesc)))
+	})
+	return file_umbrapb_user_proto_rawDescData
+}
+
+var file_umbrapb_user_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_umbrapb_user_proto_goTypes = []any{
+	(*UserRegisterRequest)(nil),       // 0: umbra.v1.UserRegisterRequest
+	(*SoulKDF)(nil),                   // 1: umbra.v1.SoulKDF
+	(*UserRegisterResponse)(nil),      // 2: umbra.v1.UserRegisterResponse
+	(*UserLoginRequest)(nil),          // 3: umbra.v1.UserLoginRequest
+	(*UserLoginResponse)(nil),         // 4: umbra.v1.UserLoginResponse
+	(*UserLoginProofRequest)(nil),     // 5: umbra.v1.UserLoginProofRequest
+	(*UserKDFResponse)(nil),           // 6: umbra.v1.UserKDFResponse
+	(*UserSoulRequest)(nil),           // 7: umbra.v1.UserSoulRequest
+	(*UserRecoveryRequest)(nil),       // 8: umbra.v1.UserRecoveryRequest
+	(*UserRecoveryResponse)(nil),      // 9: umbra.v1.UserRecoveryResponse
+	(*UserRecoveryResetRequest)(nil),  // 10: umbra.v1.UserRecoveryResetRequest
+	(*UserRecoveryResetResponse)(nil), // 11: umbra.v1.UserRecoveryResetResponse
+	(*UserLookupRequest)(nil),         // 12: umbra.v1.UserLookupRequest
+	(*UserLookupResponse)(nil),        // 13: umbra.v1.UserLookupResponse
+}
+var file_umbrapb_user_proto_depIdxs = []int32{
+	1, // 0: umbra.v1.UserRegisterRequest.soul_kdf:type_name -> umbra.v1.SoulKDF
+	1, // 1: umbra.v1.UserLoginResponse.soul_kdf:type_name -> umbra.v1.SoulKDF
+	1, // 2: umbra.v1.UserLoginResponse.upgrade_kdf:type_name -> umbra.v1.SoulKDF
+	1, // 3: umbra.v1.UserKDFResponse.soul_kdf:type_name -> umbra.v1.SoulKDF
+	1, // 4: umbra.v1.UserSoulRequest.soul_kdf:type_name -> umbra.v1.SoulKDF
+	1, // 5: umbra.v1.UserRecoveryResponse.new_soul_kdf:type_name -> umbra.v1.SoulKDF
+	1, // 6: umbra.v1.UserRecoveryResetRequest.soul_kdf:type_name -> umbra.v1.SoulKDF
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_umbrapb_user_proto_init() }
+func file_umbrapb_user_proto_init() {
+	if File_umbrapb_user_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_umbrapb_user_proto_rawDesc), len(file_umbrapb_user_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_umbrapb_user_proto_goTypes,
+		DependencyIndexes: file_umbrapb_user_proto_depIdxs,
+		MessageInfos:      file_umbrapb_user_proto_msgTypes,
+	}.Build()
+	File_umbrapb_user_proto = out.File
+	file_umbrapb_user_proto_goTypes = nil
+	file_umbrapb_user_proto_depIdxs = nil
+}
@@ -0,0 +1,217 @@
+// Package clientip works out which client a request came from. Forwarding
+// headers are only believed from trusted proxies, and only the one header
+// those proxies write: any other arrives from the client untouched. Hop lists
+// are read right to left: each trusted hop vouches for the one before it, and
+// the first hop not on the list is the client. Anything further left was
+// written by the client and proves nothing.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// The forwarding headers a trusted proxy may write.
+const (
+	HeaderForwarded     = "forwarded"
+	HeaderXForwardedFor = "xff"
+	HeaderXRealIP       = "x-real-ip"
+)
+
+// Prefixes is the list of trusted proxy networks. As text it is a
+// comma-separated list of CIDRs; a bare address means just that address.
+type Prefixes []netip.Prefix
+
+func (ps Prefixes) String() string {
+	parts := make([]string, len(ps))
+	for i, p := range ps {
+		parts[i] = p.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (ps Prefixes) MarshalText() ([]byte, error) {
+	return []byte(ps.String()), nil
+}
+
+func (ps *Prefixes) UnmarshalText(text []byte) error {
+	var parsed Prefixes
+	for _, part := range strings.Split(string(text), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			addr, addr_err := netip.ParseAddr(part)
+			if addr_err != nil {
+				return fmt.Errorf("trusted proxy %q is neither a CIDR nor an address", part)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		parsed = append(parsed, prefix.Masked())
+	}
+	*ps = parsed
+	return nil
+}
+
+func (ps Prefixes) contains(addr netip.Addr) bool {
+	for _, p := range ps {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolver finds the client address of requests and the identity it counts
+// under.
+type Resolver struct {
+	trusted    Prefixes
+	header     string
+	ipv6Prefix int
+}
+
+// New returns a Resolver that believes header, one of the Header constants,
+// from trusted and groups IPv6 clients by networks of ipv6_prefix bits.
+func New(trusted Prefixes, header string, ipv6_prefix int) *Resolver {
+	return &Resolver{trusted: trusted, header: header, ipv6Prefix: ipv6_prefix}
+}
+
+// Addr returns the client address of r, or false when not even the peer
+// address parses.
+func (res *Resolver) Addr(r *http.Request) (netip.Addr, bool) {
+	peer, ok := parseNode(r.RemoteAddr)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if !res.trusted.contains(peer) {
+		return peer, true
+	}
+
+	// Only the header the proxies write is read. Were any other one read
+	// when present, a client could send it and pick its own address.
+	var hops []string
+	switch res.header {
+	case HeaderForwarded:
+		hops, ok = forwardedHops(r.Header.Values("Forwarded"))
+	case HeaderXForwardedFor:
+		hops, ok = forwardedForHops(r.Header.Values("X-Forwarded-For"))
+	case HeaderXRealIP:
+		// The proxy sets X-Real-IP rather than appending to it, so it is
+		// one hop and a replacement for whatever the client sent.
+		hops = r.Header.Values("X-Real-IP")
+		ok = len(hops) == 1
+	default:
+		ok = false
+	}
+	if !ok {
+		return peer, true
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseNode(hops[i])
+		if !ok {
+			// A trusted proxy passed on something that is not an address,
+			// such as "unknown"; the last hop it did name is all we know.
+			break
+		}
+		client = hop
+		if !res.trusted.contains(hop) {
+			break
+		}
+	}
+	return client, true
+}
+
+// Identity is the key a client address counts under: an IPv4 address as is,
+// an IPv6 address as its network of the configured prefix length, since one
+// subscriber usually holds a whole IPv6 network and can rotate through it.
+func (res *Resolver) Identity(addr netip.Addr) string {
+	if addr.Is4() || res.ipv6Prefix >= 128 {
+		return addr.String()
+	}
+	prefix, err := addr.Prefix(res.ipv6Prefix)
+	if err != nil {
+		return addr.String()
+	}
+	return prefix.String()
+}
+
+// ClientIdentity is the Identity of r's client address. A peer address that
+// does not parse is used verbatim.
+func (res *Resolver) ClientIdentity(r *http.Request) string {
+	addr, ok := res.Addr(r)
+	if !ok {
+		return r.RemoteAddr
+	}
+	return res.Identity(addr)
+}
+
+// forwardedForHops splits X-Forwarded-For lines into hops, oldest first.
+func forwardedForHops(lines []string) ([]string, bool) {
+	var hops []string
+	for _, line := range lines {
+		for _, hop := range strings.Split(line, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops, len(hops) > 0
+}
+
+// forwardedHops takes the for= parameter of every element of RFC 7239
+// Forwarded lines, oldest first. An element without one counts as a hop
+// that could not be named.
+func forwardedHops(lines []string) ([]string, bool) {
+	var hops []string
+	for _, line := range lines {
+		for _, element := range splitQuoted(line, ',') {
+			hop := ""
+			for _, pair := range splitQuoted(element, ';') {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hop = strings.Trim(value, `"`)
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops, len(hops) > 0
+}
+
+// splitQuoted splits s at sep outside double-quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseNode reads an address with or without a port, IPv6 in brackets when
+// it has one, the way RemoteAddr, X-Forwarded-For and Forwarded write them.
+func parseNode(node string) (netip.Addr, bool) {
+	node = strings.TrimSpace(node)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	addr, err := netip.ParseAddr(strings.Trim(node, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	// A zone names an interface of whoever wrote the header, not the client.
+	return addr.Unmap().WithZone(""), true
+}
@@ -0,0 +1,180 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newResolver(t *testing.T, trusted, header string, ipv6_prefix int) *Resolver {
+	t.Helper()
+	var ps Prefixes
+	if err := ps.UnmarshalText([]byte(trusted)); err != nil {
+		t.Fatal(err)
+	}
+	return New(ps, header, ipv6_prefix)
+}
+
+func TestAddr(t *testing.T) {
+	const trusted = "10.0.0.0/8, 192.0.2.1, 2001:db8:ffff::/48"
+	resolvers := map[string]*Resolver{
+		HeaderForwarded:     newResolver(t, trusted, HeaderForwarded, 64),
+		HeaderXForwardedFor: newResolver(t, trusted, HeaderXForwardedFor, 64),
+		HeaderXRealIP:       newResolver(t, trusted, HeaderXRealIP, 64),
+	}
+	const (
+		fwd  = HeaderForwarded
+		xff  = HeaderXForwardedFor
+		real = HeaderXRealIP
+	)
+	for _, tc := range []struct {
+		name    string
+		header  string
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{"direct", xff, "198.51.100.7:4000", nil, "198.51.100.7"},
+		{"untrusted peer spoofing X-Forwarded-For", xff, "198.51.100.7:4000",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.9"}}, "198.51.100.7"},
+		{"untrusted peer spoofing Forwarded", fwd, "198.51.100.7:4000",
+			map[string][]string{"Forwarded": {"for=203.0.113.9"}}, "198.51.100.7"},
+		{"untrusted peer spoofing X-Real-IP", real, "198.51.100.7:4000",
+			map[string][]string{"X-Real-IP": {"203.0.113.9"}}, "198.51.100.7"},
+		{"one trusted hop", xff, "10.0.0.1:80",
+			map[string][]string{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		{"trusted hops walked right to left", xff, "10.0.0.1:80",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.9, 198.51.100.7, 192.0.2.1, 10.1.2.3"}}, "198.51.100.7"},
+		{"hops over several header lines", xff, "10.0.0.1:80",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.9, 198.51.100.7", "10.1.2.3"}}, "198.51.100.7"},
+		{"every hop trusted", xff, "10.0.0.1:80",
+			map[string][]string{"X-Forwarded-For": {"10.3.3.3, 10.2.2.2"}}, "10.3.3.3"},
+		{"unnamed hop stops the walk", xff, "10.0.0.1:80",
+			map[string][]string{"X-Forwarded-For": {"198.51.100.7, unknown, 10.2.2.2"}}, "10.2.2.2"},
+		{"Forwarded", fwd, "10.0.0.1:80",
+			map[string][]string{"Forwarded": {"for=198.51.100.7;proto=https;by=10.0.0.1"}}, "198.51.100.7"},
+		{"Forwarded with a quoted IPv6 address and port", fwd, "10.0.0.1:80",
+			map[string][]string{"Forwarded": {`For="[2001:db8::7]:4711";proto=https, for=10.2.2.2`}}, "2001:db8::7"},
+		{"Forwarded for=unknown", fwd, "10.0.0.1:80",
+			map[string][]string{"Forwarded": {"for=unknown"}}, "10.0.0.1"},
+		{"Forwarded element without for", fwd, "10.0.0.1:80",
+			map[string][]string{"Forwarded": {"for=198.51.100.7, proto=https"}}, "10.0.0.1"},
+		// Only the header the proxy wrote counts; the client wrote the rest.
+		{"X-Forwarded-For proxy ignores a client's Forwarded", xff, "10.0.0.1:80",
+			map[string][]string{"Forwarded": {"for=203.0.113.9"}, "X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		{"X-Forwarded-For proxy ignores a client's X-Real-IP", xff, "10.0.0.1:80",
+			map[string][]string{"X-Real-IP": {"203.0.113.9"}}, "10.0.0.1"},
+		{"Forwarded proxy ignores a client's X-Forwarded-For", fwd, "10.0.0.1:80",
+			map[string][]string{"Forwarded": {"for=198.51.100.7"}, "X-Forwarded-For": {"203.0.113.9"}}, "198.51.100.7"},
+		{"X-Real-IP", real, "10.0.0.1:80",
+			map[string][]string{"X-Real-IP": {"198.51.100.7"}}, "198.51.100.7"},
+		{"X-Real-IP proxy ignores a client's X-Forwarded-For", real, "10.0.0.1:80",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.9"}, "X-Real-IP": {"198.51.100.7"}}, "198.51.100.7"},
+		{"X-Real-IP passed on as well as set", real, "10.0.0.1:80",
+			map[string][]string{"X-Real-IP": {"203.0.113.9", "198.51.100.7"}}, "10.0.0.1"},
+		{"trusted peer without headers", xff, "10.0.0.1:80", nil, "10.0.0.1"},
+		{"IPv4-mapped peer", xff, "[::ffff:198.51.100.7]:4000", nil, "198.51.100.7"},
+		{"IPv4-mapped trusted peer", xff, "[::ffff:10.0.0.1]:80",
+			map[string][]string{"X-Forwarded-For": {"::ffff:198.51.100.7"}}, "198.51.100.7"},
+		{"trusted IPv6 peer", xff, "[2001:db8:ffff::1]:80",
+			map[string][]string{"X-Forwarded-For": {"2001:db8:1::7"}}, "2001:db8:1::7"},
+		{"zone dropped", xff, "[fe80::1%eth0]:4000", nil, "fe80::1"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tc.remote
+		for name, values := range tc.headers {
+			for _, value := range values {
+				r.Header.Add(name, value)
+			}
+		}
+		addr, ok := resolvers[tc.header].Addr(r)
+		if !ok || addr.String() != tc.want {
+			t.Errorf("%s: got %s %v, want %s", tc.name, addr, ok, tc.want)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "@unix-socket"
+	res := resolvers[xff]
+	if _, ok := res.Addr(r); ok {
+		t.Error("unparseable peer address resolved")
+	}
+	if got := res.ClientIdentity(r); got != "@unix-socket" {
+		t.Errorf("identity of an unparseable peer %q, want it verbatim", got)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, tc := range []struct {
+		ipv6_prefix int
+		remote      string
+		want        string
+	}{
+		{64, "198.51.100.7:4000", "198.51.100.7"},
+		{64, "[::ffff:198.51.100.7]:4000", "198.51.100.7"},
+		{64, "[2001:db8:1:2:3:4:5:6]:4000", "2001:db8:1:2::/64"},
+		{48, "[2001:db8:1:2:3:4:5:6]:4000", "2001:db8:1::/48"},
+		{128, "[2001:db8:1:2:3:4:5:6]:4000", "2001:db8:1:2:3:4:5:6"},
+	} {
+		r.RemoteAddr = tc.remote
+		if got := New(nil, "", tc.ipv6_prefix).ClientIdentity(r); got != tc.want {
+			t.Errorf("%s with /%d: identity %s, want %s", tc.remote, tc.ipv6_prefix, got, tc.want)
+		}
+	}
+
+	// Two addresses of one /64 are one client.
+	res := New(nil, "", 64)
+	r.RemoteAddr = "[2001:db8:1:2::1]:4000"
+	first := res.ClientIdentity(r)
+	r.RemoteAddr = "[2001:db8:1:2:ffff::2]:5000"
+	if second := res.ClientIdentity(r); first != second {
+		t.Errorf("one /64 split into %s and %s", first, second)
+	}
+}
+
+func TestForwardedHops(t *testing.T) {
+	hops, ok := forwardedHops([]string{
+		`for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"`,
+		`proto=https, FOR=unknown`,
+	})
+	want := []string{"192.0.2.60", "[2001:db8:cafe::17]:4711", "", "unknown"}
+	if !ok || !reflect.DeepEqual(hops, want) {
+		t.Fatalf("hops %q, want %q", hops, want)
+	}
+	if _, ok := forwardedHops(nil); ok {
+		t.Fatal("no Forwarded lines gave hops")
+	}
+}
+
+func TestSplitQuoted(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		sep  byte
+		want []string
+	}{
+		{"a,b,,c", ',', []string{"a", "b", "", "c"}},
+		{`for="a,b";x=1,for=c`, ',', []string{`for="a,b";x=1`, "for=c"}},
+		{`for="a\",b",c`, ',', []string{`for="a\",b"`, "c"}},
+		{`for="x;y";proto=https`, ';', []string{`for="x;y"`, "proto=https"}},
+		{"", ',', []string{""}},
+	} {
+		if got := splitQuoted(tc.s, tc.sep); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("splitQuoted(%q, %q) = %q, want %q", tc.s, tc.sep, got, tc.want)
+		}
+	}
+}
+
+func TestPrefixesText(t *testing.T) {
+	var ps Prefixes
+	if err := ps.UnmarshalText([]byte(" 10.1.2.3/8, 192.0.2.1 ,, 2001:db8::/32")); err != nil {
+		t.Fatal(err)
+	}
+	if got := ps.String(); got != "10.0.0.0/8,192.0.2.1/32,2001:db8::/32" {
+		t.Fatalf("parsed to %s", got)
+	}
+	if err := ps.UnmarshalText([]byte("10.0.0.0/8, proxy.example")); err == nil {
+		t.Fatal("host name accepted as a trusted proxy")
+	}
+}
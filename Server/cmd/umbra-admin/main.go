@@ -0,0 +1,135 @@
+// Command umbra-admin inspects and repairs the data directory of a stopped
+// Umbra server.
+//
+//	umbra-admin <command> [flags] [args]
+//
+// Commands that only read open the directory read-only. Commands that change
+// it open it the way the server does, pending migrations included. Either
+// way Badger's directory lock keeps them from running next to a live server.
+//
+// Session and user ids are read and printed in the base64 form the API uses.
+// Key material is never printed, only its length.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/MHSarmadi/Umbra/Server/database"
+)
+
+// The passphrase comes from the environment rather than a flag so it does not
+// show up in the process list or shell history.
+const dbPassphraseEnv = "UMBRA_DB_PASSPHRASE"
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands map[string]command
+
+// init fills commands, whose usage strings the commands print themselves.
+func init() {
+	commands = map[string]command{
+		"sessions list":   {"[-user ID]", sessionsList},
+		"sessions show":   {"ID", sessionsShow},
+		"sessions revoke": {"ID", sessionsRevoke},
+		"trackers list":   {"", trackersList},
+		"trackers clear":  {"-ip ADDR | -identity HASH | -username NAME", trackersClear},
+		"users list":      {"", usersList},
+		"users disable":   {"ID | -username NAME", usersDisable},
+		"sweep":           {"", sweep},
+		"stats":           {"", stats},
+	}
+}
+
+func main() {
+	name, args, ok := lookup(os.Args[1:])
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := commands[name].run(context.Background(), args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// lookup matches the longest command name at the start of args.
+func lookup(args []string) (name string, rest []string, ok bool) {
+	for n := min(2, len(args)); n > 0; n-- {
+		name = strings.Join(args[:n], " ")
+		if _, ok := commands[name]; ok {
+			return name, args[n:], true
+		}
+	}
+	return "", nil, false
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage: umbra-admin <command> [-data DIR] [-db-key-file FILE] [args]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", strings.TrimSpace(name+" "+commands[name].usage))
+	}
+}
+
+// storeFlags adds the flags every command shares and returns a function that
+// opens the store they describe.
+func storeFlags(fs *flag.FlagSet) func(writable bool) (*database.BadgerStore, error) {
+	data := fs.String("data", "./data", "data directory of the stopped server")
+	key_file := fs.String("db-key-file", "", "key file of an encrypted directory; or set "+dbPassphraseEnv)
+	return func(writable bool) (*database.BadgerStore, error) {
+		key, err := database.LoadEncryptionKey(*data, *key_file, []byte(os.Getenv(dbPassphraseEnv)))
+		if err != nil {
+			return nil, err
+		}
+		if writable {
+			return database.NewBadgerStore(*data, key)
+		}
+		return database.OpenBadgerStoreReadOnly(*data, key)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: umbra-admin %s [flags] %s\n", name, commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// oneArg returns the single positional argument left after the flags.
+func oneArg(fs *flag.FlagSet) (string, error) {
+	if fs.NArg() != 1 {
+		return "", fmt.Errorf("want exactly one argument, got %d", fs.NArg())
+	}
+	return fs.Arg(0), nil
+}
+
+var (
+	b64  = base64.RawStdEncoding.EncodeToString
+	db64 = base64.RawStdEncoding.DecodeString
+)
+
+func parseSessionID(s string) ([24]byte, error) {
+	var id [24]byte
+	raw, err := db64(s)
+	if err != nil || len(raw) != len(id) {
+		return id, fmt.Errorf("invalid session id %q", s)
+	}
+	copy(id[:], raw)
+	return id, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+func sessionsList(ctx context.Context, args []string) error {
+	fs := newFlagSet("sessions list")
+	open := storeFlags(fs)
+	user := fs.String("user", "", "only sessions logged in as this user id")
+	fs.Parse(args)
+
+	var user_uuid []byte
+	if *user != "" {
+		var err error
+		if user_uuid, err = db64(*user); err != nil {
+			return fmt.Errorf("invalid user id %q", *user)
+		}
+	}
+
+	s, err := open(false)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATE\tUSER\tCREATED\tEXPIRES")
+	err = s.ListSessions(ctx, func(session *models.Session) error {
+		if user_uuid != nil && !bytes.Equal(session.UserUUID, user_uuid) {
+			return nil
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", b64(session.UUID[:]), sessionState(session), orDash(b64(session.UserUUID)), formatTime(session.CreatedAt), formatTime(session.ExpiresAt))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+func sessionsShow(ctx context.Context, args []string) error {
+	fs := newFlagSet("sessions show")
+	open := storeFlags(fs)
+	fs.Parse(args)
+	arg, err := oneArg(fs)
+	if err != nil {
+		return err
+	}
+	id, err := parseSessionID(arg)
+	if err != nil {
+		return err
+	}
+
+	s, err := open(false)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	session, err := s.PeekSessionByUUID(ctx, id)
+	if err != nil {
+		return notFound(err, "session "+arg)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	row := func(name string, value any) {
+		fmt.Fprintf(tw, "%s\t%v\n", name, value)
+	}
+	row("id", b64(session.UUID[:]))
+	row("state", sessionState(session))
+	row("user", orDash(b64(session.UserUUID)))
+	row("created", formatTime(session.CreatedAt))
+	row("activated", formatTime(session.ActivatedAt))
+	row("expires", formatTime(session.ExpiresAt))
+	row("last activity", formatTime(unixTime(session.LastActivity)))
+	row("client ed25519 key", b64(session.ClientEdPubKey[:]))
+	row("client x25519 key", b64(session.ClientXPubKey[:]))
+	row("server soul", logger.Redact(session.ServerSoul[:]))
+	row("session token", logger.Redact(session.SessionToken[:]))
+	row("session token salt", logger.Redact(session.SessionTokenCipherKeySalt[:]))
+	row("pow params", fmt.Sprintf("memory=%dMB iterations=%d parallelism=%d", session.PoWParams.MemoryMB, session.PoWParams.Iterations, session.PoWParams.Parallelism))
+	row("pow solved", session.PoWSolved())
+	row("activation attempts", session.ActivationAttempts)
+	row("tracked nonces", len(session.LastNonces))
+	return tw.Flush()
+}
+
+func sessionsRevoke(ctx context.Context, args []string) error {
+	fs := newFlagSet("sessions revoke")
+	open := storeFlags(fs)
+	fs.Parse(args)
+	arg, err := oneArg(fs)
+	if err != nil {
+		return err
+	}
+	id, err := parseSessionID(arg)
+	if err != nil {
+		return err
+	}
+
+	s, err := open(true)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if _, err := s.PeekSessionByUUID(ctx, id); err != nil {
+		return notFound(err, "session "+arg)
+	}
+	if err := s.DeleteSession(ctx, id); err != nil {
+		return err
+	}
+	fmt.Printf("session %s revoked\n", arg)
+	return nil
+}
+
+func sessionState(session *models.Session) string {
+	if session.State == "" {
+		return string(models.SessionStatePending)
+	}
+	return string(session.State)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// notFound turns database.ErrNotFound into a message naming what was missing.
+func notFound(err error, what string) error {
+	if errors.Is(err, database.ErrNotFound) {
+		return fmt.Errorf("%s not found", what)
+	}
+	return err
+}
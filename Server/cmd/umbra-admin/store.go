@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/MHSarmadi/Umbra/Server/database/migrations"
+)
+
+// sweep runs one pass of the expiry janitor, for a directory whose server has
+// been down long enough for expired records to pile up.
+func sweep(ctx context.Context, args []string) error {
+	fs := newFlagSet("sweep")
+	open := storeFlags(fs)
+	fs.Parse(args)
+
+	s, err := open(true)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	stats, err := s.SweepExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("removed %s batches=%d lag=%s\n", stats.RemovedText(), stats.Batches, stats.Lag.Round(time.Second))
+	return nil
+}
+
+func stats(ctx context.Context, args []string) error {
+	fs := newFlagSet("stats")
+	open := storeFlags(fs)
+	fs.Parse(args)
+
+	s, err := open(false)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	stats, err := s.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "migration\t%d of %d\n", stats.MigrationVersion, migrations.Latest())
+	fmt.Fprintf(tw, "lsm bytes\t%d\n", stats.LSMBytes)
+	fmt.Fprintf(tw, "vlog bytes\t%d\n", stats.VLogBytes)
+	for _, ns := range keyspace.Namespaces() {
+		fmt.Fprintf(tw, "%s\t%d\n", ns.Name, stats.Keys[ns.Name])
+	}
+	return tw.Flush()
+}
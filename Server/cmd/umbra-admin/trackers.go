@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/clientip"
+	"github.com/MHSarmadi/Umbra/Server/controllers"
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+// trackersList prints both kinds of rate-limit tracker: session-init trackers,
+// keyed by the hash of a client identity, and recovery trackers, keyed by
+// username and that hash, or by username alone for the budget of every
+// identity together.
+func trackersList(ctx context.Context, args []string) error {
+	fs := newFlagSet("trackers list")
+	open := storeFlags(fs)
+	fs.Parse(args)
+
+	s, err := open(false)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tIDENTITY\tREQUESTS\tFAILED\tLAST REQUEST\tCOOLDOWN UNTIL\tEXPIRES")
+	if err := s.ListSessionInitTrackers(ctx, func(t *models.SessionInitTracker) error {
+		fmt.Fprintf(tw, "session-init\t%s\t%d\t-\t%s\t-\t%s\n", t.IdentityHash, len(t.RequestUnixTS), formatTime(lastRequest(t.RequestUnixTS)), formatTime(t.ExpiresAt))
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := s.ListRecoveryTrackers(ctx, func(t *models.RecoveryTracker) error {
+		identity := t.Identity
+		if identity == "" {
+			identity = "everyone"
+		}
+		fmt.Fprintf(tw, "recovery\t%s from %s\t%d\t%d\t%s\t%s\t%s\n", t.Username, identity, len(t.RequestUnixTS), len(t.FailedUnixTS), formatTime(lastRequest(t.RequestUnixTS)), formatTime(t.CooldownUntil), formatTime(t.ExpiresAt))
+		return nil
+	}); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+func trackersClear(ctx context.Context, args []string) error {
+	fs := newFlagSet("trackers clear")
+	open := storeFlags(fs)
+	ip := fs.String("ip", "", "client address whose session-init limit to clear")
+	ipv6_prefix := fs.Int("ipv6-prefix", controllers.DefaultConfig().IdentityIPv6Prefix, "the server's identity_ipv6_prefix, for an IPv6 -ip")
+	identity := fs.String("identity", "", "session-init identity hash, as trackers list prints it")
+	username := fs.String("username", "", "username whose recovery attempts and cooldowns to clear, from every identity")
+	fs.Parse(args)
+
+	set := 0
+	for _, v := range []string{*ip, *identity, *username} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 || fs.NArg() != 0 {
+		return errors.New("give exactly one of -ip, -identity or -username")
+	}
+	if *ip != "" {
+		addr, err := netip.ParseAddr(*ip)
+		if err != nil {
+			return fmt.Errorf("-ip: %w", err)
+		}
+		*identity = controllers.IdentityHash(clientip.New(nil, "", *ipv6_prefix).Identity(addr.Unmap()))
+	}
+
+	s, err := open(true)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if *username != "" {
+		if err := s.DeleteRecoveryTracker(ctx, *username); err != nil {
+			return notFound(err, "recovery tracker for "+*username)
+		}
+		fmt.Printf("recovery tracker for %s cleared\n", *username)
+		return nil
+	}
+	if err := s.DeleteSessionInitTracker(ctx, *identity); err != nil {
+		return notFound(err, "session-init tracker "+*identity)
+	}
+	fmt.Printf("session-init tracker %s cleared\n", *identity)
+	return nil
+}
+
+func lastRequest(timestamps []int64) time.Time {
+	if len(timestamps) == 0 {
+		return time.Time{}
+	}
+	return unixTime(timestamps[len(timestamps)-1])
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+func usersList(ctx context.Context, args []string) error {
+	fs := newFlagSet("users list")
+	open := storeFlags(fs)
+	fs.Parse(args)
+
+	s, err := open(false)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tUSERNAME\tSOUL KDF\tCREATED\tDISABLED")
+	if err := s.ListUsers(ctx, func(u *models.User) error {
+		fmt.Fprintf(tw, "%s\t%s\tv%d\t%s\t%s\n", b64(u.UUID), u.Username, u.SoulKDF.Version, formatTime(u.CreatedAt), formatTime(u.DisabledAt))
+		return nil
+	}); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// usersDisable turns the user away from login and recovery from now on and
+// revokes the sessions already logged in as them. There is no enable: the
+// account's data stays, so it can be restored from a backup if need be.
+func usersDisable(ctx context.Context, args []string) error {
+	fs := newFlagSet("users disable")
+	open := storeFlags(fs)
+	username := fs.String("username", "", "disable the user with this username instead of by id")
+	fs.Parse(args)
+
+	if (*username == "") == (fs.NArg() == 0) {
+		return errors.New("give either a user id or -username")
+	}
+	var user_uuid []byte
+	if *username == "" {
+		arg, err := oneArg(fs)
+		if err != nil {
+			return err
+		}
+		if user_uuid, err = db64(arg); err != nil {
+			return fmt.Errorf("invalid user id %q", arg)
+		}
+	}
+
+	s, err := open(true)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if *username != "" {
+		user, err := s.GetUserByUsername(ctx, *username)
+		if err != nil {
+			return notFound(err, "user "+*username)
+		}
+		user_uuid = user.UUID
+	}
+	revoked, err := s.DisableUser(ctx, user_uuid, time.Now())
+	if err != nil {
+		return notFound(err, "user "+b64(user_uuid))
+	}
+	fmt.Printf("user %s disabled, %d sessions revoked\n", b64(user_uuid), revoked)
+	return nil
+}
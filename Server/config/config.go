@@ -0,0 +1,188 @@
+// Package config assembles the server configuration from its defaults, a JSON
+// file, UMBRA_* environment variables and command-line flags, each overriding
+// the one before. Every package owns the type of its own section and receives
+// only that section.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/clientip"
+	"github.com/MHSarmadi/Umbra/Server/controllers"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/ratelimit"
+	"github.com/MHSarmadi/Umbra/Server/web"
+)
+
+type Config struct {
+	Server    web.Config
+	Storage   database.Config
+	Log       logger.Config
+	Session   controllers.Config
+	RateLimit ratelimit.Config
+}
+
+func Default() *Config {
+	return &Config{
+		Server:    web.DefaultConfig(),
+		Storage:   database.DefaultConfig(),
+		Log:       logger.DefaultConfig(),
+		Session:   controllers.DefaultConfig(),
+		RateLimit: ratelimit.DefaultConfig(),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if err := c.Server.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("server: %w", err))
+	}
+	if err := c.Storage.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("storage: %w", err))
+	}
+	if err := c.Log.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("log: %w", err))
+	}
+	if err := c.Session.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("session: %w", err))
+	}
+	if err := c.RateLimit.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("rate_limit: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// field binds one setting to its place in Config and to its names in every
+// source. Its file key is section.name; env and flag are optional.
+type field struct {
+	section, name string
+	env, flag     string
+	usage         string
+	// secret settings are redacted by Print and never taken from flags,
+	// which would leave them in the process list and shell history.
+	secret bool
+	ptr    any
+}
+
+func (f *field) key() string {
+	return f.section + "." + f.name
+}
+
+func (c *Config) fields() []*field {
+	return []*field{
+		{section: "server", name: "address", flag: "addr", ptr: &c.Server.Address,
+			usage: "listen address of the API"},
+		{section: "server", name: "admin_address", flag: "admin-addr", ptr: &c.Server.AdminAddress,
+			usage: "listen address for backups and other operator endpoints; empty disables it. Never expose it publicly"},
+		{section: "server", name: "tls_cert_file", flag: "tls-cert", ptr: &c.Server.TLSCertFile,
+			usage: "serve TLS with this PEM certificate chain; reloaded on SIGHUP or when the file changes"},
+		{section: "server", name: "tls_key_file", flag: "tls-key", ptr: &c.Server.TLSKeyFile,
+			usage: "PEM private key of the TLS certificate"},
+		{section: "server", name: "tls_min_version", flag: "tls-min-version", ptr: &c.Server.TLSMinVersion,
+			usage: "oldest TLS version accepted: 1.2 or 1.3"},
+		{section: "server", name: "http_redirect_address", flag: "http-redirect-addr", ptr: &c.Server.HTTPRedirectAddress,
+			usage: "listen address that redirects plain HTTP to the TLS listener; empty disables it"},
+		{section: "server", name: "drain_delay", flag: "drain-delay", ptr: &c.Server.DrainDelay,
+			usage: "how long shutdown keeps serving with /readyz failing before closing the listener"},
+
+		{section: "storage", name: "data_dir", flag: "data", ptr: &c.Storage.DataDir,
+			usage: "Badger data directory"},
+		{section: "storage", name: "memory", flag: "memory", ptr: &c.Storage.Memory,
+			usage: "keep all data in memory instead of the data directory; everything is lost on exit"},
+		{section: "storage", name: "key_file", flag: "db-key-file", ptr: &c.Storage.KeyFile,
+			usage: "encrypt the data directory with the key in this file; or set UMBRA_DB_PASSPHRASE"},
+		{section: "storage", name: "passphrase", env: "UMBRA_DB_PASSPHRASE", secret: true, ptr: &c.Storage.Passphrase,
+			usage: "encrypt the data directory with a key derived from this passphrase"},
+		{section: "storage", name: "janitor_interval", flag: "janitor-interval", ptr: &c.Storage.JanitorInterval,
+			usage: "how often expired sessions and trackers are swept"},
+
+		{section: "log", name: "dir", flag: "log-dir", ptr: &c.Log.Dir,
+			usage: "directory of the daily log files; empty logs to stdout only"},
+		{section: "log", name: "verbosity", flag: "log-verbosity", ptr: &c.Log.Verbosity,
+			usage: "0 silences logging; 1 error, 2 info, 3 debug, 4 verbose, 5 trace. Adjustable at runtime on the admin listener"},
+		{section: "log", name: "format", flag: "log-format", ptr: &c.Log.Format,
+			usage: "text, or json for one object per line"},
+		{section: "log", name: "compress_after_days", flag: "log-compress-after-days", ptr: &c.Log.CompressAfterDays,
+			usage: "gzip daily log files this many days old; 0 never does"},
+		{section: "log", name: "delete_after_days", flag: "log-delete-after-days", ptr: &c.Log.DeleteAfterDays,
+			usage: "delete daily log files this many days old; 0 keeps them"},
+
+		{section: "session", name: "ttl", flag: "session-ttl", ptr: &c.Session.SessionTTL,
+			usage: "lifetime of a new session"},
+		{section: "session", name: "init_window", flag: "session-init-window", ptr: &c.Session.SessionInitWindow,
+			usage: "window over which session inits per client are counted"},
+		{section: "session", name: "init_max_requests", flag: "session-init-max-requests", ptr: &c.Session.SessionInitMaxRequests,
+			usage: "session inits a client may make per window"},
+		{section: "session", name: "init_tracker_ttl", flag: "session-init-tracker-ttl", ptr: &c.Session.SessionInitTrackerTTL,
+			usage: "how long an idle client's session init tracker is kept"},
+		{section: "session", name: "trusted_proxies", flag: "trusted-proxies", ptr: &c.Session.TrustedProxies,
+			usage: "comma-separated CIDRs of proxies whose proxy_header is believed"},
+		{section: "session", name: "proxy_header", flag: "proxy-header", ptr: &c.Session.ProxyHeader,
+			usage: "the one header the trusted proxies write: forwarded, xff (X-Forwarded-For) or x-real-ip"},
+		{section: "session", name: "identity_ipv6_prefix", flag: "identity-ipv6-prefix", ptr: &c.Session.IdentityIPv6Prefix,
+			usage: "IPv6 clients are identified by their network of this many bits; IPv4 by their address"},
+		{section: "session", name: "pow_memory_mb", flag: "pow-memory-mb", ptr: &c.Session.PoWMemoryMB,
+			usage: "Argon2id memory of the proof of work, in MB"},
+		{section: "session", name: "pow_parallelism", flag: "pow-parallelism", ptr: &c.Session.PoWParallelism,
+			usage: "Argon2id parallelism of the proof of work"},
+		{section: "session", name: "pow_iterations_min", flag: "pow-iterations-min", ptr: &c.Session.PoWIterationsMin,
+			usage: "Argon2id iterations of the proof of work for a quiet client"},
+		{section: "session", name: "pow_iterations_max", flag: "pow-iterations-max", ptr: &c.Session.PoWIterationsMax,
+			usage: "Argon2id iterations of the proof of work for a client at its limit"},
+
+		{section: "rate_limit", name: "backend", flag: "rate-limit-backend", ptr: &c.RateLimit.Backend,
+			usage: "where rate limit buckets live: store, with the data, or memory"},
+		{section: "rate_limit", name: "rules", flag: "rate-limits", ptr: &c.RateLimit.Rules,
+			usage: "per-route rate limits, e.g. \"/user/login ip/24/64 window:20/1m; /group/messages/send user bucket:5/1s:20\""},
+	}
+}
+
+// redacted replaces a secret that is set in Print's output.
+const redacted = "<redacted>"
+
+// Print renders the effective configuration as a config file would hold it,
+// with secrets redacted.
+func (c *Config) Print() ([]byte, error) {
+	out := map[string]map[string]any{}
+	for _, f := range c.fields() {
+		if out[f.section] == nil {
+			out[f.section] = map[string]any{}
+		}
+		var v any
+		switch p := f.ptr.(type) {
+		case *time.Duration:
+			v = p.String()
+		case *string:
+			v = *p
+			if f.secret && *p != "" {
+				v = redacted
+			}
+		case *bool:
+			v = *p
+		case *int:
+			v = *p
+		case *uint:
+			v = *p
+		case *uint8:
+			v = *p
+		case *ratelimit.Rules:
+			v = p.String()
+		case *clientip.Prefixes:
+			v = p.String()
+		}
+		out[f.section][f.name] = v
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
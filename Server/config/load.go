@@ -0,0 +1,204 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/clientip"
+	"github.com/MHSarmadi/Umbra/Server/ratelimit"
+)
+
+const (
+	envPrefix = "UMBRA_"
+	// fileEnv names a config file when -config is not given.
+	fileEnv = "UMBRA_CONFIG"
+)
+
+// Load registers a flag for every setting on fs, parses args and builds the
+// configuration: defaults, then the file from -config or UMBRA_CONFIG, then
+// the environment, then the flags given. The result is validated. Flags the
+// caller defined on fs beforehand are parsed along with them.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	path := fs.String("config", os.Getenv(fileEnv), "JSON config file; or set "+fileEnv)
+	var given []func()
+	for _, f := range fields {
+		if f.flag != "" && !f.secret {
+			fs.Var(&flagValue{f: f, given: &given}, f.flag, f.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		if err := loadFile(fields, *path); err != nil {
+			return nil, err
+		}
+	}
+	if err := loadEnv(fields); err != nil {
+		return nil, err
+	}
+	for _, set := range given {
+		set()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile reads a JSON object of sections, each an object of settings.
+// Unknown settings are an error so typos do not go unnoticed.
+func loadFile(fields []*field, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var sections map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &sections); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	byKey := make(map[string]*field, len(fields))
+	for _, f := range fields {
+		byKey[f.key()] = f
+	}
+	for section, values := range sections {
+		for name, value := range values {
+			f, ok := byKey[section+"."+name]
+			if !ok {
+				return fmt.Errorf("config file %s: unknown setting %s.%s", path, section, name)
+			}
+			// Strings are unquoted; numbers and booleans are taken as written.
+			text := string(value)
+			var s string
+			if json.Unmarshal(value, &s) == nil {
+				text = s
+			}
+			set, err := f.parse(text)
+			if err != nil {
+				return fmt.Errorf("config file %s: %s: %w", path, f.key(), err)
+			}
+			set()
+		}
+	}
+	return nil
+}
+
+func loadEnv(fields []*field) error {
+	for _, f := range fields {
+		name := f.envName()
+		text, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		set, err := f.parse(text)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		set()
+	}
+	return nil
+}
+
+// envName is UMBRA_SECTION_NAME unless the field names its own.
+func (f *field) envName() string {
+	if f.env != "" {
+		return f.env
+	}
+	return envPrefix + strings.ToUpper(f.section+"_"+f.name)
+}
+
+// parse checks text against the setting's type and returns the assignment,
+// so flags can be checked while parsing but applied after the file and the
+// environment.
+func (f *field) parse(text string) (func(), error) {
+	switch p := f.ptr.(type) {
+	case *string:
+		return func() { *p = text }, nil
+	case *bool:
+		v, err := strconv.ParseBool(text)
+		return func() { *p = v }, err
+	case *int:
+		v, err := strconv.Atoi(text)
+		return func() { *p = v }, err
+	case *uint:
+		v, err := strconv.ParseUint(text, 10, strconv.IntSize)
+		return func() { *p = uint(v) }, err
+	case *uint8:
+		v, err := strconv.ParseUint(text, 10, 8)
+		return func() { *p = uint8(v) }, err
+	case *time.Duration:
+		v, err := time.ParseDuration(text)
+		return func() { *p = v }, err
+	case *ratelimit.Rules:
+		var v ratelimit.Rules
+		err := v.UnmarshalText([]byte(text))
+		return func() { *p = v }, err
+	case *clientip.Prefixes:
+		var v clientip.Prefixes
+		err := v.UnmarshalText([]byte(text))
+		return func() { *p = v }, err
+	}
+	return nil, errors.New("unsupported setting type")
+}
+
+func (f *field) String() string {
+	switch p := f.ptr.(type) {
+	case *string:
+		return *p
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *int:
+		return strconv.Itoa(*p)
+	case *uint:
+		return strconv.FormatUint(uint64(*p), 10)
+	case *uint8:
+		return strconv.FormatUint(uint64(*p), 10)
+	case *time.Duration:
+		return p.String()
+	case *ratelimit.Rules:
+		return p.String()
+	case *clientip.Prefixes:
+		return p.String()
+	}
+	return ""
+}
+
+// flagValue records a flag for Load to apply once the lower-priority sources
+// are in.
+type flagValue struct {
+	f     *field
+	given *[]func()
+}
+
+func (v *flagValue) String() string {
+	if v == nil || v.f == nil {
+		return ""
+	}
+	return v.f.String()
+}
+
+func (v *flagValue) Set(text string) error {
+	set, err := v.f.parse(text)
+	if err != nil {
+		return err
+	}
+	*v.given = append(*v.given, set)
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	_, ok := v.f.ptr.(*bool)
+	return ok
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+)
+
+// backupNextSinceTrailer carries the since to ask for in the next incremental
+// backup. It is only known once the stream is written, hence a trailer.
+const backupNextSinceTrailer = "X-Backup-Next-Since"
+
+func (c *AdminController) backupper(w http.ResponseWriter) (database.Backupper, bool) {
+	backupper, ok := c.storage.(database.Backupper)
+	if !ok {
+		http.Error(w, "store does not support backups", http.StatusNotImplemented)
+	}
+	return backupper, ok
+}
+
+// AdminBackup streams a full backup, or with ?since=N an incremental one.
+func (c *AdminController) AdminBackup(w http.ResponseWriter, r *http.Request) {
+	backupper, ok := c.backupper(w)
+	if !ok {
+		return
+	}
+	var since uint64
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="umbra-backup-%d.bak"`, since))
+	w.Header().Set("Trailer", backupNextSinceTrailer)
+	w.WriteHeader(http.StatusOK)
+
+	next, err := backupper.Backup(w, since)
+	if err != nil {
+		logger.Errorf("backup failed since=%d: %v", since, err)
+		// The status is gone already; cutting the connection is the only way
+		// left to tell the client the file is incomplete.
+		panic(http.ErrAbortHandler)
+	}
+	w.Header().Set(backupNextSinceTrailer, strconv.FormatUint(next, 10))
+	logger.Infof("backup written since=%d next=%d", since, next)
+}
+
+// AdminSnapshot streams a consistent snapshot without expired sessions and
+// trackers, for moving the data to another host.
+func (c *AdminController) AdminSnapshot(w http.ResponseWriter, r *http.Request) {
+	backupper, ok := c.backupper(w)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="umbra-snapshot-%s.bak"`, now.Format("20060102T150405Z")))
+	w.WriteHeader(http.StatusOK)
+
+	stats, err := backupper.ExportSnapshot(r.Context(), w, now)
+	if err != nil {
+		logger.Errorf("snapshot export failed: %v", err)
+		panic(http.ErrAbortHandler)
+	}
+	logger.Infof("snapshot exported records=%d expired_skipped=%d", stats.Exported, stats.Expired)
+}
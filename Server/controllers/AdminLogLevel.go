@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/MHSarmadi/Umbra/Server/logger"
+)
+
+// AdminLogLevel reports the log verbosity, or with PUT ?verbosity=N sets it
+// until the next restart.
+func (c *AdminController) AdminLogLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		parsed, err := strconv.ParseUint(r.URL.Query().Get("verbosity"), 10, 8)
+		if err != nil {
+			http.Error(w, "invalid verbosity", http.StatusBadRequest)
+			return
+		}
+		previous := logger.Verbosity()
+		if err := logger.SetVerbosity(uint8(parsed)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger.Infof("log verbosity changed from %d to %d", previous, parsed)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "%d\n", logger.Verbosity())
+}
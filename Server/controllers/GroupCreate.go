@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+func (c *Controller) GroupCreate(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("group create started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+	user, ok := c.sessionUser(w, env)
+	if !ok {
+		return
+	}
+
+	var (
+		err          error
+		body_encoded models_requests.GroupCreateRequestEncoded
+		body_decoded models_requests.GroupCreateRequestDecoded
+	)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.GroupCreateRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("group create rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.GroupCreateRequestDecoded{
+			XPublicKey:                body_pb.GetXPubKey(),
+			EPublicKey:                body_pb.GetEPubKey(),
+			EncipheredEntranceKey:     body_pb.GetEncipheredEntranceKey(),
+			EncipheredEntranceKeySalt: body_pb.GetEncipheredEntranceKeySalt(),
+			EncipheredEntranceKeyTag:  body_pb.GetEncipheredEntranceKeyTag(),
+			Secret:                    wrappedGroupSecretFromProto(body_pb.GetSecret()),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("group create rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		fields := []struct {
+			name    string
+			encoded string
+			decoded *[]byte
+		}{
+			{"x_pub_key", body_encoded.XPublicKey, &body_decoded.XPublicKey},
+			{"e_pub_key", body_encoded.EPublicKey, &body_decoded.EPublicKey},
+			{"enciphered_entrance_key", body_encoded.EncipheredEntranceKey, &body_decoded.EncipheredEntranceKey},
+			{"enciphered_entrance_key_salt", body_encoded.EncipheredEntranceKeySalt, &body_decoded.EncipheredEntranceKeySalt},
+			{"enciphered_entrance_key_tag", body_encoded.EncipheredEntranceKeyTag, &body_decoded.EncipheredEntranceKeyTag},
+		}
+		for _, field := range fields {
+			if *field.decoded, err = db64(field.encoded); err != nil {
+				logger.Debugf("group create rejected: invalid %s encoding err=%v", field.name, err)
+				http.Error(w, "invalid "+field.name+" base64 encoding", http.StatusBadRequest)
+				return
+			}
+		}
+		var field string
+		if body_decoded.Secret, field, err = decodeWrappedGroupSecret(body_encoded.Secret); err != nil {
+			logger.Debugf("group create rejected: invalid %s encoding err=%v", field, err)
+			http.Error(w, "invalid "+field+" base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	group := models.Group{
+		XPublicKey:                body_decoded.XPublicKey,
+		EPublicKey:                body_decoded.EPublicKey,
+		EncipheredEntranceKey:     body_decoded.EncipheredEntranceKey,
+		EncipheredEntranceKeySalt: body_decoded.EncipheredEntranceKeySalt,
+		EncipheredEntranceKeyTag:  body_decoded.EncipheredEntranceKeyTag,
+	}
+	err = core.CreateGroup(c.ctx, c.storage, user.UUID, &group, groupMemberWithSecret(body_decoded.Secret))
+	switch {
+	case errors.Is(err, core.ErrInvalidGroupKeys), errors.Is(err, core.ErrInvalidGroupSecret):
+		logger.Debugf("group create rejected: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		logger.Errorf("group create failed storing group: %v", err)
+		http.Error(w, "could not store group", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.GroupCreateResponse{
+		Status: "ok",
+		Uuid:   group.UUID,
+	}
+	writeEnvelopeResponse(w, r, http.StatusCreated, response, models_responses.GroupCreateResponseFromProto(response))
+	logger.Verbosef("group create completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+)
+
+// GroupList returns every group the session's user belongs to, each with the
+// group secret wrapped to that user.
+func (c *Controller) GroupList(w http.ResponseWriter, r *http.Request) {
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+	user, ok := c.sessionUser(w, env)
+	if !ok {
+		return
+	}
+
+	members, groups, err := c.storage.ListUserGroups(c.ctx, user.UUID)
+	if err != nil {
+		logger.Errorf("group list failed loading memberships: %v", err)
+		http.Error(w, "could not load groups", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.GroupListResponse{
+		Status: "ok",
+		Groups: make([]*umbrapb.GroupMembership, 0, len(groups)),
+	}
+	for i := range groups {
+		response.Groups = append(response.Groups, groupMembershipToProto(groups[i], members[i]))
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.GroupListResponseFromProto(response))
+}
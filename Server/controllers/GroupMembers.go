@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+func (c *Controller) GroupMemberAdd(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("group member add started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+	user, ok := c.sessionUser(w, env)
+	if !ok {
+		return
+	}
+
+	var (
+		err          error
+		body_encoded models_requests.GroupMemberAddRequestEncoded
+		body_decoded models_requests.GroupMemberAddRequestDecoded
+	)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.GroupMemberAddRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("group member add rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.GroupMemberAddRequestDecoded{
+			GroupUUID: body_pb.GetGroupUuid(),
+			UserUUID:  body_pb.GetUserUuid(),
+			Secret:    wrappedGroupSecretFromProto(body_pb.GetSecret()),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("group member add rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var field string
+		if body_decoded.GroupUUID, err = db64(body_encoded.GroupUUID); err != nil {
+			field = "group_uuid"
+		} else if body_decoded.UserUUID, err = db64(body_encoded.UserUUID); err != nil {
+			field = "user_uuid"
+		} else {
+			body_decoded.Secret, field, err = decodeWrappedGroupSecret(body_encoded.Secret)
+		}
+		if err != nil {
+			logger.Debugf("group member add rejected: invalid %s encoding err=%v", field, err)
+			http.Error(w, "invalid "+field+" base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(body_decoded.GroupUUID) != 32 || len(body_decoded.UserUUID) != 32 {
+		logger.Debugf("group member add rejected: invalid lengths group_uuid=%d user_uuid=%d", len(body_decoded.GroupUUID), len(body_decoded.UserUUID))
+		http.Error(w, "invalid group_uuid or user_uuid length", http.StatusBadRequest)
+		return
+	}
+
+	member := groupMemberWithSecret(body_decoded.Secret)
+	member.GroupUUID = body_decoded.GroupUUID
+	member.UserUUID = body_decoded.UserUUID
+	err = core.AddGroupMember(c.ctx, c.storage, user.UUID, member)
+	switch {
+	case errors.Is(err, core.ErrInvalidGroupSecret):
+		logger.Debugf("group member add rejected: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, core.ErrNotGroupMember), errors.Is(err, core.ErrNotGroupOwner):
+		logger.Debugf("group member add rejected: %v", err)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, database.ErrNotFound):
+		logger.Debugf("group member add rejected: user not found")
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	case errors.Is(err, database.ErrAlreadyExists):
+		logger.Debugf("group member add rejected: already a member")
+		http.Error(w, "already a member", http.StatusConflict)
+		return
+	case err != nil:
+		logger.Errorf("group member add failed storing membership: %v", err)
+		http.Error(w, "could not store membership", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.StatusResponse{
+		Status: "ok",
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.StatusResponseFromProto(response))
+	logger.Verbosef("group member add completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
+
+func (c *Controller) GroupMemberRemove(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("group member remove started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+	user, ok := c.sessionUser(w, env)
+	if !ok {
+		return
+	}
+
+	var (
+		err          error
+		body_encoded models_requests.GroupMemberRemoveRequestEncoded
+		body_decoded models_requests.GroupMemberRemoveRequestDecoded
+	)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.GroupMemberRemoveRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("group member remove rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.GroupMemberRemoveRequestDecoded{
+			GroupUUID: body_pb.GetGroupUuid(),
+			UserUUID:  body_pb.GetUserUuid(),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("group member remove rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if body_decoded.GroupUUID, err = db64(body_encoded.GroupUUID); err != nil {
+			logger.Debugf("group member remove rejected: invalid group_uuid encoding err=%v", err)
+			http.Error(w, "invalid group_uuid base64 encoding", http.StatusBadRequest)
+			return
+		} else if body_decoded.UserUUID, err = db64(body_encoded.UserUUID); err != nil {
+			logger.Debugf("group member remove rejected: invalid user_uuid encoding err=%v", err)
+			http.Error(w, "invalid user_uuid base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(body_decoded.GroupUUID) != 32 || len(body_decoded.UserUUID) != 32 {
+		logger.Debugf("group member remove rejected: invalid lengths group_uuid=%d user_uuid=%d", len(body_decoded.GroupUUID), len(body_decoded.UserUUID))
+		http.Error(w, "invalid group_uuid or user_uuid length", http.StatusBadRequest)
+		return
+	}
+
+	err = core.RemoveGroupMember(c.ctx, c.storage, user.UUID, body_decoded.GroupUUID, body_decoded.UserUUID)
+	switch {
+	case errors.Is(err, core.ErrNotGroupMember), errors.Is(err, core.ErrNotGroupOwner), errors.Is(err, core.ErrOwnerCannotLeave):
+		logger.Debugf("group member remove rejected: %v", err)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, database.ErrNotFound):
+		logger.Debugf("group member remove rejected: membership already gone")
+		http.Error(w, "not a group member", http.StatusNotFound)
+		return
+	case err != nil:
+		logger.Errorf("group member remove failed deleting membership: %v", err)
+		http.Error(w, "could not delete membership", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.StatusResponse{
+		Status: "ok",
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.StatusResponseFromProto(response))
+	logger.Verbosef("group member remove completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+const (
+	chatHistoryDefaultLimit = 50
+	chatHistoryMaxLimit     = 200
+	chatCursorSize          = 8
+)
+
+func messageToProto(m *models.Message) *umbrapb.ChatMessage {
+	return &umbrapb.ChatMessage{
+		Uuid:                  m.UUID,
+		GroupUuid:             m.GroupUUID,
+		XPubKey:               m.XPublicKey,
+		Payload:               m.Payload,
+		SenderUuid:            m.SenderUUID,
+		SenderSignature:       m.SenderSignature,
+		CreatedAtUnixMillisec: m.CreatedAt.UTC().UnixMilli(),
+		Nonce:                 m.Nonce,
+		SentAtUnixMillisec:    m.SentAt.UTC().UnixMilli(),
+	}
+}
+
+func (c *Controller) GroupMessageSend(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("group message send started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+	user, ok := c.sessionUser(w, env)
+	if !ok {
+		return
+	}
+
+	var (
+		err          error
+		body_encoded models_requests.ChatMessageSendRequestEncoded
+		body_decoded models_requests.ChatMessageSendRequestDecoded
+	)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.ChatMessage
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("group message send rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.ChatMessageSendRequestDecoded{
+			GroupUUID:       body_pb.GetGroupUuid(),
+			XPublicKey:      body_pb.GetXPubKey(),
+			Payload:         body_pb.GetPayload(),
+			Nonce:           body_pb.GetNonce(),
+			SentAt:          body_pb.GetSentAtUnixMillisec(),
+			SenderSignature: body_pb.GetSenderSignature(),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("group message send rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if body_decoded, err = decodeChatMessageSend(body_encoded); err != nil {
+			logger.Debugf("group message send rejected: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	message := models.Message{
+		GroupUUID:       body_decoded.GroupUUID,
+		XPublicKey:      body_decoded.XPublicKey,
+		Payload:         body_decoded.Payload,
+		Nonce:           body_decoded.Nonce,
+		SentAt:          time.UnixMilli(body_decoded.SentAt).UTC(),
+		SenderSignature: body_decoded.SenderSignature,
+	}
+	if !c.sendGroupMessage(w, user, &message) {
+		return
+	}
+
+	response := &umbrapb.ChatMessageSendResponse{
+		Status:  "ok",
+		Message: messageToProto(&message),
+	}
+	writeEnvelopeResponse(w, r, http.StatusCreated, response, models_responses.ChatMessageSendResponseFromProto(response))
+	logger.Verbosef("group message send completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
+
+func decodeChatMessageSend(encoded models_requests.ChatMessageSendRequestEncoded) (decoded models_requests.ChatMessageSendRequestDecoded, err error) {
+	if decoded.GroupUUID, err = db64(encoded.GroupUUID); err != nil {
+		return decoded, errors.New("invalid group_uuid base64 encoding")
+	} else if decoded.XPublicKey, err = db64(encoded.XPublicKey); err != nil {
+		return decoded, errors.New("invalid x_pub_key base64 encoding")
+	} else if decoded.Payload, err = db64(encoded.Payload); err != nil {
+		return decoded, errors.New("invalid payload base64 encoding")
+	} else if decoded.Nonce, err = db64(encoded.Nonce); err != nil {
+		return decoded, errors.New("invalid nonce base64 encoding")
+	} else if decoded.SenderSignature, err = db64(encoded.SenderSignature); err != nil {
+		return decoded, errors.New("invalid sender_signature base64 encoding")
+	}
+	sent_at, err := db64(encoded.SentAt)
+	if err != nil || len(sent_at) != 8 {
+		return decoded, errors.New("invalid sent_at_unix_millisec encoding")
+	}
+	decoded.SentAt = int64(binary.BigEndian.Uint64(sent_at))
+	return decoded, nil
+}
+
+// sendGroupMessage stores the message and pushes it to the group, writing
+// the error response itself when the message is refused. w may be nil for
+// messages arriving over the websocket.
+func (c *Controller) sendGroupMessage(w http.ResponseWriter, sender *models.User, message *models.Message) bool {
+	fail := func(status int, msg string) bool {
+		if w != nil {
+			http.Error(w, msg, status)
+		}
+		return false
+	}
+
+	err := core.SendMessage(c.ctx, c.storage, sender, message)
+	switch {
+	case errors.Is(err, core.ErrInvalidMessage):
+		logger.Debugf("group message rejected: invalid message")
+		return fail(http.StatusBadRequest, "invalid message")
+	case errors.Is(err, core.ErrStaleMessage):
+		logger.Debugf("group message rejected: sent_at outside the accepted window")
+		return fail(http.StatusBadRequest, "message sent_at too far from server time")
+	case errors.Is(err, core.ErrNotGroupMember):
+		logger.Debugf("group message rejected: sender is not a group member")
+		return fail(http.StatusForbidden, "not a group member")
+	case errors.Is(err, core.ErrInvalidMessageSignature):
+		logger.Infof("group message rejected: invalid sender signature")
+		return fail(http.StatusForbidden, "invalid sender signature")
+	case errors.Is(err, database.ErrMessageReplayed):
+		logger.Infof("group message rejected: nonce already used")
+		return fail(http.StatusConflict, "duplicate message")
+	case err != nil:
+		logger.Errorf("group message failed storing message: %v", err)
+		return fail(http.StatusInternalServerError, "could not store message")
+	}
+
+	go c.pushGroupMessage(message)
+	return true
+}
+
+func (c *Controller) GroupMessageHistory(w http.ResponseWriter, r *http.Request) {
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+	user, ok := c.sessionUser(w, env)
+	if !ok {
+		return
+	}
+
+	var (
+		err          error
+		body_encoded models_requests.ChatHistoryRequestEncoded
+		body_decoded models_requests.ChatHistoryRequestDecoded
+	)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.ChatHistoryRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("group message history rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.ChatHistoryRequestDecoded{
+			GroupUUID: body_pb.GetGroupUuid(),
+			Before:    body_pb.GetBefore(),
+			After:     body_pb.GetAfter(),
+			Limit:     body_pb.GetLimit(),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("group message history rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded.Limit = body_encoded.Limit
+		if body_decoded.GroupUUID, err = db64(body_encoded.GroupUUID); err != nil {
+			http.Error(w, "invalid group_uuid base64 encoding", http.StatusBadRequest)
+			return
+		} else if body_decoded.Before, err = db64(body_encoded.Before); err != nil {
+			http.Error(w, "invalid before base64 encoding", http.StatusBadRequest)
+			return
+		} else if body_decoded.After, err = db64(body_encoded.After); err != nil {
+			http.Error(w, "invalid after base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(body_decoded.GroupUUID) != 32 {
+		http.Error(w, "invalid group_uuid length", http.StatusBadRequest)
+		return
+	}
+	if (len(body_decoded.Before) != 0 && len(body_decoded.Before) != chatCursorSize) || (len(body_decoded.After) != 0 && len(body_decoded.After) != chatCursorSize) || (len(body_decoded.Before) != 0 && len(body_decoded.After) != 0) {
+		logger.Debugf("group message history rejected: invalid cursor before=%d after=%d", len(body_decoded.Before), len(body_decoded.After))
+		http.Error(w, "invalid cursor", http.StatusBadRequest)
+		return
+	}
+	limit := int(body_decoded.Limit)
+	if limit == 0 {
+		limit = chatHistoryDefaultLimit
+	} else if limit > chatHistoryMaxLimit {
+		limit = chatHistoryMaxLimit
+	}
+
+	if _, err := c.storage.GetGroupMember(c.ctx, body_decoded.GroupUUID, user.UUID); errors.Is(err, database.ErrNotFound) {
+		logger.Debugf("group message history rejected: not a group member")
+		http.Error(w, "not a group member", http.StatusForbidden)
+		return
+	} else if err != nil {
+		logger.Errorf("group message history failed loading membership: %v", err)
+		http.Error(w, "could not load membership", http.StatusInternalServerError)
+		return
+	}
+
+	messages, more, err := c.storage.ListMessages(c.ctx, body_decoded.GroupUUID, body_decoded.Before, body_decoded.After, limit)
+	if err != nil {
+		logger.Errorf("group message history failed loading messages: %v", err)
+		http.Error(w, "could not load messages", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.ChatHistoryResponse{
+		Status:   "ok",
+		Messages: make([]*umbrapb.ChatMessage, 0, len(messages)),
+	}
+	for _, message := range messages {
+		response.Messages = append(response.Messages, messageToProto(message))
+	}
+	if more {
+		if len(body_decoded.After) > 0 {
+			response.NextCursor = messages[len(messages)-1].Cursor()
+		} else {
+			response.NextCursor = messages[0].Cursor()
+		}
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.ChatHistoryResponseFromProto(response))
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/crypto"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+const (
+	maxSessionActivateBodyBytes = 1 << 10
+	activationNonceSize         = 24
+	maxActivationAttempts       = 3
+)
+
+var (
+	errSessionAlreadyActive = errors.New("session already active")
+	errPoWNotSolved         = errors.New("pow not solved")
+	errActivationProof      = errors.New("invalid activation proof")
+	errActivationExhausted  = errors.New("activation attempts exhausted")
+)
+
+// activationProof is the MAC a client computes with the session token it
+// recovered by solving the captcha. Binding the session id keeps a proof from
+// being replayed against another session.
+func activationProof(session_token, session_id, activation_nonce []byte) [32]byte {
+	return crypto.MAC(session_token, append(append([]byte(nil), session_id...), activation_nonce...), "@SESSION-ACTIVATION")
+}
+
+func (c *Controller) SessionActivate(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("session activate started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	var (
+		err          error
+		body_encoded models_requests.SessionActivateRequestEncoded
+		body_decoded models_requests.SessionActivateRequestDecoded
+	)
+	r.Body = http.MaxBytesReader(w, r.Body, maxSessionActivateBodyBytes)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.SessionActivateRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("session activate rejected: malformed protobuf body remote=%s err=%v", r.RemoteAddr, err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.SessionActivateRequestDecoded{
+			SessionUUID: body_pb.GetSessionId(),
+			Proof:       body_pb.GetProof(),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("session activate rejected: malformed json body remote=%s err=%v", r.RemoteAddr, err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if body_decoded.SessionUUID, err = db64(body_encoded.SessionUUID); err != nil {
+			logger.Debugf("session activate rejected: invalid session_id encoding err=%v", err)
+			http.Error(w, "invalid session_id base64 encoding", http.StatusBadRequest)
+			return
+		} else if body_decoded.Proof, err = db64(body_encoded.Proof); err != nil {
+			logger.Debugf("session activate rejected: invalid proof encoding err=%v", err)
+			http.Error(w, "invalid proof base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(body_decoded.SessionUUID) != 24 || len(body_decoded.Proof) != 32 {
+		logger.Debugf("session activate rejected: invalid lengths session_id=%d proof=%d", len(body_decoded.SessionUUID), len(body_decoded.Proof))
+		http.Error(w, "invalid session_id or proof length", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	var proofErr error
+	_, err = c.storage.UpdateSession(c.ctx, [24]byte(body_decoded.SessionUUID), func(s *models.Session) error {
+		if s.IsActive() {
+			return errSessionAlreadyActive
+		}
+		if !s.PoWSolved() || len(s.ActivationNonce) == 0 {
+			return errPoWNotSolved
+		}
+		if s.ActivationAttempts >= maxActivationAttempts {
+			return errActivationExhausted
+		}
+
+		expected := activationProof(s.SessionToken[:], s.UUID[:], s.ActivationNonce)
+		if subtle.ConstantTimeCompare(expected[:], body_decoded.Proof) != 1 {
+			// The failed attempt is still persisted; returning an error here
+			// would roll the counter back.
+			s.ActivationAttempts++
+			proofErr = errActivationProof
+			return nil
+		}
+
+		s.State = models.SessionStateActive
+		s.ActivatedAt = now
+		s.ActivationNonce = nil
+		return nil
+	})
+	if err == nil {
+		err = proofErr
+	}
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		logger.Debugf("session activate rejected: session not found")
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errSessionAlreadyActive):
+		logger.Infof("session activate rejected: session already active")
+		http.Error(w, "session already active", http.StatusConflict)
+		return
+	case errors.Is(err, errPoWNotSolved):
+		logger.Debugf("session activate rejected: proof of work not solved")
+		http.Error(w, "proof of work not solved", http.StatusForbidden)
+		return
+	case errors.Is(err, errActivationExhausted):
+		if err := c.storage.DeleteSession(c.ctx, [24]byte(body_decoded.SessionUUID)); err != nil {
+			logger.Errorf("session activate failed deleting exhausted session: %v", err)
+		}
+		logger.Infof("session activate rejected: attempts exhausted, session dropped")
+		http.Error(w, "too many activation attempts", http.StatusForbidden)
+		return
+	case errors.Is(err, errActivationProof):
+		logger.Infof("session activate rejected: invalid session token proof")
+		http.Error(w, "invalid activation proof", http.StatusForbidden)
+		return
+	case err != nil:
+		logger.Errorf("session activate failed updating session: %v", err)
+		http.Error(w, "could not update session", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.StatusResponse{
+		Status: "ok",
+	}
+	if err := wire.Write(w, r, http.StatusOK, response, models_responses.StatusResponseFromProto(response)); err != nil {
+		logger.Errorf("session activate response encode failed: %v", err)
+		return
+	}
+	logger.Verbosef("session activate completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
@@ -4,39 +4,29 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/binary"
-	"encoding/json"
-	"io"
 	"math"
-	"net"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
 	"github.com/MHSarmadi/Umbra/Server/captcha"
 	"github.com/MHSarmadi/Umbra/Server/crypto"
 	"github.com/MHSarmadi/Umbra/Server/logger"
 	math_tools "github.com/MHSarmadi/Umbra/Server/math"
 	"github.com/MHSarmadi/Umbra/Server/models"
 	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
 	"golang.org/x/crypto/argon2"
 )
 
-const sessionTTL = 15 * time.Minute
 const maxSessionInitBodyBytes = 8 << 10
 
 const (
-	sessionInitWindow             = 10 * time.Minute
-	sessionInitMaxRequestsPerWind = 32
-	sessionInitTrackerTTL         = 30 * time.Minute
-	trustForwardedIdentityHeaders = false
-
 	powChallengeSize = 1
-	powMemoryMB      = 12
-	powParallelism   = 1
-	powIterationsMin = 2
-	powIterationsMax = 7
 
+	// The client derives the session token cipher key at the same cost.
 	sessTokCiphKeyMemoryMB    = 12
 	sessTokCiphKeyParallelism = 1
 	sessTokCiphKeyIterations  = 24
@@ -47,37 +37,19 @@ var (
 	db64 = base64.RawStdEncoding.DecodeString
 )
 
-func sessionInitIdentityHash(r *http.Request) string {
-	identityRaw := clientIP(r)
-	sum := crypto.Sum([]byte(identityRaw))
+// IdentityHash is the key the session-init and recovery trackers count a
+// client identity (see clientip.Resolver.Identity) under. Only the hash is
+// stored, never the address itself.
+func IdentityHash(identity string) string {
+	sum := crypto.Sum([]byte(identity))
 	return b64(sum[:16])
 }
 
-func clientIP(r *http.Request) string {
-	if trustForwardedIdentityHeaders {
-		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-			parts := strings.Split(xff, ",")
-			first := strings.TrimSpace(parts[0])
-			if first != "" {
-				return first
-			}
-		}
-		if xrip := strings.TrimSpace(r.Header.Get("X-Real-IP")); xrip != "" {
-			return xrip
-		}
-	}
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err == nil && host != "" {
-		return host
-	}
-	return r.RemoteAddr
-}
-
-func dynamicPoWIterations(requestCount int) uint {
+func dynamicPoWIterations(cfg Config, requestCount int) uint {
 	if requestCount < 1 {
 		requestCount = 1
 	}
-	density := float64(requestCount) / float64(sessionInitMaxRequestsPerWind)
+	density := float64(requestCount) / float64(cfg.SessionInitMaxRequests)
 	if density > 1 {
 		density = 1
 	}
@@ -90,78 +62,99 @@ func dynamicPoWIterations(requestCount int) uint {
 	hi := 1.0 / (1.0 + math.Exp(-k*(1-mid)))
 	normalized := (raw - lo) / (hi - lo)
 
-	span := float64(powIterationsMax - powIterationsMin)
-	it := float64(powIterationsMin) + normalized*span
+	span := float64(cfg.PoWIterationsMax - cfg.PoWIterationsMin)
+	it := float64(cfg.PoWIterationsMin) + normalized*span
 	rounded := uint(math.Round(it))
-	if rounded < powIterationsMin {
-		return powIterationsMin
+	if rounded < cfg.PoWIterationsMin {
+		return cfg.PoWIterationsMin
 	}
-	if rounded > powIterationsMax {
-		return powIterationsMax
+	if rounded > cfg.PoWIterationsMax {
+		return cfg.PoWIterationsMax
 	}
 	return rounded
 }
 
 func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 	reqStart := time.Now()
+	outcome := sessionInitInternalError
+	defer func() {
+		sessionInitTotal.With(outcome).Inc()
+	}()
 	logger.Verbosef("session init started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
 
 	var (
 		err          error
 		body_encoded models_requests.SessionInitRequestEncoded
 		body_decoded models_requests.SessionInitRequestDecoded
+		asProto      = wire.WantsProtobuf(r)
 	)
 	r.Body = http.MaxBytesReader(w, r.Body, maxSessionInitBodyBytes)
-	decoder := json.NewDecoder(r.Body)
-	decoder.DisallowUnknownFields()
-	if err := decoder.Decode(&body_encoded); err != nil {
-		logger.Debugf("session init rejected: malformed json body remote=%s err=%v", r.RemoteAddr, err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
-		return
-	}
-	if err := decoder.Decode(&struct{}{}); err != io.EOF {
-		logger.Debugf("session init rejected: unexpected extra json values remote=%s err=%v", r.RemoteAddr, err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
-		return
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.SessionInitRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			outcome = sessionInitMalformedBody
+			logger.Debugf("session init rejected: malformed protobuf body remote=%s err=%v", r.RemoteAddr, err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.SessionInitRequestDecoded{
+			ClientEdPubKey:         body_pb.GetClientEdPubkey(),
+			ClientXPubKey:          body_pb.GetClientXPubkey(),
+			ClientXPubKeySignature: body_pb.GetClientXPubkeySign(),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			outcome = sessionInitMalformedBody
+			logger.Debugf("session init rejected: malformed json body remote=%s err=%v", r.RemoteAddr, err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		logger.Tracef(
+			"session init payload field lengths (base64 chars): ed=%d x=%d sign=%d",
+			len(body_encoded.ClientEdPubKey),
+			len(body_encoded.ClientXPubKey),
+			len(body_encoded.ClientXPubKeySignature),
+		)
+		if body_decoded.ClientEdPubKey, err = db64(body_encoded.ClientEdPubKey); err != nil {
+			outcome = sessionInitInvalidEncoding
+			logger.Debugf("session init rejected: invalid client_ed_pubkey encoding err=%v", err)
+			http.Error(w, "invalid client_ed_pubkey base64 encoding", http.StatusBadRequest)
+			return
+		} else if body_decoded.ClientXPubKey, err = db64(body_encoded.ClientXPubKey); err != nil {
+			outcome = sessionInitInvalidEncoding
+			logger.Debugf("session init rejected: invalid client_x_pubkey encoding err=%v", err)
+			http.Error(w, "invalid client_x_pubkey base64 encoding", http.StatusBadRequest)
+			return
+		} else if body_decoded.ClientXPubKeySignature, err = db64(body_encoded.ClientXPubKeySignature); err != nil {
+			outcome = sessionInitInvalidEncoding
+			logger.Debugf("session init rejected: invalid client_x_pubkey_sign encoding err=%v", err)
+			http.Error(w, "invalid client_x_pubkey_sign base64 encoding", http.StatusBadRequest)
+			return
+		}
 	}
 
-	logger.Tracef(
-		"session init payload field lengths (base64 chars): ed=%d x=%d sign=%d",
-		len(body_encoded.ClientEdPubKey),
-		len(body_encoded.ClientXPubKey),
-		len(body_encoded.ClientXPubKeySignature),
-	)
-	if body_decoded.ClientEdPubKey, err = db64(body_encoded.ClientEdPubKey); err != nil {
-		logger.Debugf("session init rejected: invalid client_ed_pubkey encoding err=%v", err)
-		http.Error(w, "invalid client_ed_pubkey base64 encoding", http.StatusBadRequest)
-		return
-	} else if body_decoded.ClientXPubKey, err = db64(body_encoded.ClientXPubKey); err != nil {
-		logger.Debugf("session init rejected: invalid client_x_pubkey encoding err=%v", err)
-		http.Error(w, "invalid client_x_pubkey base64 encoding", http.StatusBadRequest)
-		return
-	} else if body_decoded.ClientXPubKeySignature, err = db64(body_encoded.ClientXPubKeySignature); err != nil {
-		logger.Debugf("session init rejected: invalid client_x_pubkey_sign encoding err=%v", err)
-		http.Error(w, "invalid client_x_pubkey_sign base64 encoding", http.StatusBadRequest)
-		return
-	} else if len(body_decoded.ClientEdPubKey) != 32 || len(body_decoded.ClientXPubKey) != 32 {
+	if len(body_decoded.ClientEdPubKey) != 32 || len(body_decoded.ClientXPubKey) != 32 {
+		outcome = sessionInitInvalidKeyLength
 		logger.Debugf("session init rejected: invalid pubkey lengths ed=%d x=%d", len(body_decoded.ClientEdPubKey), len(body_decoded.ClientXPubKey))
 		http.Error(w, "invalid ed-pubkey or x-pubkey length", http.StatusBadRequest)
 		return
 	} else if crypto.Verify(body_decoded.ClientEdPubKey, body_decoded.ClientXPubKey, body_decoded.ClientXPubKeySignature) == false {
+		outcome = sessionInitInvalidSignature
 		logger.Debugf("session init rejected: client signature verification failed")
 		http.Error(w, "invalid signature over client_x_pubkey", http.StatusBadRequest)
 		return
 	} else {
 		logger.Tracef("session init: client cryptographic identity verified")
 		now := time.Now().UTC()
-		trackerID := sessionInitIdentityHash(r)
+		trackerID := IdentityHash(c.clients.ClientIdentity(r))
 		requestCount, limited, retryAfter, err := c.storage.RegisterSessionInitRequest(
 			c.ctx,
 			trackerID,
 			now,
-			sessionInitWindow,
-			sessionInitMaxRequestsPerWind,
-			sessionInitTrackerTTL,
+			c.cfg.SessionInitWindow,
+			c.cfg.SessionInitMaxRequests,
+			c.cfg.SessionInitTrackerTTL,
 		)
 		if err != nil {
 			logger.Errorf("session init tracker update failed for identity=%s: %v", trackerID, err)
@@ -169,6 +162,7 @@ func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if limited {
+			outcome = sessionInitRateLimited
 			logger.Infof("session init rate-limited for identity=%s retry_after=%ds", trackerID, int64(retryAfter.Seconds()))
 			retryAfterSeconds := int64(math.Ceil(retryAfter.Seconds()))
 			if retryAfterSeconds < 1 {
@@ -178,7 +172,8 @@ func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "too many session initialization requests", http.StatusTooManyRequests)
 			return
 		}
-		powIterations := dynamicPoWIterations(requestCount)
+		powIterations := dynamicPoWIterations(c.cfg, requestCount)
+		powIterationsChosen.Observe(float64(powIterations))
 		logger.Debugf("session init identity=%s request_count=%d pow_iterations=%d", trackerID, requestCount, powIterations)
 
 		if len(body_decoded.ClientEdPubKey) != 32 || len(body_decoded.ClientXPubKey) != 32 {
@@ -218,9 +213,9 @@ func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		pow_params := models.PowParamsType{
-			MemoryMB:    powMemoryMB,
+			MemoryMB:    c.cfg.PoWMemoryMB,
 			Iterations:  powIterations,
-			Parallelism: powParallelism,
+			Parallelism: c.cfg.PoWParallelism,
 		}
 		var pow_salt [12]byte
 		if _, err := rand.Read(pow_salt[:]); err != nil {
@@ -238,7 +233,9 @@ func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 		}
 		captcha_solution_bytes := make([]byte, 8)
 		binary.BigEndian.PutUint64(captcha_solution_bytes, captcha_solution_numeric)
+		captchaStart := time.Now()
 		captcha_png, err := captcha.GenerateNumericCaptcha(captcha_solution)
+		captchaDuration.ObserveSince(captchaStart)
 		if err != nil {
 			logger.Errorf("session init captcha generation failed: %v", err)
 			http.Error(w, "could not draw captcha", http.StatusInternalServerError)
@@ -252,7 +249,9 @@ func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "could not read entropy", http.StatusInternalServerError)
 			return
 		}
+		argon2Start := time.Now()
 		session_token_cipher_key := argon2.IDKey(captcha_solution_bytes, session_token_cipher_key_salt, sessTokCiphKeyIterations, sessTokCiphKeyMemoryMB*1024, sessTokCiphKeyParallelism, 32)
+		argon2Duration.With("session_token_key").ObserveSince(argon2Start)
 
 		var session_token [24]byte
 		if _, err := rand.Read(session_token[:]); err != nil {
@@ -260,7 +259,7 @@ func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "could not read entropy", http.StatusInternalServerError)
 			return
 		}
-		logger.Verbosef("session token randomly generated \"%s\"", b64(session_token[:]))
+		logger.Verbosef("session token randomly generated %s", logger.Redact(session_token[:]))
 		session_token_ciphered, session_token_salt, session_token_tag := crypto.MACE_Encrypt_MIXIN_AEAD(session_token_cipher_key, session_token[:], session_id[:], "@SESSION-TOKEN", 2, false)
 		logger.Tracef("session init session-token encryption produced cipher_bytes=%d salt_bytes=%d tag_bytes=%d", len(session_token_ciphered), len(session_token_salt), len(session_token_tag))
 
@@ -268,10 +267,11 @@ func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 		session_token_ciphered_pack = append(session_token_ciphered_pack, session_token_ciphered...) // session_token_salt is always exactly 12 bytes and session_token_tag is always exactly 16 bytes
 
 		session := models.Session{
-			UUID: session_id,
+			UUID:  session_id,
+			State: models.SessionStatePending,
 
 			CreatedAt: now,
-			ExpiresAt: now.Add(sessionTTL).UTC(),
+			ExpiresAt: now.Add(c.cfg.SessionTTL).UTC(),
 
 			ClientEdPubKey: [32]byte(body_decoded.ClientEdPubKey),
 			ClientXPubKey:  [32]byte(body_decoded.ClientXPubKey),
@@ -293,38 +293,23 @@ func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 		}
 		logger.Tracef("session init session persisted session_id_b64_len=%d", len(b64(session.UUID[:])))
 
-		type SessionInitResponse struct {
-			Status                 string `json:"status"`
-			ServerEdPubKey         string `json:"server_ed_pubkey"`
-			ServerXPubKey          string `json:"server_x_pubkey"`
-			ServerXPubKeySignature string `json:"server_x_pubkey_sign"`
-			Payload                string `json:"payload"`
-			Signature              string `json:"signature"`
-			ExpiresAt              string `json:"expiry_unix_millisec"`
-		}
-		type SessionInitRawPayload struct {
-			SessionUUID               string               `json:"session_id"`
-			CaptchaChallenge          string               `json:"captcha_challenge"`
-			PoWChallenge              string               `json:"pow_challenge"`
-			PowParams                 models.PowParamsType `json:"pow_params"`
-			PoWSalt                   string               `json:"pow_salt"`
-			SessionToken              string               `json:"session_token_ciphered"`
-			SessionTokenCipherKeySalt string               `json:"session_token_cipher_key_salt"`
+		payload_pb := &umbrapb.SessionInitPayload{
+			SessionId:        session.UUID[:],
+			CaptchaChallenge: captcha_png,
+			PowChallenge:     session.PoWChallenge[:],
+			PowParams: &umbrapb.PoWParams{
+				MemoryMb:    uint32(session.PoWParams.MemoryMB),
+				Iterations:  uint32(session.PoWParams.Iterations),
+				Parallelism: uint32(session.PoWParams.Parallelism),
+			},
+			PowSalt:                   session.PoWSalt[:],
+			SessionTokenCiphered:      session_token_ciphered_pack,
+			SessionTokenCipherKeySalt: session_token_cipher_key_salt,
 		}
-
-		payload_raw := SessionInitRawPayload{
-			SessionUUID:               b64(session.UUID[:]),
-			PoWChallenge:              b64(session.PoWChallenge[:]),
-			PowParams:                 session.PoWParams,
-			PoWSalt:                   b64(session.PoWSalt[:]),
-			SessionToken:              b64(session_token_ciphered_pack),
-			SessionTokenCipherKeySalt: b64(session_token_cipher_key_salt),
-			CaptchaChallenge:          b64(captcha_png),
-		}
-		payload_encoded, err := json.Marshal(payload_raw)
+		payload_encoded, err := wire.Marshal(asProto, payload_pb, models_responses.SessionInitPayloadFromProto(payload_pb))
 		if err != nil {
-			logger.Errorf("session init failed marshaling payload json: %v", err)
-			http.Error(w, "could not marshal to json", http.StatusInternalServerError)
+			logger.Errorf("session init failed marshaling payload: %v", err)
+			http.Error(w, "could not marshal payload", http.StatusInternalServerError)
 			return
 		}
 		logger.Tracef("session init payload marshaled bytes=%d", len(payload_encoded))
@@ -341,24 +326,20 @@ func (c *Controller) SessionInit(w http.ResponseWriter, r *http.Request) {
 		signature := crypto.Sign(server_soul[:], payload)
 		logger.Tracef("session init response cryptography complete payload_bytes=%d signature_bytes=%d", len(payload), len(signature))
 
-		expiry_unix_millisec_bytes := make([]byte, 8)
-		binary.BigEndian.PutUint64(expiry_unix_millisec_bytes, uint64(session.ExpiresAt.UTC().UnixMilli()))
-
-		response := SessionInitResponse{
-			Status:                 "ok",
-			ServerEdPubKey:         b64(server_ed_pubkey),
-			ServerXPubKey:          b64(server_x_pubkey),
-			ServerXPubKeySignature: b64(server_x_pubkey_sign),
-			Payload:                b64(payload),
-			Signature:              b64(signature),
-			ExpiresAt:              b64(expiry_unix_millisec_bytes),
+		response := &umbrapb.SessionInitResponse{
+			Status:             "ok",
+			ServerEdPubkey:     server_ed_pubkey,
+			ServerXPubkey:      server_x_pubkey,
+			ServerXPubkeySign:  server_x_pubkey_sign,
+			Payload:            payload,
+			Signature:          signature,
+			ExpiryUnixMillisec: uint64(session.ExpiresAt.UTC().UnixMilli()),
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
+		if err := wire.Write(w, r, http.StatusOK, response, models_responses.SessionInitResponseFromProto(response)); err != nil {
 			logger.Errorf("session init response encode failed: %v", err)
 			return
 		}
+		outcome = sessionInitOK
 		logger.Verbosef("session init completed identity=\"%s\" duration_ms=%d", trackerID, time.Since(reqStart).Milliseconds())
 	}
 }
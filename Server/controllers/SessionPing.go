@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+)
+
+func (c *Controller) SessionPing(w http.ResponseWriter, r *http.Request) {
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.SessionPingResponse{
+		State:              string(env.session.State),
+		ExpiryUnixMillisec: uint64(env.session.ExpiresAt.UTC().UnixMilli()),
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.SessionPingResponseFromProto(response))
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	maxSessionPoWBodyBytes = 1 << 10
+	powNonceSize           = 8
+)
+
+var (
+	errPoWAlreadySolved    = errors.New("pow already solved")
+	errPoWAlreadyAttempted = errors.New("pow already attempted")
+)
+
+func verifyPoW(session *models.Session, nonce []byte) bool {
+	defer argon2Duration.With("pow_verify").ObserveSince(time.Now())
+	hash := argon2.IDKey(
+		nonce,
+		session.PoWSalt[:],
+		uint32(session.PoWParams.Iterations),
+		uint32(session.PoWParams.MemoryMB*1024),
+		uint8(session.PoWParams.Parallelism),
+		32,
+	)
+	return subtle.ConstantTimeCompare(hash[:len(session.PoWChallenge)], session.PoWChallenge[:]) == 1
+}
+
+func (c *Controller) SessionPoW(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("session pow started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	var (
+		err          error
+		body_encoded models_requests.SessionPoWRequestEncoded
+		body_decoded models_requests.SessionPoWRequestDecoded
+	)
+	r.Body = http.MaxBytesReader(w, r.Body, maxSessionPoWBodyBytes)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.SessionPoWRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("session pow rejected: malformed protobuf body remote=%s err=%v", r.RemoteAddr, err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.SessionPoWRequestDecoded{
+			SessionUUID: body_pb.GetSessionId(),
+			Nonce:       body_pb.GetNonce(),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("session pow rejected: malformed json body remote=%s err=%v", r.RemoteAddr, err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if body_decoded.SessionUUID, err = db64(body_encoded.SessionUUID); err != nil {
+			logger.Debugf("session pow rejected: invalid session_id encoding err=%v", err)
+			http.Error(w, "invalid session_id base64 encoding", http.StatusBadRequest)
+			return
+		} else if body_decoded.Nonce, err = db64(body_encoded.Nonce); err != nil {
+			logger.Debugf("session pow rejected: invalid nonce encoding err=%v", err)
+			http.Error(w, "invalid nonce base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(body_decoded.SessionUUID) != 24 || len(body_decoded.Nonce) != powNonceSize {
+		logger.Debugf("session pow rejected: invalid lengths session_id=%d nonce=%d", len(body_decoded.SessionUUID), len(body_decoded.Nonce))
+		http.Error(w, "invalid session_id or nonce length", http.StatusBadRequest)
+		return
+	}
+
+	// Claim the single verification attempt before spending Argon2 time on it,
+	// so concurrent or repeated submissions cannot each force a hash.
+	session, err := c.storage.UpdateSession(c.ctx, [24]byte(body_decoded.SessionUUID), func(s *models.Session) error {
+		if s.PoWSolved() {
+			return errPoWAlreadySolved
+		}
+		if s.PoWAttempted {
+			return errPoWAlreadyAttempted
+		}
+		s.PoWAttempted = true
+		return nil
+	})
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		logger.Debugf("session pow rejected: session not found")
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errPoWAlreadySolved), errors.Is(err, errPoWAlreadyAttempted):
+		logger.Infof("session pow rejected: repeated attempt err=%v", err)
+		http.Error(w, "proof of work already submitted", http.StatusConflict)
+		return
+	case err != nil:
+		logger.Errorf("session pow failed claiming attempt: %v", err)
+		http.Error(w, "could not load session", http.StatusInternalServerError)
+		return
+	}
+
+	verifyStart := time.Now()
+	valid := verifyPoW(session, body_decoded.Nonce)
+	logger.Tracef("session pow argon2 verification complete in %d microseconds valid=%t", time.Since(verifyStart).Microseconds(), valid)
+	if !valid {
+		// The attempt is spent, so the session can never become usable.
+		if err := c.storage.DeleteSession(c.ctx, session.UUID); err != nil {
+			logger.Errorf("session pow failed deleting session after invalid proof: %v", err)
+		}
+		logger.Infof("session pow rejected: invalid proof of work")
+		http.Error(w, "invalid proof of work", http.StatusForbidden)
+		return
+	}
+
+	var activation_nonce [activationNonceSize]byte
+	if _, err := rand.Read(activation_nonce[:]); err != nil {
+		logger.Errorf("session pow entropy read failed for activation nonce: %v", err)
+		http.Error(w, "could not read entropy", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := c.storage.UpdateSession(c.ctx, session.UUID, func(s *models.Session) error {
+		if s.PoWSolved() {
+			return errPoWAlreadySolved
+		}
+		s.PoWSolution = body_decoded.Nonce
+		s.ActivationNonce = activation_nonce[:]
+		return nil
+	}); err != nil {
+		logger.Errorf("session pow failed persisting solution: %v", err)
+		http.Error(w, "could not store session", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.SessionPoWResponse{
+		Status:          "ok",
+		ActivationNonce: activation_nonce[:],
+	}
+	if err := wire.Write(w, r, http.StatusOK, response, models_responses.SessionPoWResponseFromProto(response)); err != nil {
+		logger.Errorf("session pow response encode failed: %v", err)
+		return
+	}
+	logger.Verbosef("session pow completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/crypto"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestController serves from a MemoryStore with the cheapest proof of
+// work, and allows max_requests session inits per identity.
+func newTestController(t *testing.T, max_requests int) (*Controller, database.Store) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	cfg := DefaultConfig()
+	cfg.SessionInitMaxRequests = max_requests
+	cfg.PoWMemoryMB = 1
+	cfg.PoWIterationsMin = 1
+	cfg.PoWIterationsMax = 1
+	storage := database.NewMemoryStore()
+	return NewController(ctx, cfg, storage), storage
+}
+
+// postProto sends req to handler as protobuf and decodes a 200 response into
+// resp.
+func postProto(t *testing.T, handler http.HandlerFunc, req, resp proto.Message) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	r.RemoteAddr = "198.51.100.7:4000"
+	r.Header.Set("Content-Type", wire.ContentTypeProtobuf)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	if w.Code == http.StatusOK {
+		if err := proto.Unmarshal(w.Body.Bytes(), resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w
+}
+
+var testClientSoul = bytes.Repeat([]byte{9}, 32)
+
+func sessionInitRequest(t *testing.T, soul []byte) *umbrapb.SessionInitRequest {
+	t.Helper()
+	x_pubkey, err := crypto.DeriveX25519PubKey(soul)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &umbrapb.SessionInitRequest{
+		ClientEdPubkey:    crypto.DeriveEd25519PubKey(soul),
+		ClientXPubkey:     x_pubkey,
+		ClientXPubkeySign: crypto.Sign(soul, x_pubkey),
+	}
+}
+
+// initSession starts a session the way the client does and returns the
+// decrypted payload.
+func initSession(t *testing.T, c *Controller) *umbrapb.SessionInitPayload {
+	t.Helper()
+	var resp umbrapb.SessionInitResponse
+	w := postProto(t, c.SessionInit, sessionInitRequest(t, testClientSoul), &resp)
+	if w.Code != http.StatusOK {
+		t.Fatalf("session init: %d %s", w.Code, w.Body)
+	}
+	if !crypto.Verify(resp.GetServerEdPubkey(), resp.GetPayload(), resp.GetSignature()) {
+		t.Fatal("session init response signature does not verify")
+	}
+
+	shared_secret, err := crypto.ComputeSharedSecret(testClientSoul, resp.GetServerXPubkey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed := resp.GetPayload()
+	raw, valid, err := crypto.MACE_Decrypt_AEAD(crypto.KDF(shared_secret, "@SESSION-SHARED-KEY", 32), packed[28:], packed[:12], packed[12:28], "@RESPONSE-PAYLOAD", 8)
+	if err != nil || !valid {
+		t.Fatalf("session init payload does not open: valid=%v err=%v", valid, err)
+	}
+	var payload umbrapb.SessionInitPayload
+	if err := proto.Unmarshal(raw, &payload); err != nil {
+		t.Fatal(err)
+	}
+	return &payload
+}
+
+// solvePoW searches for a nonce the session accepts.
+func solvePoW(t *testing.T, payload *umbrapb.SessionInitPayload, valid bool) []byte {
+	t.Helper()
+	params := payload.GetPowParams()
+	session := &models.Session{
+		PoWParams: models.PowParamsType{
+			MemoryMB:    uint(params.GetMemoryMb()),
+			Iterations:  uint(params.GetIterations()),
+			Parallelism: uint(params.GetParallelism()),
+		},
+	}
+	copy(session.PoWChallenge[:], payload.GetPowChallenge())
+	copy(session.PoWSalt[:], payload.GetPowSalt())
+	nonce := make([]byte, powNonceSize)
+	for i := uint64(0); ; i++ {
+		binary.BigEndian.PutUint64(nonce, i)
+		if verifyPoW(session, nonce) == valid {
+			return nonce
+		}
+	}
+}
+
+func TestSessionInitThenPoW(t *testing.T) {
+	c, storage := newTestController(t, 32)
+	payload := initSession(t, c)
+	session_id := [24]byte(payload.GetSessionId())
+	if session, err := storage.PeekSessionByUUID(context.Background(), session_id); err != nil {
+		t.Fatalf("session not stored: %v", err)
+	} else if session.State != models.SessionStatePending {
+		t.Fatalf("new session is %q, want %q", session.State, models.SessionStatePending)
+	}
+
+	nonce := solvePoW(t, payload, true)
+	var resp umbrapb.SessionPoWResponse
+	if w := postProto(t, c.SessionPoW, &umbrapb.SessionPoWRequest{SessionId: session_id[:], Nonce: nonce}, &resp); w.Code != http.StatusOK {
+		t.Fatalf("session pow: %d %s", w.Code, w.Body)
+	}
+	if len(resp.GetActivationNonce()) != activationNonceSize {
+		t.Fatalf("activation nonce of %d bytes, want %d", len(resp.GetActivationNonce()), activationNonceSize)
+	}
+	session, err := storage.PeekSessionByUUID(context.Background(), session_id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.PoWSolved() || !bytes.Equal(session.ActivationNonce, resp.GetActivationNonce()) {
+		t.Fatal("solved proof of work not stored on the session")
+	}
+
+	if w := postProto(t, c.SessionPoW, &umbrapb.SessionPoWRequest{SessionId: session_id[:], Nonce: nonce}, &resp); w.Code != http.StatusConflict {
+		t.Fatalf("repeated pow: %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestSessionPoWInvalidProof(t *testing.T) {
+	c, storage := newTestController(t, 32)
+	payload := initSession(t, c)
+	session_id := [24]byte(payload.GetSessionId())
+
+	var resp umbrapb.SessionPoWResponse
+	if w := postProto(t, c.SessionPoW, &umbrapb.SessionPoWRequest{SessionId: session_id[:], Nonce: solvePoW(t, payload, false)}, &resp); w.Code != http.StatusForbidden {
+		t.Fatalf("invalid pow: %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if _, err := storage.PeekSessionByUUID(context.Background(), session_id); err != database.ErrNotFound {
+		t.Fatalf("session after an invalid proof: %v, want ErrNotFound", err)
+	}
+	if w := postProto(t, c.SessionPoW, &umbrapb.SessionPoWRequest{SessionId: session_id[:], Nonce: solvePoW(t, payload, true)}, &resp); w.Code != http.StatusNotFound {
+		t.Fatalf("pow after the session was dropped: %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestSessionInitRateLimit(t *testing.T) {
+	c, _ := newTestController(t, 2)
+	initSession(t, c)
+	initSession(t, c)
+
+	w := postProto(t, c.SessionInit, sessionInitRequest(t, testClientSoul), nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third session init: %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("rate-limited session init has no Retry-After")
+	}
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+)
+
+// UserKDF hands out the current soul KDF with a fresh salt, so a registering
+// client derives its soul key under the parameters the server will accept.
+func (c *Controller) UserKDF(w http.ResponseWriter, r *http.Request) {
+	soul_kdf, err := core.NewSoulKDF()
+	if err != nil {
+		logger.Errorf("user kdf failed generating soul kdf salt: %v", err)
+		http.Error(w, "could not generate soul kdf", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.UserKDFResponse{
+		Status:  "ok",
+		SoulKdf: soulKDFToProto(soul_kdf),
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.UserKDFResponseFromProto(response))
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/crypto"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+// UserLogin hands the enciphered soul of a user to the session. Only a client
+// that knows the password can decipher it, and it has to prove that through
+// UserLoginProof before the session is bound to the user.
+func (c *Controller) UserLogin(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("user login started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	var username string
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.UserLoginRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("user login rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		username = body_pb.GetUsername()
+	} else {
+		var body_encoded models_requests.UserLoginRequestEncoded
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("user login rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		username = body_encoded.Username
+	}
+
+	if err := core.ValidateUsername(username); err != nil {
+		logger.Debugf("user login rejected: invalid username")
+		http.Error(w, "invalid username", http.StatusBadRequest)
+		return
+	}
+
+	user, err := c.storage.GetUserByUsername(c.ctx, username)
+	if errors.Is(err, database.ErrNotFound) {
+		logger.Debugf("user login rejected: user not found")
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		logger.Errorf("user login failed loading user: %v", err)
+		http.Error(w, "could not load user", http.StatusInternalServerError)
+		return
+	}
+	if user.Disabled() {
+		logger.Infof("user login rejected: account disabled")
+		http.Error(w, "account disabled", http.StatusForbidden)
+		return
+	}
+
+	response := &umbrapb.UserLoginResponse{
+		Status:             "ok",
+		Uuid:               user.UUID,
+		EncipheredSoul:     user.EncipheredSoul,
+		EncipheredSoulSalt: user.EncipheredSoulSalt,
+		EncipheredSoulTag:  user.EncipheredSoulTag,
+		SoulKdf:            soulKDFToProto(user.SoulKDF),
+		UpgradeRequired:    core.SoulKDFNeedsUpgrade(user.SoulKDF),
+	}
+	if response.UpgradeRequired {
+		upgrade_kdf, err := core.NewSoulKDF()
+		if err != nil {
+			logger.Errorf("user login failed generating soul kdf salt: %v", err)
+			http.Error(w, "could not generate soul kdf", http.StatusInternalServerError)
+			return
+		}
+		response.UpgradeKdf = soulKDFToProto(upgrade_kdf)
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.UserLoginResponseFromProto(response))
+	logger.Verbosef("user login completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
+
+func (c *Controller) UserLoginProof(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("user login proof started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+
+	var (
+		err          error
+		body_encoded models_requests.UserLoginProofRequestEncoded
+		body_decoded models_requests.UserLoginProofRequestDecoded
+	)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.UserLoginProofRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("user login proof rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.UserLoginProofRequestDecoded{
+			Username:  body_pb.GetUsername(),
+			Signature: body_pb.GetSignature(),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("user login proof rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded.Username = body_encoded.Username
+		if body_decoded.Signature, err = db64(body_encoded.Signature); err != nil {
+			logger.Debugf("user login proof rejected: invalid signature encoding err=%v", err)
+			http.Error(w, "invalid signature base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := core.ValidateUsername(body_decoded.Username); err != nil {
+		logger.Debugf("user login proof rejected: invalid username")
+		http.Error(w, "invalid username", http.StatusBadRequest)
+		return
+	}
+
+	user, err := c.storage.GetUserByUsername(c.ctx, body_decoded.Username)
+	if errors.Is(err, database.ErrNotFound) {
+		logger.Debugf("user login proof rejected: user not found")
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		logger.Errorf("user login proof failed loading user: %v", err)
+		http.Error(w, "could not load user", http.StatusInternalServerError)
+		return
+	}
+	if user.Disabled() {
+		logger.Infof("user login proof rejected: account disabled")
+		http.Error(w, "account disabled", http.StatusForbidden)
+		return
+	}
+
+	if !crypto.Verify(user.EPublicKey, core.UserProofMessage(env.session.UUID[:]), body_decoded.Signature) {
+		logger.Infof("user login proof rejected: invalid user soul proof")
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	if _, err := c.storage.UpdateSession(c.ctx, env.session.UUID, func(s *models.Session) error {
+		s.UserUUID = user.UUID
+		return nil
+	}); err != nil {
+		logger.Errorf("user login proof failed binding session to user: %v", err)
+		http.Error(w, "could not update session", http.StatusInternalServerError)
+		return
+	}
+
+	// The session is bound either way: the client needs it to re-encipher the
+	// soul through /user/soul before doing anything else.
+	status := "ok"
+	if core.SoulKDFNeedsUpgrade(user.SoulKDF) {
+		status = "upgrade_required"
+	}
+	response := &umbrapb.StatusResponse{
+		Status: status,
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.StatusResponseFromProto(response))
+	logger.Verbosef("user login proof completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+// UserLookup returns the public keys of a user, which a group owner needs to
+// wrap the group secret to them.
+func (c *Controller) UserLookup(w http.ResponseWriter, r *http.Request) {
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+	if _, ok := c.sessionUser(w, env); !ok {
+		return
+	}
+
+	var username string
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.UserLookupRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("user lookup rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		username = body_pb.GetUsername()
+	} else {
+		var body_encoded models_requests.UserLookupRequestEncoded
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("user lookup rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		username = body_encoded.Username
+	}
+
+	if err := core.ValidateUsername(username); err != nil {
+		logger.Debugf("user lookup rejected: invalid username")
+		http.Error(w, "invalid username", http.StatusBadRequest)
+		return
+	}
+
+	user, err := c.storage.GetUserByUsername(c.ctx, username)
+	if errors.Is(err, database.ErrNotFound) {
+		logger.Debugf("user lookup rejected: user not found")
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		logger.Errorf("user lookup failed loading user: %v", err)
+		http.Error(w, "could not load user", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.UserLookupResponse{
+		Status:  "ok",
+		Uuid:    user.UUID,
+		XPubKey: user.XPublicKey,
+		EPubKey: user.EPublicKey,
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.UserLookupResponseFromProto(response))
+}
@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+const (
+	// Fetching the recovery blob and resetting with it share one budget per
+	// username and client identity, so a stranger spending theirs does not
+	// touch the owner's.
+	userRecoveryWindow      = 1 * time.Hour
+	userRecoveryMaxAttempts = 5
+	userRecoveryTrackerTTL  = 2 * time.Hour
+	// An identity that fails this many proofs on a username within the
+	// window cannot try that username again for the cooldown.
+	userRecoveryMaxFailures = 3
+	userRecoveryCooldown    = 24 * time.Hour
+
+	// Every identity together gets a larger budget on one username, so a
+	// guessing run spread over many addresses still runs into a ceiling and
+	// a cooldown. The cooldown is shorter, since it locks out the owner too.
+	userRecoveryUsernameMaxAttempts = 30
+	userRecoveryUsernameMaxFailures = 10
+	userRecoveryUsernameCooldown    = 6 * time.Hour
+)
+
+// recoveryEveryIdentity is the identity the username-wide budget is tracked
+// under. IdentityHash never returns it.
+const recoveryEveryIdentity = ""
+
+// allowRecoveryAttempt counts an attempt by r's client against username, first
+// on the client's own budget and then on the username-wide one, and writes the
+// 429 itself when either is spent. An attempt the client's own budget refuses
+// does not count against the username, so one client cannot spend the budget
+// of everyone else.
+func (c *Controller) allowRecoveryAttempt(w http.ResponseWriter, r *http.Request, username string) bool {
+	identity := IdentityHash(c.clients.ClientIdentity(r))
+	now := time.Now().UTC()
+	limited, retryAfter, err := c.storage.RegisterRecoveryAttempt(c.ctx, identity, username, now, userRecoveryWindow, userRecoveryMaxAttempts, userRecoveryTrackerTTL)
+	if err == nil && !limited {
+		limited, retryAfter, err = c.storage.RegisterRecoveryAttempt(c.ctx, recoveryEveryIdentity, username, now, userRecoveryWindow, userRecoveryUsernameMaxAttempts, userRecoveryTrackerTTL)
+	}
+	if err != nil {
+		logger.Errorf("user recovery failed tracking attempt: %v", err)
+		http.Error(w, "could not track recovery attempt", http.StatusInternalServerError)
+		return false
+	}
+	if limited {
+		retryAfterSeconds := int64(retryAfter / time.Second)
+		if retryAfterSeconds < 1 {
+			retryAfterSeconds = 1
+		}
+		logger.Infof("user recovery rejected: rate limited retry_after_s=%d", retryAfterSeconds)
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfterSeconds, 10))
+		http.Error(w, "too many recovery attempts", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// registerRecoveryFailure counts a failed proof by r's client on username
+// against both budgets, and reports whether either is now cooling down.
+func (c *Controller) registerRecoveryFailure(r *http.Request, username string) (cooling bool, err error) {
+	identity := IdentityHash(c.clients.ClientIdentity(r))
+	now := time.Now().UTC()
+	cooling, err = c.storage.RegisterRecoveryFailure(c.ctx, identity, username, now, userRecoveryWindow, userRecoveryMaxFailures, userRecoveryCooldown)
+	if err != nil {
+		return false, err
+	}
+	username_cooling, err := c.storage.RegisterRecoveryFailure(c.ctx, recoveryEveryIdentity, username, now, userRecoveryWindow, userRecoveryUsernameMaxFailures, userRecoveryUsernameCooldown)
+	return cooling || username_cooling, err
+}
+
+// UserRecovery hands out the recovery blob and the enciphered soul of a user.
+// Both are useless without the recovery key, which never leaves the client.
+func (c *Controller) UserRecovery(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("user recovery started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	var username string
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.UserRecoveryRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("user recovery rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		username = body_pb.GetUsername()
+	} else {
+		var body_encoded models_requests.UserRecoveryRequestEncoded
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("user recovery rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		username = body_encoded.Username
+	}
+
+	if err := core.ValidateUsername(username); err != nil {
+		logger.Debugf("user recovery rejected: invalid username")
+		http.Error(w, "invalid username", http.StatusBadRequest)
+		return
+	}
+	if !c.allowRecoveryAttempt(w, r, username) {
+		return
+	}
+
+	user, err := c.storage.GetUserByUsername(c.ctx, username)
+	if errors.Is(err, database.ErrNotFound) {
+		logger.Debugf("user recovery rejected: user not found")
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		logger.Errorf("user recovery failed loading user: %v", err)
+		http.Error(w, "could not load user", http.StatusInternalServerError)
+		return
+	}
+	if user.Disabled() {
+		logger.Infof("user recovery rejected: account disabled")
+		http.Error(w, "account disabled", http.StatusForbidden)
+		return
+	}
+
+	new_soul_kdf, err := core.NewSoulKDF()
+	if err != nil {
+		logger.Errorf("user recovery failed generating soul kdf salt: %v", err)
+		http.Error(w, "could not generate soul kdf", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.UserRecoveryResponse{
+		Status:             "ok",
+		Uuid:               user.UUID,
+		EncipheredSoul:     user.EncipheredSoul,
+		EncipheredSoulSalt: user.EncipheredSoulSalt,
+		EncipheredSoulTag:  user.EncipheredSoulTag,
+		SoulRecovery:       user.SoulRecovery,
+		SoulRecoverySalt:   user.SoulRecoverySalt,
+		SoulRecoveryTag:    user.SoulRecoveryTag,
+		NewSoulKdf:         soulKDFToProto(new_soul_kdf),
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.UserRecoveryResponseFromProto(response))
+	logger.Verbosef("user recovery completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
+
+func (c *Controller) UserRecoveryReset(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("user recovery reset started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+
+	var (
+		err          error
+		body_encoded models_requests.UserRecoveryResetRequestEncoded
+		body_decoded models_requests.UserRecoveryResetRequestDecoded
+	)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.UserRecoveryResetRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("user recovery reset rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.UserRecoveryResetRequestDecoded{
+			Username:           body_pb.GetUsername(),
+			Signature:          body_pb.GetSignature(),
+			EncipheredSoul:     body_pb.GetEncipheredSoul(),
+			EncipheredSoulSalt: body_pb.GetEncipheredSoulSalt(),
+			EncipheredSoulTag:  body_pb.GetEncipheredSoulTag(),
+			SoulRecovery:       body_pb.GetSoulRecovery(),
+			SoulRecoverySalt:   body_pb.GetSoulRecoverySalt(),
+			SoulRecoveryTag:    body_pb.GetSoulRecoveryTag(),
+			SoulKDF:            soulKDFFromProto(body_pb.GetSoulKdf()),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("user recovery reset rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		body_decoded.Username = body_encoded.Username
+		fields := []struct {
+			name    string
+			encoded string
+			decoded *[]byte
+		}{
+			{"signature", body_encoded.Signature, &body_decoded.Signature},
+			{"enciphered_soul", body_encoded.EncipheredSoul, &body_decoded.EncipheredSoul},
+			{"enciphered_soul_salt", body_encoded.EncipheredSoulSalt, &body_decoded.EncipheredSoulSalt},
+			{"enciphered_soul_tag", body_encoded.EncipheredSoulTag, &body_decoded.EncipheredSoulTag},
+			{"soul_recovery", body_encoded.SoulRecovery, &body_decoded.SoulRecovery},
+			{"soul_recovery_salt", body_encoded.SoulRecoverySalt, &body_decoded.SoulRecoverySalt},
+			{"soul_recovery_tag", body_encoded.SoulRecoveryTag, &body_decoded.SoulRecoveryTag},
+		}
+		for _, field := range fields {
+			if *field.decoded, err = db64(field.encoded); err != nil {
+				logger.Debugf("user recovery reset rejected: invalid %s encoding err=%v", field.name, err)
+				http.Error(w, "invalid "+field.name+" base64 encoding", http.StatusBadRequest)
+				return
+			}
+		}
+		if body_decoded.SoulKDF, err = decodeSoulKDF(body_encoded.SoulKDF); err != nil {
+			logger.Debugf("user recovery reset rejected: invalid soul_kdf salt encoding err=%v", err)
+			http.Error(w, "invalid soul_kdf salt base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := core.ValidateUsername(body_decoded.Username); err != nil {
+		logger.Debugf("user recovery reset rejected: invalid username")
+		http.Error(w, "invalid username", http.StatusBadRequest)
+		return
+	}
+	if !c.allowRecoveryAttempt(w, r, body_decoded.Username) {
+		return
+	}
+
+	user, err := core.RecoverUser(c.ctx, c.storage, env.session.UUID[:], body_decoded.Username, &models.User{
+		EncipheredSoul:     body_decoded.EncipheredSoul,
+		EncipheredSoulSalt: body_decoded.EncipheredSoulSalt,
+		EncipheredSoulTag:  body_decoded.EncipheredSoulTag,
+		SoulRecovery:       body_decoded.SoulRecovery,
+		SoulRecoverySalt:   body_decoded.SoulRecoverySalt,
+		SoulRecoveryTag:    body_decoded.SoulRecoveryTag,
+		SoulKDF:            body_decoded.SoulKDF,
+	}, body_decoded.Signature)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		logger.Debugf("user recovery reset rejected: user not found")
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	case errors.Is(err, core.ErrUserDisabled):
+		logger.Infof("user recovery reset rejected: account disabled")
+		http.Error(w, "account disabled", http.StatusForbidden)
+		return
+	case errors.Is(err, core.ErrInvalidUserProof):
+		cooling, err := c.registerRecoveryFailure(r, body_decoded.Username)
+		if err != nil {
+			logger.Errorf("user recovery reset failed tracking failed proof: %v", err)
+		}
+		logger.Infof("user recovery reset rejected: invalid user soul proof cooling_down=%t", cooling)
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	case errors.Is(err, core.ErrInvalidSoulBlob), errors.Is(err, core.ErrInvalidSoulKDF), errors.Is(err, core.ErrStaleRecovery):
+		logger.Debugf("user recovery reset rejected: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		logger.Errorf("user recovery reset failed updating user: %v", err)
+		http.Error(w, "could not update user", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := c.storage.UpdateSession(c.ctx, env.session.UUID, func(s *models.Session) error {
+		s.UserUUID = user.UUID
+		return nil
+	}); err != nil {
+		logger.Errorf("user recovery reset failed binding session to user: %v", err)
+		http.Error(w, "could not update session", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.UserRecoveryResetResponse{
+		Status:           "ok",
+		Uuid:             user.UUID,
+		SoulRecovery:     user.SoulRecovery,
+		SoulRecoverySalt: user.SoulRecoverySalt,
+		SoulRecoveryTag:  user.SoulRecoveryTag,
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.UserRecoveryResetResponseFromProto(response))
+	logger.Verbosef("user recovery reset completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
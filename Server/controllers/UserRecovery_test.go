@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func recoveryRequest(client int) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.RemoteAddr = fmt.Sprintf("198.51.100.%d:4000", client)
+	return r
+}
+
+func allowRecovery(c *Controller, client int, username string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	if c.allowRecoveryAttempt(w, recoveryRequest(client), username) {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w
+}
+
+func TestRecoveryAttemptBudgets(t *testing.T) {
+	c, _ := newTestController(t, 1)
+
+	for i := 0; i < userRecoveryMaxAttempts; i++ {
+		if w := allowRecovery(c, 1, "alice"); w.Code != http.StatusOK {
+			t.Fatalf("attempt %d: %d", i, w.Code)
+		}
+	}
+	if w := allowRecovery(c, 1, "alice"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("attempt past the identity budget: %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	// Refused attempts do not count against the username.
+	for i := 0; i < userRecoveryUsernameMaxAttempts; i++ {
+		allowRecovery(c, 1, "alice")
+	}
+
+	// Spread over identities, the username still runs into its ceiling.
+	allowed := userRecoveryMaxAttempts
+	for client := 2; allowed < userRecoveryUsernameMaxAttempts; client++ {
+		for i := 0; i < userRecoveryMaxAttempts && allowed < userRecoveryUsernameMaxAttempts; i++ {
+			if w := allowRecovery(c, client, "alice"); w.Code != http.StatusOK {
+				t.Fatalf("attempt %d of client %d: %d", i, client, w.Code)
+			}
+			allowed++
+		}
+	}
+	if w := allowRecovery(c, 200, "alice"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("fresh identity past the username ceiling: %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w := allowRecovery(c, 200, "bob"); w.Code != http.StatusOK {
+		t.Fatalf("another username: %d", w.Code)
+	}
+}
+
+func TestRecoveryFailureCooldowns(t *testing.T) {
+	c, _ := newTestController(t, 1)
+
+	for i := 0; i < userRecoveryMaxFailures; i++ {
+		cooling, err := c.registerRecoveryFailure(recoveryRequest(1), "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cooling != (i == userRecoveryMaxFailures-1) {
+			t.Fatalf("failure %d: cooling=%v", i, cooling)
+		}
+	}
+	if w := allowRecovery(c, 1, "alice"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("identity cooling down: %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w := allowRecovery(c, 2, "alice"); w.Code != http.StatusOK {
+		t.Fatalf("other identity during one identity's cooldown: %d", w.Code)
+	}
+
+	// One failure each from enough identities cools the whole username.
+	for client := 2; client < 2+userRecoveryUsernameMaxFailures-userRecoveryMaxFailures; client++ {
+		if _, err := c.registerRecoveryFailure(recoveryRequest(client), "alice"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := allowRecovery(c, 200, "alice")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("fresh identity during the username cooldown: %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	retry_after, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || time.Duration(retry_after)*time.Second > userRecoveryUsernameCooldown || time.Duration(retry_after)*time.Second < userRecoveryUsernameCooldown-time.Minute {
+		t.Fatalf("Retry-After %q, want about %s", w.Header().Get("Retry-After"), userRecoveryUsernameCooldown)
+	}
+}
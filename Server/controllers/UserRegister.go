@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+func (c *Controller) UserRegister(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("user register started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+
+	var (
+		err          error
+		body_encoded models_requests.UserRegisterRequestEncoded
+		body_decoded models_requests.UserRegisterRequestDecoded
+	)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.UserRegisterRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("user register rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.UserRegisterRequestDecoded{
+			Username:           body_pb.GetUsername(),
+			XPublicKey:         body_pb.GetXPubKey(),
+			EPublicKey:         body_pb.GetEPubKey(),
+			EncipheredSoul:     body_pb.GetEncipheredSoul(),
+			EncipheredSoulSalt: body_pb.GetEncipheredSoulSalt(),
+			EncipheredSoulTag:  body_pb.GetEncipheredSoulTag(),
+			SoulRecovery:       body_pb.GetSoulRecovery(),
+			SoulRecoverySalt:   body_pb.GetSoulRecoverySalt(),
+			SoulRecoveryTag:    body_pb.GetSoulRecoveryTag(),
+			Signature:          body_pb.GetSignature(),
+			SoulKDF:            soulKDFFromProto(body_pb.GetSoulKdf()),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("user register rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		body_decoded.Username = body_encoded.Username
+		fields := []struct {
+			name    string
+			encoded string
+			decoded *[]byte
+		}{
+			{"x_pub_key", body_encoded.XPublicKey, &body_decoded.XPublicKey},
+			{"e_pub_key", body_encoded.EPublicKey, &body_decoded.EPublicKey},
+			{"enciphered_soul", body_encoded.EncipheredSoul, &body_decoded.EncipheredSoul},
+			{"enciphered_soul_salt", body_encoded.EncipheredSoulSalt, &body_decoded.EncipheredSoulSalt},
+			{"enciphered_soul_tag", body_encoded.EncipheredSoulTag, &body_decoded.EncipheredSoulTag},
+			{"soul_recovery", body_encoded.SoulRecovery, &body_decoded.SoulRecovery},
+			{"soul_recovery_salt", body_encoded.SoulRecoverySalt, &body_decoded.SoulRecoverySalt},
+			{"soul_recovery_tag", body_encoded.SoulRecoveryTag, &body_decoded.SoulRecoveryTag},
+			{"signature", body_encoded.Signature, &body_decoded.Signature},
+		}
+		for _, field := range fields {
+			if *field.decoded, err = db64(field.encoded); err != nil {
+				logger.Debugf("user register rejected: invalid %s encoding err=%v", field.name, err)
+				http.Error(w, "invalid "+field.name+" base64 encoding", http.StatusBadRequest)
+				return
+			}
+		}
+		if body_decoded.SoulKDF, err = decodeSoulKDF(body_encoded.SoulKDF); err != nil {
+			logger.Debugf("user register rejected: invalid soul_kdf salt encoding err=%v", err)
+			http.Error(w, "invalid soul_kdf salt base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	user := models.User{
+		Username:           body_decoded.Username,
+		XPublicKey:         body_decoded.XPublicKey,
+		EPublicKey:         body_decoded.EPublicKey,
+		EncipheredSoul:     body_decoded.EncipheredSoul,
+		EncipheredSoulSalt: body_decoded.EncipheredSoulSalt,
+		EncipheredSoulTag:  body_decoded.EncipheredSoulTag,
+		SoulRecovery:       body_decoded.SoulRecovery,
+		SoulRecoverySalt:   body_decoded.SoulRecoverySalt,
+		SoulRecoveryTag:    body_decoded.SoulRecoveryTag,
+		SoulKDF:            body_decoded.SoulKDF,
+	}
+	err = core.RegisterUser(c.ctx, c.storage, env.session.UUID[:], &user, body_decoded.Signature)
+	switch {
+	case errors.Is(err, core.ErrInvalidUsername):
+		logger.Debugf("user register rejected: invalid username")
+		http.Error(w, "invalid username", http.StatusBadRequest)
+		return
+	case errors.Is(err, core.ErrInvalidUserKeys), errors.Is(err, core.ErrInvalidSoulBlob), errors.Is(err, core.ErrInvalidSoulKDF):
+		logger.Debugf("user register rejected: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, core.ErrInvalidUserProof):
+		logger.Infof("user register rejected: invalid user soul proof")
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	case errors.Is(err, database.ErrUsernameTaken):
+		logger.Debugf("user register rejected: username taken")
+		http.Error(w, "username taken", http.StatusConflict)
+		return
+	case err != nil:
+		logger.Errorf("user register failed storing user: %v", err)
+		http.Error(w, "could not store user", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := c.storage.UpdateSession(c.ctx, env.session.UUID, func(s *models.Session) error {
+		s.UserUUID = user.UUID
+		return nil
+	}); err != nil {
+		logger.Errorf("user register failed binding session to user: %v", err)
+		http.Error(w, "could not update session", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.UserRegisterResponse{
+		Status: "ok",
+		Uuid:   user.UUID,
+	}
+	writeEnvelopeResponse(w, r, http.StatusCreated, response, models_responses.UserRegisterResponseFromProto(response))
+	logger.Verbosef("user register completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
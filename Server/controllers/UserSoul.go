@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+// UserSoul re-enciphers the soul of the user bound to the session, which is
+// how a user moves to the current soul KDF after logging in. Disabled users
+// cannot rewrite their soul or recovery blob.
+func (c *Controller) UserSoul(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	logger.Verbosef("user soul started method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+	user, ok := c.sessionUserAnyKDF(w, env)
+	if !ok {
+		return
+	}
+
+	var (
+		err          error
+		body_encoded models_requests.UserSoulRequestEncoded
+		body_decoded models_requests.UserSoulRequestDecoded
+	)
+	if wire.IsProtobuf(r) {
+		var body_pb umbrapb.UserSoulRequest
+		if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+			logger.Debugf("user soul rejected: malformed protobuf body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		body_decoded = models_requests.UserSoulRequestDecoded{
+			EncipheredSoul:     body_pb.GetEncipheredSoul(),
+			EncipheredSoulSalt: body_pb.GetEncipheredSoulSalt(),
+			EncipheredSoulTag:  body_pb.GetEncipheredSoulTag(),
+			SoulRecovery:       body_pb.GetSoulRecovery(),
+			SoulRecoverySalt:   body_pb.GetSoulRecoverySalt(),
+			SoulRecoveryTag:    body_pb.GetSoulRecoveryTag(),
+			SoulKDF:            soulKDFFromProto(body_pb.GetSoulKdf()),
+		}
+	} else {
+		if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+			logger.Debugf("user soul rejected: malformed json body err=%v", err)
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		fields := []struct {
+			name    string
+			encoded string
+			decoded *[]byte
+		}{
+			{"enciphered_soul", body_encoded.EncipheredSoul, &body_decoded.EncipheredSoul},
+			{"enciphered_soul_salt", body_encoded.EncipheredSoulSalt, &body_decoded.EncipheredSoulSalt},
+			{"enciphered_soul_tag", body_encoded.EncipheredSoulTag, &body_decoded.EncipheredSoulTag},
+			{"soul_recovery", body_encoded.SoulRecovery, &body_decoded.SoulRecovery},
+			{"soul_recovery_salt", body_encoded.SoulRecoverySalt, &body_decoded.SoulRecoverySalt},
+			{"soul_recovery_tag", body_encoded.SoulRecoveryTag, &body_decoded.SoulRecoveryTag},
+		}
+		for _, field := range fields {
+			if *field.decoded, err = db64(field.encoded); err != nil {
+				logger.Debugf("user soul rejected: invalid %s encoding err=%v", field.name, err)
+				http.Error(w, "invalid "+field.name+" base64 encoding", http.StatusBadRequest)
+				return
+			}
+		}
+		if body_decoded.SoulKDF, err = decodeSoulKDF(body_encoded.SoulKDF); err != nil {
+			logger.Debugf("user soul rejected: invalid soul_kdf salt encoding err=%v", err)
+			http.Error(w, "invalid soul_kdf salt base64 encoding", http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, err = core.UpgradeSoul(c.ctx, c.storage, user.UUID, &models.User{
+		EncipheredSoul:     body_decoded.EncipheredSoul,
+		EncipheredSoulSalt: body_decoded.EncipheredSoulSalt,
+		EncipheredSoulTag:  body_decoded.EncipheredSoulTag,
+		SoulRecovery:       body_decoded.SoulRecovery,
+		SoulRecoverySalt:   body_decoded.SoulRecoverySalt,
+		SoulRecoveryTag:    body_decoded.SoulRecoveryTag,
+		SoulKDF:            body_decoded.SoulKDF,
+	})
+	switch {
+	case errors.Is(err, core.ErrInvalidSoulBlob), errors.Is(err, core.ErrInvalidSoulKDF):
+		logger.Debugf("user soul rejected: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, database.ErrNotFound):
+		logger.Infof("user soul rejected: session bound to a missing user")
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	case err != nil:
+		logger.Errorf("user soul failed updating user: %v", err)
+		http.Error(w, "could not update user", http.StatusInternalServerError)
+		return
+	}
+
+	response := &umbrapb.StatusResponse{
+		Status: "ok",
+	}
+	writeEnvelopeResponse(w, r, http.StatusOK, response, models_responses.StatusResponseFromProto(response))
+	logger.Verbosef("user soul completed duration_ms=%d", time.Since(reqStart).Milliseconds())
+}
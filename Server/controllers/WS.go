@@ -1,15 +1,372 @@
 package controllers
 
 import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/crypto"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+	"github.com/olahol/melody"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	wsMaxFrameBytes      = 64 << 10
+	wsSessionCheckPeriod = 15 * time.Second
+
+	// Close codes in the 4000-4999 range are reserved for applications.
+	wsCloseProtocolError = 4000
+	wsCloseSessionEnded  = 4001
+	wsCloseServerClosing = 4002
+
+	wsDirectionClientToServer = "@WS-CLIENT-TO-SERVER"
+	wsDirectionServerToClient = "@WS-SERVER-TO-CLIENT"
 )
 
+var errWSFrameSequence = errors.New("websocket frame out of sequence")
+
+// wsConn is the per-connection channel state kept in the melody session keys.
+// Each direction has its own key and its own strictly increasing sequence
+// number, which is mixed into every frame together with the nonce of the
+// upgrade envelope, so a frame of one connection is worthless on any other.
+type wsConn struct {
+	mu sync.Mutex
+	// writeMu keeps frames on the wire in the order seal numbered them when
+	// pushes and replies race.
+	writeMu sync.Mutex
+
+	sessionID [24]byte
+	connNonce []byte
+	userUUID  []byte // refreshed by watchWSSessions, so a login mid-connection is picked up
+	asProto   bool
+	recvKey   []byte
+	sendKey   []byte
+	recvSeq   uint64
+	sendSeq   uint64
+
+	lastInbound time.Time
+	lastChecked time.Time
+}
+
+func wsFrameMixin(session_id, conn_nonce []byte, direction string, seq []byte) []byte {
+	mixin := make([]byte, 0, len(session_id)+len(conn_nonce)+len(direction)+len(seq))
+	mixin = append(mixin, session_id...)
+	mixin = append(mixin, conn_nonce...)
+	mixin = append(mixin, direction...)
+	mixin = append(mixin, seq...)
+	return mixin
+}
+
+func wsConnFrom(s *melody.Session) (*wsConn, bool) {
+	value, ok := s.Get("conn")
+	if !ok {
+		return nil, false
+	}
+	conn, ok := value.(*wsConn)
+	return conn, ok
+}
+
+// open authenticates and deciphers an inbound frame laid out as
+// seq (8 bytes) || salt (12 bytes) || tag (16 bytes) || cipher.
+func (conn *wsConn) open(frame []byte) ([]byte, error) {
+	if len(frame) < 8+12+16+64 {
+		return nil, errors.New("websocket frame too short")
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	seq := binary.BigEndian.Uint64(frame[:8])
+	if seq != conn.recvSeq+1 {
+		return nil, errWSFrameSequence
+	}
+	mixin := wsFrameMixin(conn.sessionID[:], conn.connNonce, wsDirectionClientToServer, frame[:8])
+	raw, valid, err := crypto.MACE_Decrypt_MIXIN_AEAD(conn.recvKey, frame[8+12+16:], mixin, frame[8:8+12], frame[8+12:8+12+16], "@WS-FRAME", 2)
+	if !valid {
+		return nil, errors.New("websocket frame authentication failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.recvSeq = seq
+	conn.lastInbound = time.Now().UTC()
+	return raw, nil
+}
+
+func (conn *wsConn) seal(payload []byte) []byte {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	conn.sendSeq++
+	frame := make([]byte, 8, 8+12+16+len(payload)+64)
+	binary.BigEndian.PutUint64(frame, conn.sendSeq)
+	mixin := wsFrameMixin(conn.sessionID[:], conn.connNonce, wsDirectionServerToClient, frame[:8])
+	cipher, salt, tag := crypto.MACE_Encrypt_MIXIN_AEAD(conn.sendKey, payload, mixin, "@WS-FRAME", 2, false)
+	frame = append(frame, salt...)
+	frame = append(frame, tag...)
+	return append(frame, cipher...)
+}
+
 func (c *Controller) WS(w http.ResponseWriter, r *http.Request) {
 	if c.ws == nil {
 		http.Error(w, "websocket server unavailable", http.StatusInternalServerError)
 		return
 	}
-	if err := c.ws.HandleRequest(w, r); err != nil {
+	env, ok := envelopeFrom(r)
+	if !ok {
+		http.Error(w, "missing request envelope", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now().UTC()
+	conn := &wsConn{
+		sessionID:   env.session.UUID,
+		connNonce:   append([]byte(nil), env.nonce...),
+		userUUID:    env.session.UserUUID,
+		asProto:     wire.WantsProtobuf(r),
+		recvKey:     crypto.KDF(env.sharedKey, wsDirectionClientToServer, 32),
+		sendKey:     crypto.KDF(env.sharedKey, wsDirectionServerToClient, 32),
+		lastInbound: now,
+		lastChecked: now,
+	}
+	if err := c.ws.HandleRequestWithKeys(w, r, map[string]any{"conn": conn}); err != nil {
 		http.Error(w, "websocket handshake failed", http.StatusBadRequest)
 	}
 }
+
+func (c *Controller) sendWS(s *melody.Session, pb *umbrapb.WSMessage, legacy any) error {
+	conn, ok := wsConnFrom(s)
+	if !ok {
+		return errors.New("websocket session has no channel state")
+	}
+	payload, err := wire.Marshal(conn.asProto, pb, legacy)
+	if err != nil {
+		return err
+	}
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	return s.WriteBinary(conn.seal(payload))
+}
+
+func (c *Controller) registerWSHandlers() {
+	c.ws.Config.MaxMessageSize = wsMaxFrameBytes
+
+	c.ws.HandleConnect(func(s *melody.Session) {
+		logger.Debugf("websocket connected remote=%s", s.RemoteAddr())
+	})
+	c.ws.HandleDisconnect(func(s *melody.Session) {
+		logger.Debugf("websocket disconnected remote=%s", s.RemoteAddr())
+	})
+	c.ws.HandleError(func(s *melody.Session, err error) {
+		logger.Debugf("websocket error remote=%s err=%v", s.RemoteAddr(), err)
+	})
+	c.ws.HandleMessage(func(s *melody.Session, _ []byte) {
+		logger.Debugf("websocket rejected text frame remote=%s", s.RemoteAddr())
+		_ = s.CloseWithMsg(melody.FormatCloseMessage(wsCloseProtocolError, "binary frames only"))
+	})
+	c.ws.HandleMessageBinary(func(s *melody.Session, frame []byte) {
+		conn, ok := wsConnFrom(s)
+		if !ok {
+			_ = s.CloseWithMsg(melody.FormatCloseMessage(wsCloseProtocolError, "unauthenticated"))
+			return
+		}
+		payload, err := conn.open(frame)
+		if err != nil {
+			logger.Infof("websocket frame rejected remote=%s err=%v", s.RemoteAddr(), err)
+			_ = s.CloseWithMsg(melody.FormatCloseMessage(wsCloseProtocolError, "invalid frame"))
+			return
+		}
+		c.handleWSPayload(s, conn, payload)
+	})
+}
+
+// wsLegacyMessage is the JSON form of umbrapb.WSMessage: the oneof case is
+// named by Type.
+type wsLegacyMessage struct {
+	Type        string                               `json:"type"`
+	ChatMessage *models_responses.ChatMessageEncoded `json:"chat_message,omitempty"`
+}
+
+// wsLegacyInbound is wsLegacyMessage as clients send it.
+type wsLegacyInbound struct {
+	Type        string                                         `json:"type"`
+	ChatMessage *models_requests.ChatMessageSendRequestEncoded `json:"chat_message,omitempty"`
+}
+
+func (c *Controller) handleWSPayload(s *melody.Session, conn *wsConn, payload []byte) {
+	var message umbrapb.WSMessage
+	if conn.asProto {
+		if err := proto.Unmarshal(payload, &message); err != nil {
+			logger.Debugf("websocket payload rejected: malformed protobuf err=%v", err)
+			return
+		}
+	} else {
+		var legacy wsLegacyInbound
+		if err := json.Unmarshal(payload, &legacy); err != nil {
+			logger.Debugf("websocket payload rejected: malformed json err=%v", err)
+			return
+		}
+		switch legacy.Type {
+		case "ping":
+			message.Body = &umbrapb.WSMessage_Ping{Ping: &umbrapb.Ping{}}
+		case "chat_message":
+			if legacy.ChatMessage == nil {
+				logger.Debugf("websocket payload rejected: chat_message without body")
+				return
+			}
+			decoded, err := decodeChatMessageSend(*legacy.ChatMessage)
+			if err != nil {
+				logger.Debugf("websocket payload rejected: %v", err)
+				return
+			}
+			message.Body = &umbrapb.WSMessage_ChatMessage{ChatMessage: &umbrapb.ChatMessage{
+				GroupUuid:          decoded.GroupUUID,
+				XPubKey:            decoded.XPublicKey,
+				Payload:            decoded.Payload,
+				Nonce:              decoded.Nonce,
+				SentAtUnixMillisec: decoded.SentAt,
+				SenderSignature:    decoded.SenderSignature,
+			}}
+		}
+	}
+
+	switch body := message.Body.(type) {
+	case *umbrapb.WSMessage_Ping:
+		reply := &umbrapb.WSMessage{Body: &umbrapb.WSMessage_Pong{Pong: &umbrapb.Pong{}}}
+		if err := c.sendWS(s, reply, wsLegacyMessage{Type: "pong"}); err != nil {
+			logger.Debugf("websocket pong failed err=%v", err)
+		}
+	case *umbrapb.WSMessage_ChatMessage:
+		// There is no reply: the stored message is pushed back to every
+		// connection of the group, this one included.
+		conn.mu.Lock()
+		user_uuid := conn.userUUID
+		conn.mu.Unlock()
+		if len(user_uuid) == 0 {
+			logger.Debugf("websocket chat message rejected: session not logged in")
+			return
+		}
+		sender, err := c.storage.GetUserByUUID(c.ctx, user_uuid)
+		if err != nil {
+			logger.Debugf("websocket chat message rejected: could not load sender err=%v", err)
+			return
+		}
+		if sender.Disabled() {
+			logger.Debugf("websocket chat message rejected: account disabled")
+			return
+		}
+		if core.SoulKDFNeedsUpgrade(sender.SoulKDF) {
+			logger.Debugf("websocket chat message rejected: soul kdf upgrade required")
+			return
+		}
+		c.sendGroupMessage(nil, sender, &models.Message{
+			GroupUUID:       body.ChatMessage.GetGroupUuid(),
+			XPublicKey:      body.ChatMessage.GetXPubKey(),
+			Payload:         body.ChatMessage.GetPayload(),
+			Nonce:           body.ChatMessage.GetNonce(),
+			SentAt:          time.UnixMilli(body.ChatMessage.GetSentAtUnixMillisec()).UTC(),
+			SenderSignature: body.ChatMessage.GetSenderSignature(),
+		})
+	default:
+		logger.Debugf("websocket payload ignored: unsupported message %T", message.Body)
+	}
+}
+
+// watchWSSessions closes connections whose session expired, was revoked or is
+// no longer active. Inbound traffic since the last check counts as activity
+// and slides the session expiry the same way an HTTP request does.
+func (c *Controller) watchWSSessions() {
+	ticker := time.NewTicker(wsSessionCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			_ = c.ws.CloseWithMsg(melody.FormatCloseMessage(wsCloseServerClosing, "server shutting down"))
+			return
+		case t := <-ticker.C:
+			sessions, err := c.ws.Sessions()
+			if err != nil {
+				continue
+			}
+			for _, s := range sessions {
+				conn, ok := wsConnFrom(s)
+				if !ok {
+					_ = s.Close()
+					continue
+				}
+				conn.mu.Lock()
+				active := conn.lastInbound.After(conn.lastChecked)
+				conn.lastChecked = t.UTC()
+				conn.mu.Unlock()
+
+				var (
+					session *models.Session
+					err     error
+				)
+				if active {
+					session, err = c.storage.GetSessionByUUID(c.ctx, conn.sessionID)
+				} else {
+					session, err = c.storage.PeekSessionByUUID(c.ctx, conn.sessionID)
+				}
+				if errors.Is(err, database.ErrNotFound) || (err == nil && !session.IsActive()) {
+					_ = s.CloseWithMsg(melody.FormatCloseMessage(wsCloseSessionEnded, "session ended"))
+				} else if err != nil {
+					logger.Errorf("websocket session check failed: %v", err)
+				} else {
+					conn.mu.Lock()
+					conn.userUUID = session.UserUUID
+					conn.mu.Unlock()
+				}
+			}
+		}
+	}
+}
+
+// pushGroupMessage delivers a stored message to every open connection whose
+// session is logged in as a member of the message's group.
+func (c *Controller) pushGroupMessage(message *models.Message) {
+	members, err := c.storage.ListGroupMembers(c.ctx, message.GroupUUID)
+	if err != nil {
+		logger.Errorf("websocket push failed loading group members: %v", err)
+		return
+	}
+	recipients := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		recipients[string(member.UserUUID)] = struct{}{}
+	}
+
+	sessions, err := c.ws.Sessions()
+	if err != nil {
+		return
+	}
+	pb := messageToProto(message)
+	push := &umbrapb.WSMessage{Body: &umbrapb.WSMessage_ChatMessage{ChatMessage: pb}}
+	legacy_message := models_responses.ChatMessageFromProto(pb)
+	legacy := wsLegacyMessage{Type: "chat_message", ChatMessage: &legacy_message}
+	for _, s := range sessions {
+		conn, ok := wsConnFrom(s)
+		if !ok {
+			continue
+		}
+		conn.mu.Lock()
+		_, recipient := recipients[string(conn.userUUID)]
+		conn.mu.Unlock()
+		if !recipient {
+			continue
+		}
+		if err := c.sendWS(s, push, legacy); err != nil {
+			logger.Debugf("websocket push failed remote=%s err=%v", s.RemoteAddr(), err)
+		}
+	}
+}
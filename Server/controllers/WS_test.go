@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/MHSarmadi/Umbra/Server/crypto"
+)
+
+// newTestWSConn returns the server side of a connection of the session with
+// shared_key, upgraded by an envelope with nonce.
+func newTestWSConn(session_id [24]byte, shared_key, nonce []byte) *wsConn {
+	return &wsConn{
+		sessionID: session_id,
+		connNonce: nonce,
+		recvKey:   crypto.KDF(shared_key, wsDirectionClientToServer, 32),
+		sendKey:   crypto.KDF(shared_key, wsDirectionServerToClient, 32),
+	}
+}
+
+// sealClientFrame builds a frame the way the client's SealWSFrame does.
+func sealClientFrame(session_id [24]byte, shared_key, nonce []byte, seq uint64, payload []byte) []byte {
+	key := crypto.KDF(shared_key, wsDirectionClientToServer, 32)
+	frame := make([]byte, 8)
+	binary.BigEndian.PutUint64(frame, seq)
+	cipher, salt, tag := crypto.MACE_Encrypt_MIXIN_AEAD(key, payload, wsFrameMixin(session_id[:], nonce, wsDirectionClientToServer, frame[:8]), "@WS-FRAME", 2, false)
+	frame = append(frame, salt...)
+	frame = append(frame, tag...)
+	return append(frame, cipher...)
+}
+
+func TestWSFrameReplayAcrossConnections(t *testing.T) {
+	var session_id [24]byte
+	copy(session_id[:], "ws-replay-test-session!!")
+	shared_key := bytes.Repeat([]byte{7}, 32)
+	first_nonce := bytes.Repeat([]byte{1}, 16)
+	second_nonce := bytes.Repeat([]byte{2}, 16)
+	payload := bytes.Repeat([]byte("x"), 64)
+
+	first := newTestWSConn(session_id, shared_key, first_nonce)
+	frame := sealClientFrame(session_id, shared_key, first_nonce, 1, payload)
+	raw, err := first.open(frame)
+	if err != nil {
+		t.Fatalf("frame #1 on its own connection: %v", err)
+	}
+	if !bytes.Equal(raw, payload) {
+		t.Fatalf("frame #1 opened to %q, want %q", raw, payload)
+	}
+
+	second := newTestWSConn(session_id, shared_key, second_nonce)
+	if _, err := second.open(frame); err == nil {
+		t.Fatal("client frame #1 replayed on a second connection was accepted")
+	}
+
+	// A server frame reflected back is bound to the other direction as well
+	// as the other connection.
+	reflected := first.seal(payload)
+	if _, err := newTestWSConn(session_id, shared_key, second_nonce).open(reflected); err == nil {
+		t.Fatal("server frame #1 reflected onto a second connection was accepted")
+	}
+	if _, err := newTestWSConn(session_id, shared_key, first_nonce).open(reflected); err == nil {
+		t.Fatal("server frame #1 reflected onto its own connection was accepted")
+	}
+}
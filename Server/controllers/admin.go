@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/MHSarmadi/Umbra/Server/database"
+)
+
+// AdminController serves the operator endpoints. They are mounted on a
+// separate listener that is meant to stay on loopback or a private network,
+// and carry no authentication of their own.
+type AdminController struct {
+	ctx     context.Context
+	storage database.Store
+}
+
+func NewAdminController(ctx context.Context, storage database.Store) *AdminController {
+	return &AdminController{
+		ctx:     ctx,
+		storage: storage,
+	}
+}
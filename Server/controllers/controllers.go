@@ -2,21 +2,106 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
 
+	"github.com/MHSarmadi/Umbra/Server/clientip"
 	"github.com/MHSarmadi/Umbra/Server/database"
 	"github.com/olahol/melody"
 )
 
+// Config holds the tunables of session establishment. Parameters the client
+// hardcodes, like the session token cipher key's Argon2 cost, stay constants.
+type Config struct {
+	SessionTTL time.Duration
+
+	// A client identity may start SessionInitMaxRequests sessions per
+	// SessionInitWindow; its tracker is kept for SessionInitTrackerTTL.
+	SessionInitWindow      time.Duration
+	SessionInitMaxRequests int
+	SessionInitTrackerTTL  time.Duration
+	// ProxyHeader, the one forwarding header the proxies write, is believed
+	// from TrustedProxies only. IPv6 clients are identified by their network
+	// of IdentityIPv6Prefix bits.
+	TrustedProxies     clientip.Prefixes
+	ProxyHeader        string
+	IdentityIPv6Prefix int
+
+	// The Argon2id cost of the proof of work. Iterations scale between the
+	// bounds with how busy the identity is.
+	PoWMemoryMB      uint
+	PoWParallelism   uint
+	PoWIterationsMin uint
+	PoWIterationsMax uint
+}
+
+func DefaultConfig() Config {
+	return Config{
+		SessionTTL:             15 * time.Minute,
+		SessionInitWindow:      10 * time.Minute,
+		SessionInitMaxRequests: 32,
+		SessionInitTrackerTTL:  30 * time.Minute,
+		ProxyHeader:            clientip.HeaderXForwardedFor,
+		IdentityIPv6Prefix:     64,
+
+		PoWMemoryMB:      12,
+		PoWParallelism:   1,
+		PoWIterationsMin: 2,
+		PoWIterationsMax: 7,
+	}
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.SessionTTL <= 0 {
+		errs = append(errs, errors.New("session ttl must be positive"))
+	}
+	if c.SessionInitWindow <= 0 || c.SessionInitMaxRequests < 1 {
+		errs = append(errs, errors.New("session init window and max requests must be positive"))
+	}
+	if c.SessionInitTrackerTTL < c.SessionInitWindow {
+		errs = append(errs, errors.New("session init tracker ttl must cover the whole window"))
+	}
+	switch c.ProxyHeader {
+	case clientip.HeaderForwarded, clientip.HeaderXForwardedFor, clientip.HeaderXRealIP:
+	default:
+		errs = append(errs, fmt.Errorf("unknown proxy header %q; use %s, %s or %s",
+			c.ProxyHeader, clientip.HeaderForwarded, clientip.HeaderXForwardedFor, clientip.HeaderXRealIP))
+	}
+	if c.IdentityIPv6Prefix < 1 || c.IdentityIPv6Prefix > 128 {
+		errs = append(errs, errors.New("identity ipv6 prefix must be between 1 and 128 bits"))
+	}
+	if c.PoWMemoryMB < 1 || c.PoWMemoryMB > math.MaxUint32/1024 {
+		errs = append(errs, fmt.Errorf("pow memory of %d MB is out of range", c.PoWMemoryMB))
+	}
+	if c.PoWParallelism < 1 || c.PoWParallelism > math.MaxUint8 {
+		errs = append(errs, fmt.Errorf("pow parallelism must be between 1 and %d", math.MaxUint8))
+	}
+	if c.PoWIterationsMin < 1 || c.PoWIterationsMax < c.PoWIterationsMin {
+		errs = append(errs, errors.New("pow iterations need 1 <= min <= max"))
+	}
+	return errors.Join(errs...)
+}
+
 type Controller struct {
 	ctx     context.Context
-	storage *database.BadgerStore
+	cfg     Config
+	storage database.Store
+	clients *clientip.Resolver
 	ws      *melody.Melody
 }
 
-func NewController(ctx context.Context, storage *database.BadgerStore) *Controller {
-	return &Controller{
+func NewController(ctx context.Context, cfg Config, storage database.Store) *Controller {
+	c := &Controller{
 		ctx:     ctx,
+		cfg:     cfg,
 		storage: storage,
+		clients: clientip.New(cfg.TrustedProxies, cfg.ProxyHeader, cfg.IdentityIPv6Prefix),
 		ws:      melody.New(),
 	}
+	c.registerWSHandlers()
+	go c.watchWSSessions()
+	return c
 }
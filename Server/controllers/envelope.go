@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/core"
+	"github.com/MHSarmadi/Umbra/Server/crypto"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_responses "github.com/MHSarmadi/Umbra/Server/models/responses"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+type envelopeContextKey struct{}
+
+type envelopeContext struct {
+	session   *models.Session
+	nonce     []byte
+	sharedKey []byte
+}
+
+// WithEnvelope attaches the session that authenticated a request envelope to
+// ctx, so handlers behind the envelope middleware can reply under its keys.
+func WithEnvelope(ctx context.Context, session *models.Session, nonce, sharedKey []byte) context.Context {
+	return context.WithValue(ctx, envelopeContextKey{}, &envelopeContext{
+		session:   session,
+		nonce:     nonce,
+		sharedKey: sharedKey,
+	})
+}
+
+// EnvelopeSession returns the session that authenticated r's envelope, for
+// middleware running behind the envelope middleware.
+func EnvelopeSession(r *http.Request) (*models.Session, bool) {
+	env, ok := envelopeFrom(r)
+	if !ok {
+		return nil, false
+	}
+	return env.session, true
+}
+
+func envelopeFrom(r *http.Request) (*envelopeContext, bool) {
+	env, ok := r.Context().Value(envelopeContextKey{}).(*envelopeContext)
+	return env, ok && env != nil
+}
+
+// sessionUser loads the user the envelope's session is logged in as and
+// writes the error response itself when there is none. Disabled users are
+// turned away, and so are users whose soul is still under an outdated KDF
+// until they upgrade it through /user/soul.
+func (c *Controller) sessionUser(w http.ResponseWriter, env *envelopeContext) (*models.User, bool) {
+	user, ok := c.sessionUserAnyKDF(w, env)
+	if !ok {
+		return nil, false
+	}
+	if core.SoulKDFNeedsUpgrade(user.SoulKDF) {
+		logger.Debugf("user request rejected: soul kdf upgrade required")
+		http.Error(w, "soul upgrade required", http.StatusForbidden)
+		return nil, false
+	}
+	return user, true
+}
+
+// sessionUserAnyKDF is sessionUser for /user/soul, the one route a user with
+// an outdated soul KDF must still reach.
+func (c *Controller) sessionUserAnyKDF(w http.ResponseWriter, env *envelopeContext) (*models.User, bool) {
+	if len(env.session.UserUUID) == 0 {
+		logger.Debugf("user request rejected: session not logged in")
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return nil, false
+	}
+	user, err := c.storage.GetUserByUUID(c.ctx, env.session.UserUUID)
+	if errors.Is(err, database.ErrNotFound) {
+		logger.Infof("user request rejected: session bound to a missing user")
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return nil, false
+	} else if err != nil {
+		logger.Errorf("user request failed loading session user: %v", err)
+		http.Error(w, "could not load user", http.StatusInternalServerError)
+		return nil, false
+	}
+	if user.Disabled() {
+		logger.Infof("user request rejected: account disabled")
+		http.Error(w, "account disabled", http.StatusForbidden)
+		return nil, false
+	}
+	return user, true
+}
+
+func SessionSharedKey(session *models.Session) ([]byte, error) {
+	shared_secret, err := crypto.ComputeSharedSecret(session.ServerSoul[:], session.ClientXPubKey[:])
+	if err != nil {
+		return nil, err
+	}
+	return crypto.KDF(shared_secret, "@SESSION-SHARED-KEY", 32), nil
+}
+
+// writeEnvelopeResponse seals the response under the session shared key, bound
+// to the request nonce, and signs it with the session's server soul. The
+// sealed payload uses the same encoding as the rest of the exchange.
+func writeEnvelopeResponse(w http.ResponseWriter, r *http.Request, status int, pb proto.Message, legacy any) {
+	env, ok := envelopeFrom(r)
+	if !ok {
+		logger.Errorf("envelope response requested outside of an envelope path=%s", r.URL.Path)
+		http.Error(w, "missing request envelope", http.StatusInternalServerError)
+		return
+	}
+
+	payload_encoded, err := wire.Marshal(wire.WantsProtobuf(r), pb, legacy)
+	if err != nil {
+		logger.Errorf("envelope response failed marshaling payload: %v", err)
+		http.Error(w, "could not marshal payload", http.StatusInternalServerError)
+		return
+	}
+	mixin := append(append([]byte(nil), env.session.UUID[:]...), env.nonce...)
+	payload_ciphered, payload_salt, payload_tag := crypto.MACE_Encrypt_MIXIN_AEAD(env.sharedKey, payload_encoded, mixin, "@RESPONSE-ENVELOPE", 4, false)
+	payload := append(payload_salt, payload_tag...)
+	payload = append(payload, payload_ciphered...) // payload_salt is always exactly 12 bytes and payload_tag is always exactly 16 bytes
+	signature := crypto.Sign(env.session.ServerSoul[:], payload)
+
+	response := &umbrapb.EnvelopeResponse{
+		Status:    "ok",
+		Payload:   payload,
+		Signature: signature,
+	}
+	if err := wire.Write(w, r, status, response, models_responses.EnvelopeResponseFromProto(response)); err != nil {
+		logger.Errorf("envelope response encode failed: %v", err)
+	}
+}
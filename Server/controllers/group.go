@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+)
+
+func wrappedGroupSecretFromProto(pb *umbrapb.WrappedGroupSecret) models_requests.WrappedGroupSecretDecoded {
+	return models_requests.WrappedGroupSecretDecoded{
+		XPublicKey: pb.GetXPubKey(),
+		Cipher:     pb.GetCipher(),
+		Salt:       pb.GetSalt(),
+		Tag:        pb.GetTag(),
+	}
+}
+
+func decodeWrappedGroupSecret(encoded models_requests.WrappedGroupSecretEncoded) (decoded models_requests.WrappedGroupSecretDecoded, field string, err error) {
+	if decoded.XPublicKey, err = db64(encoded.XPublicKey); err != nil {
+		return decoded, "secret.x_pub_key", err
+	} else if decoded.Cipher, err = db64(encoded.Cipher); err != nil {
+		return decoded, "secret.cipher", err
+	} else if decoded.Salt, err = db64(encoded.Salt); err != nil {
+		return decoded, "secret.salt", err
+	} else if decoded.Tag, err = db64(encoded.Tag); err != nil {
+		return decoded, "secret.tag", err
+	}
+	return decoded, "", nil
+}
+
+func groupMemberWithSecret(secret models_requests.WrappedGroupSecretDecoded) *models.GroupMember {
+	return &models.GroupMember{
+		WrapXPublicKey:    secret.XPublicKey,
+		WrappedSecret:     secret.Cipher,
+		WrappedSecretSalt: secret.Salt,
+		WrappedSecretTag:  secret.Tag,
+	}
+}
+
+func groupMembershipToProto(group *models.Group, member *models.GroupMember) *umbrapb.GroupMembership {
+	return &umbrapb.GroupMembership{
+		Group: &umbrapb.Group{
+			Uuid:                      group.UUID,
+			XPubKey:                   group.XPublicKey,
+			EPubKey:                   group.EPublicKey,
+			EncipheredEntranceKey:     group.EncipheredEntranceKey,
+			EncipheredEntranceKeySalt: group.EncipheredEntranceKeySalt,
+			EncipheredEntranceKeyTag:  group.EncipheredEntranceKeyTag,
+			CreatorUuid:               group.CreatorUUID,
+			CreatedAtUnixMillisec:     group.CreatedAt.UTC().UnixMilli(),
+		},
+		Role: string(member.Role),
+		Secret: &umbrapb.WrappedGroupSecret{
+			XPubKey: member.WrapXPublicKey,
+			Cipher:  member.WrappedSecret,
+			Salt:    member.WrappedSecretSalt,
+			Tag:     member.WrappedSecretTag,
+		},
+	}
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import "github.com/MHSarmadi/Umbra/Server/metrics"
+
+// Outcomes of SessionInit, the label of sessionInitTotal.
+const (
+	sessionInitOK               = "ok"
+	sessionInitRateLimited      = "rate_limited"
+	sessionInitMalformedBody    = "malformed_body"
+	sessionInitInvalidEncoding  = "invalid_encoding"
+	sessionInitInvalidKeyLength = "invalid_key_length"
+	sessionInitInvalidSignature = "invalid_signature"
+	sessionInitInternalError    = "internal_error"
+)
+
+var (
+	sessionInitTotal = metrics.NewCounterVec("umbra_session_init_total",
+		"Session init requests by outcome.", "outcome")
+	powIterationsChosen = metrics.NewHistogram("umbra_session_init_pow_iterations",
+		"Argon2id iterations of the proof of work handed to new sessions.", metrics.LinearBuckets(1, 1, 16))
+	argon2Duration = metrics.NewHistogramVec("umbra_argon2_duration_seconds",
+		"Time spent in Argon2id by purpose.", metrics.DurationBuckets, "purpose")
+	captchaDuration = metrics.NewHistogram("umbra_captcha_generate_duration_seconds",
+		"Time spent drawing captchas.", metrics.DurationBuckets)
+)
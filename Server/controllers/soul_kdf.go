@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+)
+
+func soulKDFToProto(kdf models.SoulKDF) *umbrapb.SoulKDF {
+	return &umbrapb.SoulKDF{
+		Version:     uint32(kdf.Version),
+		Salt:        kdf.Salt,
+		MemoryKib:   kdf.MemoryKiB,
+		Iterations:  kdf.Iterations,
+		Parallelism: uint32(kdf.Parallelism),
+	}
+}
+
+// soulKDFFromProto truncates oversized version and parallelism values; they
+// can only ever fail core.ValidateSoulKDF afterwards.
+func soulKDFFromProto(pb *umbrapb.SoulKDF) models.SoulKDF {
+	if pb == nil {
+		return models.SoulKDF{}
+	}
+	version, parallelism := pb.GetVersion(), pb.GetParallelism()
+	if version > 0xff {
+		version = 0xff
+	}
+	if parallelism > 0xff {
+		parallelism = 0
+	}
+	return models.SoulKDF{
+		Version:     uint8(version),
+		Salt:        pb.GetSalt(),
+		MemoryKiB:   pb.GetMemoryKib(),
+		Iterations:  pb.GetIterations(),
+		Parallelism: uint8(parallelism),
+	}
+}
+
+func decodeSoulKDF(encoded models_requests.SoulKDFEncoded) (models.SoulKDF, error) {
+	salt, err := db64(encoded.Salt)
+	if err != nil {
+		return models.SoulKDF{}, err
+	}
+	return models.SoulKDF{
+		Version:     encoded.Version,
+		Salt:        salt,
+		MemoryKiB:   encoded.MemoryKiB,
+		Iterations:  encoded.Iterations,
+		Parallelism: encoded.Parallelism,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+var (
+	ErrInvalidGroupKeys   = errors.New("invalid group public keys")
+	ErrInvalidGroupSecret = errors.New("invalid wrapped group secret")
+	ErrNotGroupMember     = errors.New("not a group member")
+	ErrNotGroupOwner      = errors.New("only the group owner can do this")
+	ErrOwnerCannotLeave   = errors.New("the group owner cannot leave the group")
+)
+
+const maxWrappedSecretBytes = 256
+
+func validateWrappedSecret(m *models.GroupMember) error {
+	if len(m.WrapXPublicKey) != 32 || len(m.WrappedSecret) == 0 || len(m.WrappedSecret) > maxWrappedSecretBytes || len(m.WrappedSecretSalt) != 12 || len(m.WrappedSecretTag) != 16 {
+		return ErrInvalidGroupSecret
+	}
+	return nil
+}
+
+// CreateGroup stores a group owned by creator_uuid. owner carries the group
+// secret the creator wrapped to its own X25519 key.
+func CreateGroup(ctx context.Context, s database.Store, creator_uuid []byte, group *models.Group, owner *models.GroupMember) error {
+	if len(group.XPublicKey) != 32 || len(group.EPublicKey) != 32 {
+		return ErrInvalidGroupKeys
+	}
+	if len(group.EncipheredEntranceKey) == 0 || len(group.EncipheredEntranceKey) > maxWrappedSecretBytes || len(group.EncipheredEntranceKeySalt) != 12 || len(group.EncipheredEntranceKeyTag) != 16 {
+		return ErrInvalidGroupSecret
+	}
+	if err := validateWrappedSecret(owner); err != nil {
+		return err
+	}
+
+	group.UUID = nil
+	group.CreatorUUID = creator_uuid
+	owner.UserUUID = creator_uuid
+	owner.Role = models.GroupRoleOwner
+	owner.AddedBy = creator_uuid
+	return s.PutGroup(ctx, group, owner)
+}
+
+// AddGroupMember lets the owner of a group add a user. The secret in member
+// must be wrapped to that user's X25519 key on the owner's client.
+func AddGroupMember(ctx context.Context, s database.Store, actor_uuid []byte, member *models.GroupMember) error {
+	if err := validateWrappedSecret(member); err != nil {
+		return err
+	}
+	if err := requireGroupOwner(ctx, s, member.GroupUUID, actor_uuid); err != nil {
+		return err
+	}
+	if _, err := s.GetUserByUUID(ctx, member.UserUUID); err != nil {
+		return err
+	}
+
+	member.Role = models.GroupRoleMember
+	member.AddedBy = actor_uuid
+	return s.PutGroupMember(ctx, member)
+}
+
+// RemoveGroupMember lets the owner remove anyone but themselves, and any
+// member leave on their own.
+func RemoveGroupMember(ctx context.Context, s database.Store, actor_uuid, group_uuid, user_uuid []byte) error {
+	target, err := s.GetGroupMember(ctx, group_uuid, user_uuid)
+	if errors.Is(err, database.ErrNotFound) {
+		return ErrNotGroupMember
+	} else if err != nil {
+		return err
+	}
+	if target.Role == models.GroupRoleOwner {
+		return ErrOwnerCannotLeave
+	}
+	if !bytes.Equal(actor_uuid, user_uuid) {
+		if err := requireGroupOwner(ctx, s, group_uuid, actor_uuid); err != nil {
+			return err
+		}
+	}
+	return s.DeleteGroupMember(ctx, group_uuid, user_uuid)
+}
+
+func requireGroupOwner(ctx context.Context, s database.Store, group_uuid, user_uuid []byte) error {
+	actor, err := s.GetGroupMember(ctx, group_uuid, user_uuid)
+	if errors.Is(err, database.ErrNotFound) {
+		return ErrNotGroupMember
+	} else if err != nil {
+		return err
+	}
+	if actor.Role != models.GroupRoleOwner {
+		return ErrNotGroupOwner
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	umbra_crypto "github.com/MHSarmadi/Umbra/Server/crypto"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+var (
+	ErrInvalidMessage          = errors.New("invalid message")
+	ErrInvalidMessageSignature = errors.New("invalid message signature")
+	ErrStaleMessage            = errors.New("message sent_at too far from server time")
+)
+
+const (
+	MaxMessagePayloadBytes = 32 << 10
+	MessageNonceSize       = 16
+	// MessageClockSkew is how far a message's SentAt may be from the server
+	// clock. Nonces are remembered just as long, which is what makes a
+	// replay of an older message fail on SentAt instead.
+	MessageClockSkew = 5 * time.Minute
+)
+
+// MessageSignedData is what a sender signs with its user soul. It covers
+// everything the sender chooses; the UUID and time are assigned by the server.
+func MessageSignedData(group_uuid, x_pub_key, nonce []byte, sent_at_unix_millisec int64, payload []byte) []byte {
+	data := make([]byte, 0, len("@GROUP-MESSAGE-")+len(group_uuid)+len(x_pub_key)+len(nonce)+8+len(payload))
+	data = append(data, "@GROUP-MESSAGE-"...)
+	data = append(data, group_uuid...)
+	data = append(data, x_pub_key...)
+	data = append(data, nonce...)
+	data = binary.BigEndian.AppendUint64(data, uint64(sent_at_unix_millisec))
+	return append(data, payload...)
+}
+
+// SendMessage stores a message from sender after checking it belongs to the
+// group, signed the message with its user soul and has not sent it before.
+func SendMessage(ctx context.Context, s database.Store, sender *models.User, m *models.Message) error {
+	if len(m.GroupUUID) != 32 || len(m.XPublicKey) != 32 || len(m.Payload) == 0 || len(m.Payload) > MaxMessagePayloadBytes || len(m.Nonce) != MessageNonceSize {
+		return ErrInvalidMessage
+	}
+	now := time.Now().UTC()
+	if skew := now.Sub(m.SentAt); skew > MessageClockSkew || skew < -MessageClockSkew {
+		return ErrStaleMessage
+	}
+	if _, err := s.GetGroupMember(ctx, m.GroupUUID, sender.UUID); errors.Is(err, database.ErrNotFound) {
+		return ErrNotGroupMember
+	} else if err != nil {
+		return err
+	}
+	if !umbra_crypto.Verify(sender.EPublicKey, MessageSignedData(m.GroupUUID, m.XPublicKey, m.Nonce, m.SentAt.UnixMilli(), m.Payload), m.SenderSignature) {
+		return ErrInvalidMessageSignature
+	}
+
+	m.SenderUUID = sender.UUID
+	return s.PutMessage(ctx, m, m.SentAt.Add(MessageClockSkew))
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"crypto/rand"
+	"errors"
+
+	umbra_crypto "github.com/MHSarmadi/Umbra/Server/crypto"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// SoulKDFVersionLegacy is a single BLAKE3 hash of the password. It is only
+	// kept so existing users can still log in and upgrade.
+	SoulKDFVersionLegacy uint8 = 0
+	// CurrentSoulKDFVersion is what new souls are enciphered with. Users on an
+	// older version are asked to re-encipher their soul on their next login.
+	CurrentSoulKDFVersion uint8 = 1
+
+	soulKDFSaltSize = 16
+	soulKeySize     = 64
+)
+
+var ErrInvalidSoulKDF = errors.New("invalid soul kdf parameters")
+
+// soulKDFVersions holds the Argon2id cost of every version after the legacy
+// one. A version's parameters must never change once it is released; raise
+// the cost by adding a new version instead.
+var soulKDFVersions = map[uint8]models.SoulKDF{
+	1: {Version: 1, MemoryKiB: 64 * 1024, Iterations: 3, Parallelism: 1},
+}
+
+// NewSoulKDF returns the current parameters with a fresh per-user salt.
+func NewSoulKDF() (models.SoulKDF, error) {
+	kdf := soulKDFVersions[CurrentSoulKDFVersion]
+	kdf.Salt = make([]byte, soulKDFSaltSize)
+	if _, err := rand.Read(kdf.Salt); err != nil {
+		return models.SoulKDF{}, err
+	}
+	return kdf, nil
+}
+
+// ValidateSoulKDF accepts only the current version with its exact cost, so a
+// client can never enroll a soul under weaker parameters than the server asks
+// for.
+func ValidateSoulKDF(kdf models.SoulKDF) error {
+	expected, ok := soulKDFVersions[kdf.Version]
+	if !ok || kdf.Version != CurrentSoulKDFVersion {
+		return ErrInvalidSoulKDF
+	}
+	if len(kdf.Salt) != soulKDFSaltSize || kdf.MemoryKiB != expected.MemoryKiB || kdf.Iterations != expected.Iterations || kdf.Parallelism != expected.Parallelism {
+		return ErrInvalidSoulKDF
+	}
+	return nil
+}
+
+func SoulKDFNeedsUpgrade(kdf models.SoulKDF) bool {
+	return kdf.Version < CurrentSoulKDFVersion
+}
+
+// DeriveSoulKey derives the 64-byte soul key from a password. The first 32
+// bytes encipher the soul; the whole key is what the recovery blob wraps.
+func DeriveSoulKey(password []byte, kdf models.SoulKDF) ([]byte, error) {
+	if kdf.Version == SoulKDFVersionLegacy {
+		soul_key := umbra_crypto.Sum(password)
+		return soul_key[:], nil
+	}
+	expected, ok := soulKDFVersions[kdf.Version]
+	if !ok || len(kdf.Salt) != soulKDFSaltSize {
+		return nil, ErrInvalidSoulKDF
+	}
+	if kdf.MemoryKiB != expected.MemoryKiB || kdf.Iterations != expected.Iterations || kdf.Parallelism != expected.Parallelism {
+		return nil, ErrInvalidSoulKDF
+	}
+	return argon2.IDKey(password, kdf.Salt, kdf.Iterations, kdf.MemoryKiB, kdf.Parallelism, soulKeySize), nil
+}
@@ -1,47 +1,117 @@
 package core
 
 import (
+	"bytes"
 	"context"
-	"crypto/rand"
+	"errors"
+	"regexp"
 
 	umbra_crypto "github.com/MHSarmadi/Umbra/Server/crypto"
 	"github.com/MHSarmadi/Umbra/Server/database"
 	"github.com/MHSarmadi/Umbra/Server/models"
 )
 
-func NewUser(ctx context.Context, s *database.BadgerStore, username, password string, recovery_key []byte) (uuid []byte, err error) {
-	soul := make([]byte, 32)
-	rand.Read(soul)
+var (
+	ErrInvalidUsername  = errors.New("invalid username")
+	ErrInvalidUserKeys  = errors.New("invalid user public keys")
+	ErrInvalidSoulBlob  = errors.New("invalid enciphered soul material")
+	ErrInvalidUserProof = errors.New("invalid user soul proof")
+	ErrStaleRecovery    = errors.New("recovery blob was not renewed")
+	ErrUserDisabled     = errors.New("account disabled")
+)
 
-	e_pub_key := umbra_crypto.DeriveEd25519PubKey(soul)
-	x_pub_key, err := umbra_crypto.DeriveX25519PubKey(soul)
-	if err != nil {
-		return nil, err
-	}
+const maxSoulBlobBytes = 256
 
-	soul_key := umbra_crypto.Sum([]byte(password))
-	soul_cipher, soul_salt, soul_tag := umbra_crypto.MACE_Encrypt_AEAD(soul_key[:32], soul, "@SOUL-ENCRYPTION", 4, false)
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{2,31}$`)
 
-	true_recovery_key := umbra_crypto.Sum(recovery_key)
-	recovery_cipher, recovery_salt, recovery_tag := umbra_crypto.MACE_Encrypt_AEAD(true_recovery_key[:32], soul_key[:], "@SOUL-ENCRYPTION-@RECOVERY-KEY", 16, false)
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	return nil
+}
 
-	uuid = make([]byte, 32)
-	rand.Read(uuid)
+// UserProofMessage is what a client signs with a user soul to prove it holds
+// that soul within the given session. Binding the session id keeps a proof
+// from being replayed on another session.
+func UserProofMessage(session_id []byte) []byte {
+	return append([]byte("@USER-SOUL-PROOF-"), session_id...)
+}
 
-	user := models.User{
-		UUID:               uuid,
-		Username:           username,
-		XPublicKey:         x_pub_key,
-		EPublicKey:         e_pub_key,
-		EncipheredSoul:     soul_cipher,
-		EncipheredSoulSalt: soul_salt,
-		EncipheredSoulTag:  soul_tag,
-		SoulRecovery:       recovery_cipher,
-		SoulRecoverySalt:   recovery_salt,
-		SoulRecoveryTag:    recovery_tag,
+// RegisterUser stores a user whose soul was generated and enciphered on the
+// client. The server only checks the shape of the material and that the
+// caller proved possession of the soul behind the public keys; it never sees
+// the soul itself.
+func RegisterUser(ctx context.Context, s database.Store, session_id []byte, user *models.User, proof []byte) error {
+	if err := ValidateUsername(user.Username); err != nil {
+		return err
+	}
+	if len(user.XPublicKey) != 32 || len(user.EPublicKey) != 32 {
+		return ErrInvalidUserKeys
+	}
+	if len(user.EncipheredSoul) == 0 || len(user.EncipheredSoul) > maxSoulBlobBytes || len(user.EncipheredSoulSalt) != 12 || len(user.EncipheredSoulTag) != 16 {
+		return ErrInvalidSoulBlob
+	}
+	if len(user.SoulRecovery) == 0 || len(user.SoulRecovery) > maxSoulBlobBytes || len(user.SoulRecoverySalt) != 12 || len(user.SoulRecoveryTag) != 16 {
+		return ErrInvalidSoulBlob
+	}
+	if err := ValidateSoulKDF(user.SoulKDF); err != nil {
+		return err
+	}
+	if !umbra_crypto.Verify(user.EPublicKey, UserProofMessage(session_id), proof) {
+		return ErrInvalidUserProof
 	}
 
-	s.PutUser(ctx, &user)
+	user.UUID = nil
+	return s.PutUser(ctx, user)
+}
 
-	return
+// UpgradeSoul replaces the enciphered soul and recovery blob of a user with
+// ones derived under the current soul KDF. The public keys must stay the same:
+// the soul itself does not change, only the key that enciphers it.
+func UpgradeSoul(ctx context.Context, s database.Store, user_uuid []byte, upgraded *models.User) (*models.User, error) {
+	if len(upgraded.EncipheredSoul) == 0 || len(upgraded.EncipheredSoul) > maxSoulBlobBytes || len(upgraded.EncipheredSoulSalt) != 12 || len(upgraded.EncipheredSoulTag) != 16 {
+		return nil, ErrInvalidSoulBlob
+	}
+	if len(upgraded.SoulRecovery) == 0 || len(upgraded.SoulRecovery) > maxSoulBlobBytes || len(upgraded.SoulRecoverySalt) != 12 || len(upgraded.SoulRecoveryTag) != 16 {
+		return nil, ErrInvalidSoulBlob
+	}
+	if err := ValidateSoulKDF(upgraded.SoulKDF); err != nil {
+		return nil, err
+	}
+	return s.UpdateUser(ctx, user_uuid, func(u *models.User) error {
+		u.EncipheredSoul = upgraded.EncipheredSoul
+		u.EncipheredSoulSalt = upgraded.EncipheredSoulSalt
+		u.EncipheredSoulTag = upgraded.EncipheredSoulTag
+		u.SoulRecovery = upgraded.SoulRecovery
+		u.SoulRecoverySalt = upgraded.SoulRecoverySalt
+		u.SoulRecoveryTag = upgraded.SoulRecoveryTag
+		u.SoulKDF = upgraded.SoulKDF
+		return nil
+	})
+}
+
+// RecoverUser re-enrolls a user who lost their password. The client opened
+// the recovery blob with its recovery key, deciphered the soul and proves it
+// by signing the session proof message; it then enciphers the soul under a
+// new password and wraps the new soul key under a new recovery key. The old
+// recovery blob is refused so a recovery key is never good for two resets.
+func RecoverUser(ctx context.Context, s database.Store, session_id []byte, username string, recovered *models.User, proof []byte) (*models.User, error) {
+	if err := ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	user, err := s.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() {
+		return nil, ErrUserDisabled
+	}
+	if !umbra_crypto.Verify(user.EPublicKey, UserProofMessage(session_id), proof) {
+		return nil, ErrInvalidUserProof
+	}
+	if bytes.Equal(user.SoulRecovery, recovered.SoulRecovery) || bytes.Equal(user.SoulRecoverySalt, recovered.SoulRecoverySalt) {
+		return nil, ErrStaleRecovery
+	}
+	return UpgradeSoul(ctx, s, user.UUID, recovered)
 }
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Backups and snapshots share Badger's backup format, so either restores with
+// RestoreBadgerStore. A backup is a faithful copy of every version since a
+// point, deletions included; a snapshot is the live state at one instant,
+// minus whatever had expired.
+
+var ErrRestoreNotEmpty = errors.New("restore needs an empty data directory")
+
+// restoreMaxPendingWrites bounds how many batched writes Badger keeps in
+// flight while loading a backup.
+const restoreMaxPendingWrites = 256
+
+// Backupper is implemented by stores that can be backed up while serving.
+type Backupper interface {
+	// Backup streams every entry written after version since to w, all of
+	// them for since 0, and returns the since to pass for the next
+	// incremental backup.
+	Backup(w io.Writer, since uint64) (next uint64, err error)
+	// ExportSnapshot streams a consistent snapshot to w, leaving out sessions
+	// and trackers that had expired by now.
+	ExportSnapshot(ctx context.Context, w io.Writer, now time.Time) (SnapshotStats, error)
+}
+
+var _ Backupper = (*BadgerStore)(nil)
+
+// SnapshotStats counts what ExportSnapshot wrote and left out.
+type SnapshotStats struct {
+	Exported int64
+	Expired  int64
+}
+
+func (s *BadgerStore) Backup(w io.Writer, since uint64) (next uint64, err error) {
+	// Badger's stream only reads versions above since, so the last version
+	// dumped is exactly where the next backup picks up.
+	last, err := s.db.Backup(w, since)
+	if err != nil {
+		return 0, err
+	}
+	if last < since {
+		// Nothing was written since then.
+		return since, nil
+	}
+	return last, nil
+}
+
+func (s *BadgerStore) ExportSnapshot(ctx context.Context, w io.Writer, now time.Time) (stats SnapshotStats, err error) {
+	var exported, expired atomic.Int64
+	expiring := make([][]byte, len(expiringNamespaces))
+	for i, ns := range expiringNamespaces {
+		expiring[i] = ns.Key()
+	}
+	expiries := keyspace.Expiries.Key()
+
+	// A stream reads at a single timestamp, so the snapshot is consistent even
+	// while the server keeps writing.
+	stream := s.db.NewStream()
+	stream.LogPrefix = "ExportSnapshot"
+	stream.ChooseKey = func(item *badger.Item) bool {
+		// Stream takes no context; once ctx is done every key is skipped so
+		// it winds down quickly.
+		if ctx.Err() != nil || item.IsDeletedOrExpired() {
+			return false
+		}
+		key := item.Key()
+		if bytes.HasPrefix(key, expiries) {
+			// Index entries of records that are left out are due by now.
+			if expires_at, _, ok := keyspace.SplitExpiry(key); ok && !expires_at.After(now) {
+				return false
+			}
+			return true
+		}
+		for _, prefix := range expiring {
+			if !bytes.HasPrefix(key, prefix) {
+				continue
+			}
+			var expires_at time.Time
+			if err := item.Value(func(val []byte) (err error) {
+				expires_at, err = codec.ExpiresAt(val)
+				return err
+			}); err == nil && !expires_at.IsZero() && now.After(expires_at) {
+				expired.Add(1)
+				return false
+			}
+			break
+		}
+		exported.Add(1)
+		return true
+	}
+
+	if _, err := stream.Backup(w, 0); err != nil {
+		return stats, err
+	}
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	return SnapshotStats{Exported: exported.Load(), Expired: expired.Load()}, nil
+}
+
+// RestoreBadgerStore loads backups, oldest first, into the empty data
+// directory at path: typically a full backup followed by its incremental ones,
+// or a single snapshot. NewBadgerStore afterwards migrates the data as usual.
+func RestoreBadgerStore(path string, encryption_key []byte, backups ...io.Reader) error {
+	db, err := badger.Open(badgerOptions(path, encryption_key))
+	if err != nil {
+		return openError(err)
+	}
+	defer db.Close()
+
+	empty := true
+	err = db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{})
+		defer it.Close()
+		it.Rewind()
+		empty = !it.Valid()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return ErrRestoreNotEmpty
+	}
+
+	for i, r := range backups {
+		if err := db.Load(r, restoreMaxPendingWrites); err != nil {
+			return err
+		}
+		logger.Infof("restore loaded backup %d of %d", i+1, len(backups))
+	}
+	return nil
+}
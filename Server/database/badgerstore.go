@@ -1,23 +1,81 @@
 package database
 
 import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/MHSarmadi/Umbra/Server/database/migrations"
+	"github.com/MHSarmadi/Umbra/Server/logger"
 	badger "github.com/dgraph-io/badger/v4"
 )
 
+var ErrSchemaTooNew = migrations.ErrTooNew
+
 type BadgerStore struct {
-	db *badger.DB
+	db             *badger.DB
+	rateLimitLocks keyLocks
+	messageLocks   keyLocks
 }
 
-func NewBadgerStore(path string) (*BadgerStore, error) {
-	opts := badger.DefaultOptions(path)
-	opts.Logger = nil
-	db, err := badger.Open(opts)
+// keyLocks serializes writes to one key within the process, for keys hot
+// enough that Badger's conflict detection would fail most of a burst. Keys
+// share a lock when their hashes collide, which costs some waiting but
+// nothing else.
+type keyLocks [64]sync.Mutex
+
+func (l *keyLocks) of(key []byte) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write(key)
+	return &l[h.Sum32()%uint32(len(l))]
+}
+
+// NewBadgerStore opens the data directory at path and brings it up to date
+// with the pending migrations. A non-empty encryption_key, see
+// LoadEncryptionKey, encrypts everything written to it.
+func NewBadgerStore(path string, encryption_key []byte) (*BadgerStore, error) {
+	db, err := badger.Open(badgerOptions(path, encryption_key))
+	if err != nil {
+		return nil, openError(err)
+	}
+	reports, err := migrations.Run(db, migrations.Options{})
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
+	for _, report := range reports {
+		logger.Infof("migration %d (%s) applied writes=%d deletes=%d", report.Version, report.Name, report.Writes, report.Deletes)
+	}
 	return &BadgerStore{db: db}, nil
 }
 
+// PlanMigrations reports what NewBadgerStore would migrate in the data
+// directory at path, without changing it. Like OpenBadgerStoreReadOnly it
+// fails while a server has the directory open.
+func PlanMigrations(path string, encryption_key []byte) (from int, reports []migrations.Report, err error) {
+	db, err := badger.Open(badgerOptions(path, encryption_key).WithReadOnly(true))
+	if err != nil {
+		return 0, nil, openError(err)
+	}
+	defer db.Close()
+	meta, err := migrations.ReadMeta(db)
+	if err != nil {
+		return 0, nil, err
+	}
+	reports, err = migrations.Run(db, migrations.Options{DryRun: true})
+	return meta.Version, reports, err
+}
+
+func badgerOptions(path string, encryption_key []byte) badger.Options {
+	opts := badger.DefaultOptions(path)
+	opts.Logger = nil
+	if len(encryption_key) > 0 {
+		// Encrypted tables cannot keep their indices in the table files, so
+		// Badger needs a cache for them.
+		opts = opts.WithEncryptionKey(encryption_key).WithIndexCacheSize(100 << 20)
+	}
+	return opts
+}
+
 func (s *BadgerStore) Close() error {
 	return s.db.Close()
 }
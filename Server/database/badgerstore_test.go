@@ -0,0 +1,70 @@
+package database
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/MHSarmadi/Umbra/Server/database/migrations"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// dirState is the name, size and modification time of every file in dir.
+func dirState(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := make(map[string]string)
+	for _, entry := range entries {
+		info, err := os.Stat(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		state[entry.Name()] = info.ModTime().String() + " " + strconv.FormatInt(info.Size(), 10)
+	}
+	return state
+}
+
+func TestPlanMigrationsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewBadgerStore(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Roll the recorded version back one step, so there is something to plan.
+	meta, err := migrations.ReadMeta(s.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.Version--
+	meta.Applied = meta.Applied[:meta.Version]
+	encoded, err := json.Marshal(&meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(migrations.MetaKey, encoded)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	before := dirState(t, dir)
+	from, reports, err := PlanMigrations(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != migrations.Latest()-1 || len(reports) != 1 || reports[0].Version != migrations.Latest() {
+		t.Fatalf("planned from %d: %+v", from, reports)
+	}
+	if after := dirState(t, dir); !reflect.DeepEqual(before, after) {
+		t.Fatalf("dry run changed the directory:\nbefore %v\nafter  %v", before, after)
+	}
+}
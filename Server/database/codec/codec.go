@@ -0,0 +1,156 @@
+// Package codec is the on-disk encoding of every record the stores keep.
+//
+//	value = Version || kind || fields...
+//
+// Fields are written in a fixed order per kind: fixed-size arrays raw,
+// variable-size bytes and strings behind a uvarint length, integers as
+// varints and times as a presence byte followed by unix seconds and
+// nanoseconds. Records that expire put ExpiresAt first, so the janitor can
+// read it without decoding the rest.
+//
+// Values written before the codec existed are JSON objects. They always start
+// with '{', which is never a valid Version, and are still decoded as JSON.
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+// Version is the codec version written by Marshal. Version 2 appended
+// DisabledAt to users, version 3 Identity and FailedUnixTS to recovery
+// trackers, version 4 Nonce and SentAt to messages and version 5 Seq to
+// messages; every other kind is laid out as in version 1.
+const Version byte = 5
+
+// Kind tells which record type a value holds.
+type Kind byte
+
+const (
+	KindSession Kind = iota + 1
+	KindUser
+	KindSessionInitTracker
+	KindRecoveryTracker
+	KindGroup
+	KindGroupMember
+	KindMessage
+	KindRateLimit
+	KindMessageNonce
+)
+
+var (
+	ErrCorrupt            = errors.New("codec: corrupt value")
+	ErrUnsupportedVersion = errors.New("codec: unsupported version")
+	ErrKindMismatch       = errors.New("codec: record kind mismatch")
+	ErrUnsupportedType    = errors.New("codec: unsupported type")
+)
+
+// IsLegacy reports whether val is a JSON value from before the codec.
+func IsLegacy(val []byte) bool {
+	return len(val) > 0 && val[0] == '{'
+}
+
+// Marshal encodes one of the record types in models.
+func Marshal(v any) ([]byte, error) {
+	var w writer
+	switch r := v.(type) {
+	case *models.Session:
+		w.header(KindSession)
+		encodeSession(&w, r)
+	case *models.User:
+		w.header(KindUser)
+		encodeUser(&w, r)
+	case *models.SessionInitTracker:
+		w.header(KindSessionInitTracker)
+		encodeSessionInitTracker(&w, r)
+	case *models.RecoveryTracker:
+		w.header(KindRecoveryTracker)
+		encodeRecoveryTracker(&w, r)
+	case *models.Group:
+		w.header(KindGroup)
+		encodeGroup(&w, r)
+	case *models.GroupMember:
+		w.header(KindGroupMember)
+		encodeGroupMember(&w, r)
+	case *models.Message:
+		w.header(KindMessage)
+		encodeMessage(&w, r)
+	case *models.RateLimitState:
+		w.header(KindRateLimit)
+		encodeRateLimitState(&w, r)
+	case *models.MessageNonce:
+		w.header(KindMessageNonce)
+		encodeMessageNonce(&w, r)
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	return w.buf, nil
+}
+
+// Unmarshal decodes val into v, which must point to the record type val was
+// encoded from. Legacy JSON values are accepted as well.
+func Unmarshal(val []byte, v any) error {
+	if IsLegacy(val) {
+		return json.Unmarshal(val, v)
+	}
+	r := reader{buf: val}
+	switch d := v.(type) {
+	case *models.Session:
+		r.header(KindSession)
+		decodeSession(&r, d)
+	case *models.User:
+		r.header(KindUser)
+		decodeUser(&r, d)
+	case *models.SessionInitTracker:
+		r.header(KindSessionInitTracker)
+		decodeSessionInitTracker(&r, d)
+	case *models.RecoveryTracker:
+		r.header(KindRecoveryTracker)
+		decodeRecoveryTracker(&r, d)
+	case *models.Group:
+		r.header(KindGroup)
+		decodeGroup(&r, d)
+	case *models.GroupMember:
+		r.header(KindGroupMember)
+		decodeGroupMember(&r, d)
+	case *models.Message:
+		r.header(KindMessage)
+		decodeMessage(&r, d)
+	case *models.RateLimitState:
+		r.header(KindRateLimit)
+		decodeRateLimitState(&r, d)
+	case *models.MessageNonce:
+		r.header(KindMessageNonce)
+		decodeMessageNonce(&r, d)
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	return r.finish()
+}
+
+// ExpiresAt reads the expiry of a session, tracker, rate-limit or nonce value without
+// decoding the whole record.
+func ExpiresAt(val []byte) (time.Time, error) {
+	if IsLegacy(val) {
+		var legacy struct {
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		err := json.Unmarshal(val, &legacy)
+		return legacy.ExpiresAt, err
+	}
+	r := reader{buf: val}
+	switch kind := r.anyHeader(); kind {
+	case KindSession, KindSessionInitTracker, KindRecoveryTracker, KindRateLimit, KindMessageNonce:
+		expires_at := r.time()
+		return expires_at, r.err
+	default:
+		if r.err != nil {
+			return time.Time{}, r.err
+		}
+		return time.Time{}, fmt.Errorf("%w: kind %d does not expire", ErrKindMismatch, kind)
+	}
+}
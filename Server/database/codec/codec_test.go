@@ -0,0 +1,295 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+var (
+	testCreatedAt = time.Date(2026, 3, 14, 15, 9, 26, 535897932, time.UTC)
+	testExpiresAt = time.Date(2026, 3, 14, 16, 0, 0, 1, time.UTC)
+)
+
+func b(s string) []byte {
+	return []byte(s)
+}
+
+func testSession() *models.Session {
+	s := &models.Session{
+		State:              models.SessionStateActive,
+		ActivatedAt:        testCreatedAt.Add(time.Second),
+		CreatedAt:          testCreatedAt,
+		ExpiresAt:          testExpiresAt,
+		LastNonces:         map[string]int64{"b": 2, "a": -1},
+		LastActivity:       testCreatedAt.Unix(),
+		PoWChallenge:       [1]byte{7},
+		PoWParams:          models.PowParamsType{MemoryMB: 12, Iterations: 300, Parallelism: 1},
+		PoWSolution:        b("solution"),
+		PoWAttempted:       true,
+		ActivationNonce:    b("activation"),
+		ActivationAttempts: 2,
+		UserUUID:           b("user"),
+	}
+	copy(s.UUID[:], "session-uuid-24-bytes...")
+	copy(s.ClientEdPubKey[:], "client-ed-pubkey-of-32-bytes....")
+	copy(s.ClientXPubKey[:], "client-x-pubkey-of-32-bytes.....")
+	copy(s.ServerSoul[:], "server-soul-of-32-bytes.........")
+	copy(s.SessionToken[:], "session-token-24-bytes..")
+	copy(s.SessionTokenCipherKeySalt[:], "salt12bytes.")
+	copy(s.PoWSalt[:], "powsalt12by.")
+	return s
+}
+
+func testUser() *models.User {
+	return &models.User{
+		UUID:               b("user-uuid"),
+		Username:           "alice",
+		XPublicKey:         b("x"),
+		EPublicKey:         b("e"),
+		EncipheredSoul:     b("soul"),
+		EncipheredSoulSalt: b("soul-salt"),
+		EncipheredSoulTag:  b("soul-tag"),
+		SoulRecovery:       b("recovery"),
+		SoulRecoverySalt:   b("recovery-salt"),
+		SoulRecoveryTag:    b("recovery-tag"),
+		SoulKDF:            models.SoulKDF{Version: 2, Salt: b("kdf-salt"), MemoryKiB: 65536, Iterations: 3, Parallelism: 4},
+		CreatedAt:          testCreatedAt,
+		DisabledAt:         testExpiresAt,
+	}
+}
+
+// records holds one fully populated record of every kind, with whether the
+// kind expires.
+func records() []struct {
+	kind     Kind
+	record   any
+	expiring bool
+} {
+	return []struct {
+		kind     Kind
+		record   any
+		expiring bool
+	}{
+		{KindSession, testSession(), true},
+		{KindUser, testUser(), false},
+		{KindSessionInitTracker, &models.SessionInitTracker{IdentityHash: "identity", RequestUnixTS: []int64{1, 2, 3}, ExpiresAt: testExpiresAt}, true},
+		{KindRecoveryTracker, &models.RecoveryTracker{Username: "alice", Identity: "identity", RequestUnixTS: []int64{4}, FailedUnixTS: []int64{5, 6}, CooldownUntil: testCreatedAt, ExpiresAt: testExpiresAt}, true},
+		{KindGroup, &models.Group{UUID: b("group"), XPublicKey: b("x"), EPublicKey: b("e"), EncipheredEntranceKey: b("key"), EncipheredEntranceKeySalt: b("salt"), EncipheredEntranceKeyTag: b("tag"), CreatorUUID: b("creator"), CreatedAt: testCreatedAt}, false},
+		{KindGroupMember, &models.GroupMember{GroupUUID: b("group"), UserUUID: b("user"), Role: models.GroupRoleOwner, WrapXPublicKey: b("wrap"), WrappedSecret: b("secret"), WrappedSecretSalt: b("salt"), WrappedSecretTag: b("tag"), AddedBy: b("adder"), AddedAt: testCreatedAt}, false},
+		{KindMessage, &models.Message{UUID: b("message"), GroupUUID: b("group"), XPublicKey: b("x"), Payload: b("payload"), SenderUUID: b("sender"), SenderSignature: b("signature"), Nonce: b("nonce"), SentAt: testCreatedAt, CreatedAt: testCreatedAt.Add(time.Millisecond), Seq: 300}, false},
+		{KindRateLimit, &models.RateLimitState{Bucket: "ip:198.51.100.7", State: b("state"), ExpiresAt: testExpiresAt}, true},
+		{KindMessageNonce, &models.MessageNonce{GroupUUID: b("group"), SenderUUID: b("sender"), Nonce: b("nonce"), ExpiresAt: testExpiresAt}, true},
+	}
+}
+
+// empty returns a new zero record of the same type as record.
+func empty(record any) any {
+	return reflect.New(reflect.TypeOf(record).Elem()).Interface()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tc := range records() {
+		val, err := Marshal(tc.record)
+		if err != nil {
+			t.Fatalf("%T: %v", tc.record, err)
+		}
+		if val[0] != Version || Kind(val[1]) != tc.kind {
+			t.Fatalf("%T: header %d %d, want %d %d", tc.record, val[0], val[1], Version, tc.kind)
+		}
+		decoded := empty(tc.record)
+		if err := Unmarshal(val, decoded); err != nil {
+			t.Fatalf("%T: %v", tc.record, err)
+		}
+		if !reflect.DeepEqual(decoded, tc.record) {
+			t.Fatalf("%T round trip:\n got %+v\nwant %+v", tc.record, decoded, tc.record)
+		}
+	}
+}
+
+func TestLegacyJSON(t *testing.T) {
+	for _, tc := range records() {
+		val, err := json.Marshal(tc.record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsLegacy(val) {
+			t.Fatalf("%T: JSON value not seen as legacy", tc.record)
+		}
+		decoded := empty(tc.record)
+		if err := Unmarshal(val, decoded); err != nil {
+			t.Fatalf("%T: %v", tc.record, err)
+		}
+		if !reflect.DeepEqual(decoded, tc.record) {
+			t.Fatalf("%T legacy decode:\n got %+v\nwant %+v", tc.record, decoded, tc.record)
+		}
+	}
+
+	var user models.User
+	if err := Unmarshal([]byte(`{"uuid":"dXNlcg==","username":"bob","created_at":"2026-03-14T15:09:26Z"}`), &user); err != nil {
+		t.Fatal(err)
+	}
+	if string(user.UUID) != "user" || user.Username != "bob" || !user.CreatedAt.Equal(testCreatedAt.Truncate(time.Second)) || user.Disabled() {
+		t.Fatalf("legacy user decoded to %+v", user)
+	}
+}
+
+// TestOlderVersions decodes values laid out by earlier versions, which lack
+// the fields appended since.
+func TestOlderVersions(t *testing.T) {
+	want := testUser()
+	want.DisabledAt = time.Time{}
+	w := writer{buf: []byte{1, byte(KindUser)}}
+	w.bytes(want.UUID)
+	w.string(want.Username)
+	w.bytes(want.XPublicKey)
+	w.bytes(want.EPublicKey)
+	w.bytes(want.EncipheredSoul)
+	w.bytes(want.EncipheredSoulSalt)
+	w.bytes(want.EncipheredSoulTag)
+	w.bytes(want.SoulRecovery)
+	w.bytes(want.SoulRecoverySalt)
+	w.bytes(want.SoulRecoveryTag)
+	w.byte(want.SoulKDF.Version)
+	w.bytes(want.SoulKDF.Salt)
+	w.uvarint(uint64(want.SoulKDF.MemoryKiB))
+	w.uvarint(uint64(want.SoulKDF.Iterations))
+	w.byte(want.SoulKDF.Parallelism)
+	w.time(want.CreatedAt)
+	var user models.User
+	if err := Unmarshal(w.buf, &user); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&user, want) {
+		t.Fatalf("v1 user:\n got %+v\nwant %+v", user, want)
+	}
+
+	w = writer{buf: []byte{2, byte(KindRecoveryTracker)}}
+	w.time(testExpiresAt)
+	w.string("alice")
+	w.int64s([]int64{4})
+	w.time(testCreatedAt)
+	var tracker models.RecoveryTracker
+	if err := Unmarshal(w.buf, &tracker); err != nil {
+		t.Fatal(err)
+	}
+	if tracker.Username != "alice" || tracker.Identity != "" || tracker.FailedUnixTS != nil || !tracker.CooldownUntil.Equal(testCreatedAt) {
+		t.Fatalf("v2 recovery tracker decoded to %+v", tracker)
+	}
+
+	w = writer{buf: []byte{3, byte(KindMessage)}}
+	for _, field := range []string{"message", "group", "x", "payload", "sender", "signature"} {
+		w.bytes(b(field))
+	}
+	w.time(testCreatedAt)
+	var message models.Message
+	if err := Unmarshal(w.buf, &message); err != nil {
+		t.Fatal(err)
+	}
+	if string(message.Payload) != "payload" || message.Nonce != nil || !message.SentAt.IsZero() {
+		t.Fatalf("v3 message decoded to %+v", message)
+	}
+
+	w = writer{buf: []byte{4, byte(KindMessage)}}
+	for _, field := range []string{"message", "group", "x", "payload", "sender", "signature"} {
+		w.bytes(b(field))
+	}
+	w.time(testCreatedAt)
+	w.bytes(b("nonce"))
+	w.time(testCreatedAt)
+	message = models.Message{}
+	if err := Unmarshal(w.buf, &message); err != nil {
+		t.Fatal(err)
+	}
+	if string(message.Nonce) != "nonce" || message.Seq != 0 {
+		t.Fatalf("v4 message decoded to %+v", message)
+	}
+}
+
+func TestCorruptValues(t *testing.T) {
+	for _, tc := range records() {
+		val, err := Marshal(tc.record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := 0; n < len(val); n++ {
+			if err := Unmarshal(val[:n], empty(tc.record)); !errors.Is(err, ErrCorrupt) {
+				t.Fatalf("%T truncated to %d of %d bytes: %v, want ErrCorrupt", tc.record, n, len(val), err)
+			}
+		}
+		if err := Unmarshal(append(val, 0), empty(tc.record)); !errors.Is(err, ErrCorrupt) {
+			t.Fatalf("%T with a trailing byte: %v, want ErrCorrupt", tc.record, err)
+		}
+
+		newer := append([]byte{Version + 1}, val[1:]...)
+		if err := Unmarshal(newer, empty(tc.record)); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Fatalf("%T from a newer version: %v, want ErrUnsupportedVersion", tc.record, err)
+		}
+	}
+
+	val, err := Marshal(testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Unmarshal(val, &models.User{}); !errors.Is(err, ErrKindMismatch) {
+		t.Fatalf("session decoded as a user: %v, want ErrKindMismatch", err)
+	}
+	if err := Unmarshal(val, &models.Session{UUID: [24]byte{1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Marshal(&struct{}{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("marshal of an unknown type: %v, want ErrUnsupportedType", err)
+	}
+	if err := Unmarshal(val, &struct{}{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("unmarshal into an unknown type: %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	for _, tc := range records() {
+		val, err := Marshal(tc.record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		legacy, err := json.Marshal(tc.record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expires_at, err := ExpiresAt(val)
+		if !tc.expiring {
+			if !errors.Is(err, ErrKindMismatch) {
+				t.Fatalf("%T expiry: %v, want ErrKindMismatch", tc.record, err)
+			}
+			continue
+		}
+		if err != nil || !expires_at.Equal(testExpiresAt) {
+			t.Fatalf("%T expiry: %v %v, want %v", tc.record, expires_at, err, testExpiresAt)
+		}
+		if expires_at, err = ExpiresAt(legacy); err != nil || !expires_at.Equal(testExpiresAt) {
+			t.Fatalf("%T legacy expiry: %v %v, want %v", tc.record, expires_at, err, testExpiresAt)
+		}
+	}
+
+	val, err := Marshal(&models.SessionInitTracker{IdentityHash: "identity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expires_at, err := ExpiresAt(val); err != nil || !expires_at.IsZero() {
+		t.Fatalf("record without an expiry: %v %v, want zero", expires_at, err)
+	}
+	if _, err := ExpiresAt(val[:1]); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("truncated header: %v, want ErrCorrupt", err)
+	}
+	if val, err = Marshal(&models.SessionInitTracker{ExpiresAt: testExpiresAt}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExpiresAt(val[:3]); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("truncated expiry: %v, want ErrCorrupt", err)
+	}
+}
@@ -0,0 +1,242 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) header(kind Kind) {
+	w.buf = append(w.buf, Version, byte(kind))
+}
+
+func (w *writer) byte(b byte) {
+	w.buf = append(w.buf, b)
+}
+
+func (w *writer) bool(b bool) {
+	if b {
+		w.byte(1)
+	} else {
+		w.byte(0)
+	}
+}
+
+func (w *writer) uvarint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *writer) varint(v int64) {
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+// fixed writes an array field without a length; the reader knows its size.
+func (w *writer) fixed(b []byte) {
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) bytes(b []byte) {
+	w.uvarint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) string(s string) {
+	w.uvarint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *writer) time(t time.Time) {
+	if t.IsZero() {
+		w.byte(0)
+		return
+	}
+	w.byte(1)
+	w.varint(t.Unix())
+	w.uvarint(uint64(t.Nanosecond()))
+}
+
+func (w *writer) int64s(v []int64) {
+	w.uvarint(uint64(len(v)))
+	for _, n := range v {
+		w.varint(n)
+	}
+}
+
+// int64Map writes the entries sorted by key, so equal maps encode equally.
+func (w *writer) int64Map(m map[string]int64) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.uvarint(uint64(len(keys)))
+	for _, k := range keys {
+		w.string(k)
+		w.varint(m[k])
+	}
+}
+
+// reader decodes fields in order. The first failure sticks in err and turns
+// every later read into a no-op returning a zero value.
+type reader struct {
+	buf     []byte
+	err     error
+	version byte
+}
+
+func (r *reader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: "+format, append([]any{ErrCorrupt}, args...)...)
+	}
+}
+
+func (r *reader) anyHeader() Kind {
+	if len(r.buf) < 2 {
+		r.fail("short header")
+		return 0
+	}
+	if r.buf[0] == 0 || r.buf[0] > Version {
+		r.err = fmt.Errorf("%w: %d", ErrUnsupportedVersion, r.buf[0])
+		return 0
+	}
+	r.version = r.buf[0]
+	kind := Kind(r.buf[1])
+	r.buf = r.buf[2:]
+	return kind
+}
+
+func (r *reader) header(want Kind) {
+	if kind := r.anyHeader(); r.err == nil && kind != want {
+		r.err = fmt.Errorf("%w: got %d, want %d", ErrKindMismatch, kind, want)
+	}
+}
+
+func (r *reader) finish() error {
+	if r.err == nil && len(r.buf) != 0 {
+		r.fail("%d trailing bytes", len(r.buf))
+	}
+	return r.err
+}
+
+func (r *reader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.fail("field overruns value")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) byte() byte {
+	b := r.take(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) bool() bool {
+	return r.byte() == 1
+}
+
+func (r *reader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.fail("bad uvarint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *reader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.fail("bad varint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+// length reads a length prefix and checks it against what is left, so a
+// corrupt prefix cannot trigger a huge allocation.
+func (r *reader) length() int {
+	n := r.uvarint()
+	if n > uint64(len(r.buf)) {
+		r.fail("length %d overruns value", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *reader) fixed(dst []byte) {
+	copy(dst, r.take(len(dst)))
+}
+
+// bytes returns a copy, so decoded records never alias the store's buffers.
+func (r *reader) bytes() []byte {
+	n := r.length()
+	b := r.take(n)
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+func (r *reader) string() string {
+	return string(r.take(r.length()))
+}
+
+func (r *reader) time() time.Time {
+	if !r.bool() {
+		return time.Time{}
+	}
+	sec := r.varint()
+	nsec := r.uvarint()
+	if nsec >= uint64(time.Second) {
+		r.fail("bad nanoseconds")
+		return time.Time{}
+	}
+	return time.Unix(sec, int64(nsec)).UTC()
+}
+
+func (r *reader) int64s() []int64 {
+	n := r.length()
+	if n == 0 {
+		return nil
+	}
+	v := make([]int64, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		v = append(v, r.varint())
+	}
+	return v
+}
+
+func (r *reader) int64Map() map[string]int64 {
+	n := r.length()
+	if n == 0 {
+		return nil
+	}
+	m := make(map[string]int64, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		k := r.string()
+		m[k] = r.varint()
+	}
+	return m
+}
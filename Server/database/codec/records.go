@@ -0,0 +1,232 @@
+package codec
+
+import "github.com/MHSarmadi/Umbra/Server/models"
+
+// Field order is the format. Appending, removing or reordering a field means
+// bumping Version and keeping a decoder for the old one.
+
+func encodeSession(w *writer, s *models.Session) {
+	w.time(s.ExpiresAt)
+	w.fixed(s.UUID[:])
+	w.string(string(s.State))
+	w.time(s.ActivatedAt)
+	w.time(s.CreatedAt)
+	w.fixed(s.ClientEdPubKey[:])
+	w.fixed(s.ClientXPubKey[:])
+	w.fixed(s.ServerSoul[:])
+	w.fixed(s.SessionToken[:])
+	w.fixed(s.SessionTokenCipherKeySalt[:])
+	w.int64Map(s.LastNonces)
+	w.varint(s.LastActivity)
+	w.fixed(s.PoWChallenge[:])
+	w.uvarint(uint64(s.PoWParams.MemoryMB))
+	w.uvarint(uint64(s.PoWParams.Iterations))
+	w.uvarint(uint64(s.PoWParams.Parallelism))
+	w.fixed(s.PoWSalt[:])
+	w.bytes(s.PoWSolution)
+	w.bool(s.PoWAttempted)
+	w.bytes(s.ActivationNonce)
+	w.byte(s.ActivationAttempts)
+	w.bytes(s.UserUUID)
+}
+
+func decodeSession(r *reader, s *models.Session) {
+	s.ExpiresAt = r.time()
+	r.fixed(s.UUID[:])
+	s.State = models.SessionState(r.string())
+	s.ActivatedAt = r.time()
+	s.CreatedAt = r.time()
+	r.fixed(s.ClientEdPubKey[:])
+	r.fixed(s.ClientXPubKey[:])
+	r.fixed(s.ServerSoul[:])
+	r.fixed(s.SessionToken[:])
+	r.fixed(s.SessionTokenCipherKeySalt[:])
+	s.LastNonces = r.int64Map()
+	s.LastActivity = r.varint()
+	r.fixed(s.PoWChallenge[:])
+	s.PoWParams.MemoryMB = uint(r.uvarint())
+	s.PoWParams.Iterations = uint(r.uvarint())
+	s.PoWParams.Parallelism = uint(r.uvarint())
+	r.fixed(s.PoWSalt[:])
+	s.PoWSolution = r.bytes()
+	s.PoWAttempted = r.bool()
+	s.ActivationNonce = r.bytes()
+	s.ActivationAttempts = r.byte()
+	s.UserUUID = r.bytes()
+}
+
+func encodeUser(w *writer, u *models.User) {
+	w.bytes(u.UUID)
+	w.string(u.Username)
+	w.bytes(u.XPublicKey)
+	w.bytes(u.EPublicKey)
+	w.bytes(u.EncipheredSoul)
+	w.bytes(u.EncipheredSoulSalt)
+	w.bytes(u.EncipheredSoulTag)
+	w.bytes(u.SoulRecovery)
+	w.bytes(u.SoulRecoverySalt)
+	w.bytes(u.SoulRecoveryTag)
+	w.byte(u.SoulKDF.Version)
+	w.bytes(u.SoulKDF.Salt)
+	w.uvarint(uint64(u.SoulKDF.MemoryKiB))
+	w.uvarint(uint64(u.SoulKDF.Iterations))
+	w.byte(u.SoulKDF.Parallelism)
+	w.time(u.CreatedAt)
+	w.time(u.DisabledAt)
+}
+
+func decodeUser(r *reader, u *models.User) {
+	u.UUID = r.bytes()
+	u.Username = r.string()
+	u.XPublicKey = r.bytes()
+	u.EPublicKey = r.bytes()
+	u.EncipheredSoul = r.bytes()
+	u.EncipheredSoulSalt = r.bytes()
+	u.EncipheredSoulTag = r.bytes()
+	u.SoulRecovery = r.bytes()
+	u.SoulRecoverySalt = r.bytes()
+	u.SoulRecoveryTag = r.bytes()
+	u.SoulKDF.Version = r.byte()
+	u.SoulKDF.Salt = r.bytes()
+	u.SoulKDF.MemoryKiB = uint32(r.uvarint())
+	u.SoulKDF.Iterations = uint32(r.uvarint())
+	u.SoulKDF.Parallelism = r.byte()
+	u.CreatedAt = r.time()
+	if r.version >= 2 {
+		u.DisabledAt = r.time()
+	}
+}
+
+func encodeSessionInitTracker(w *writer, t *models.SessionInitTracker) {
+	w.time(t.ExpiresAt)
+	w.string(t.IdentityHash)
+	w.int64s(t.RequestUnixTS)
+}
+
+func decodeSessionInitTracker(r *reader, t *models.SessionInitTracker) {
+	t.ExpiresAt = r.time()
+	t.IdentityHash = r.string()
+	t.RequestUnixTS = r.int64s()
+}
+
+func encodeRecoveryTracker(w *writer, t *models.RecoveryTracker) {
+	w.time(t.ExpiresAt)
+	w.string(t.Username)
+	w.int64s(t.RequestUnixTS)
+	w.time(t.CooldownUntil)
+	w.string(t.Identity)
+	w.int64s(t.FailedUnixTS)
+}
+
+func decodeRecoveryTracker(r *reader, t *models.RecoveryTracker) {
+	t.ExpiresAt = r.time()
+	t.Username = r.string()
+	t.RequestUnixTS = r.int64s()
+	t.CooldownUntil = r.time()
+	if r.version >= 3 {
+		t.Identity = r.string()
+		t.FailedUnixTS = r.int64s()
+	}
+}
+
+func encodeRateLimitState(w *writer, t *models.RateLimitState) {
+	w.time(t.ExpiresAt)
+	w.string(t.Bucket)
+	w.bytes(t.State)
+}
+
+func decodeRateLimitState(r *reader, t *models.RateLimitState) {
+	t.ExpiresAt = r.time()
+	t.Bucket = r.string()
+	t.State = r.bytes()
+}
+
+func encodeGroup(w *writer, g *models.Group) {
+	w.bytes(g.UUID)
+	w.bytes(g.XPublicKey)
+	w.bytes(g.EPublicKey)
+	w.bytes(g.EncipheredEntranceKey)
+	w.bytes(g.EncipheredEntranceKeySalt)
+	w.bytes(g.EncipheredEntranceKeyTag)
+	w.bytes(g.CreatorUUID)
+	w.time(g.CreatedAt)
+}
+
+func decodeGroup(r *reader, g *models.Group) {
+	g.UUID = r.bytes()
+	g.XPublicKey = r.bytes()
+	g.EPublicKey = r.bytes()
+	g.EncipheredEntranceKey = r.bytes()
+	g.EncipheredEntranceKeySalt = r.bytes()
+	g.EncipheredEntranceKeyTag = r.bytes()
+	g.CreatorUUID = r.bytes()
+	g.CreatedAt = r.time()
+}
+
+func encodeGroupMember(w *writer, m *models.GroupMember) {
+	w.bytes(m.GroupUUID)
+	w.bytes(m.UserUUID)
+	w.string(string(m.Role))
+	w.bytes(m.WrapXPublicKey)
+	w.bytes(m.WrappedSecret)
+	w.bytes(m.WrappedSecretSalt)
+	w.bytes(m.WrappedSecretTag)
+	w.bytes(m.AddedBy)
+	w.time(m.AddedAt)
+}
+
+func decodeGroupMember(r *reader, m *models.GroupMember) {
+	m.GroupUUID = r.bytes()
+	m.UserUUID = r.bytes()
+	m.Role = models.GroupRole(r.string())
+	m.WrapXPublicKey = r.bytes()
+	m.WrappedSecret = r.bytes()
+	m.WrappedSecretSalt = r.bytes()
+	m.WrappedSecretTag = r.bytes()
+	m.AddedBy = r.bytes()
+	m.AddedAt = r.time()
+}
+
+func encodeMessage(w *writer, m *models.Message) {
+	w.bytes(m.UUID)
+	w.bytes(m.GroupUUID)
+	w.bytes(m.XPublicKey)
+	w.bytes(m.Payload)
+	w.bytes(m.SenderUUID)
+	w.bytes(m.SenderSignature)
+	w.time(m.CreatedAt)
+	w.bytes(m.Nonce)
+	w.time(m.SentAt)
+	w.uvarint(m.Seq)
+}
+
+func decodeMessage(r *reader, m *models.Message) {
+	m.UUID = r.bytes()
+	m.GroupUUID = r.bytes()
+	m.XPublicKey = r.bytes()
+	m.Payload = r.bytes()
+	m.SenderUUID = r.bytes()
+	m.SenderSignature = r.bytes()
+	m.CreatedAt = r.time()
+	if r.version >= 4 {
+		m.Nonce = r.bytes()
+		m.SentAt = r.time()
+	}
+	if r.version >= 5 {
+		m.Seq = r.uvarint()
+	}
+}
+
+func encodeMessageNonce(w *writer, n *models.MessageNonce) {
+	w.time(n.ExpiresAt)
+	w.bytes(n.GroupUUID)
+	w.bytes(n.SenderUUID)
+	w.bytes(n.Nonce)
+}
+
+func decodeMessageNonce(r *reader, n *models.MessageNonce) {
+	n.ExpiresAt = r.time()
+	n.GroupUUID = r.bytes()
+	n.SenderUUID = r.bytes()
+	n.Nonce = r.bytes()
+}
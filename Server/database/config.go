@@ -0,0 +1,43 @@
+package database
+
+import (
+	"errors"
+	"time"
+)
+
+// Config selects and opens the store.
+type Config struct {
+	// DataDir is the Badger directory. It is ignored with Memory.
+	DataDir string
+	Memory  bool
+	// KeyFile or Passphrase, never both, encrypt DataDir at rest; see
+	// LoadEncryptionKey.
+	KeyFile    string
+	Passphrase string
+	// JanitorInterval is how often expired sessions and trackers are swept.
+	JanitorInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		DataDir:         "./data",
+		JanitorInterval: 1 * time.Minute,
+	}
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Memory && (c.KeyFile != "" || c.Passphrase != "") {
+		errs = append(errs, errors.New("the in-memory store cannot be encrypted at rest"))
+	}
+	if !c.Memory && c.DataDir == "" {
+		errs = append(errs, errors.New("data directory is required unless the store is in memory"))
+	}
+	if c.KeyFile != "" && c.Passphrase != "" {
+		errs = append(errs, ErrEncryptionKeySource)
+	}
+	if c.JanitorInterval <= 0 {
+		errs = append(errs, errors.New("janitor interval must be positive"))
+	}
+	return errors.Join(errs...)
+}
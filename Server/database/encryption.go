@@ -0,0 +1,161 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	"golang.org/x/crypto/argon2"
+)
+
+// Badger encrypts every table and value log with data keys, which it rotates
+// on its own and keeps in its key registry. The master key configured here
+// only encrypts that registry, so rotating it rewrites one small file.
+
+var (
+	ErrEncryptionKeySource  = errors.New("give either an encryption key file or a passphrase, not both")
+	ErrInvalidEncryptionKey = errors.New("encryption key must be 16, 24 or 32 bytes, raw or hex encoded")
+	ErrWrongEncryptionKey   = errors.New("encryption key does not match the data directory")
+)
+
+// encryptionKDFFile sits next to Badger's own files and holds the Argon2id
+// parameters a passphrase is stretched with. None of it is secret.
+const encryptionKDFFile = "ENCRYPTION_KDF"
+
+const (
+	encryptionKDFVersion     = 1
+	encryptionKDFIterations  = 3
+	encryptionKDFMemoryKiB   = 64 * 1024
+	encryptionKDFParallelism = 4
+	encryptionKDFSaltSize    = 16
+	encryptionKeySize        = 32
+)
+
+// LoadEncryptionKey returns the master key for the data directory at dir. It
+// is read from key_file or derived from passphrase; with neither it is nil
+// and the store stays unencrypted.
+func LoadEncryptionKey(dir, key_file string, passphrase []byte) ([]byte, error) {
+	switch {
+	case key_file != "" && len(passphrase) > 0:
+		return nil, ErrEncryptionKeySource
+	case key_file != "":
+		return readKeyFile(key_file)
+	case len(passphrase) > 0:
+		return derivePassphraseKey(dir, passphrase)
+	}
+	return nil, nil
+}
+
+// readKeyFile accepts the raw key bytes or their hex encoding.
+func readKeyFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("encryption key file %s must not be readable by group or others", path)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := raw
+	if decoded, err := hex.DecodeString(strings.TrimSpace(string(raw))); err == nil {
+		key = decoded
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, ErrInvalidEncryptionKey
+}
+
+// derivePassphraseKey stretches passphrase with Argon2id. The salt and cost
+// are created on first use and read back from dir afterwards, so the same
+// passphrase keeps opening the same directory.
+func derivePassphraseKey(dir string, passphrase []byte) ([]byte, error) {
+	path := filepath.Join(dir, encryptionKDFFile)
+	params, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		params = make([]byte, 1+4+4+1+encryptionKDFSaltSize)
+		params[0] = encryptionKDFVersion
+		binary.BigEndian.PutUint32(params[1:5], encryptionKDFIterations)
+		binary.BigEndian.PutUint32(params[5:9], encryptionKDFMemoryKiB)
+		params[9] = encryptionKDFParallelism
+		if _, err := rand.Read(params[10:]); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, params, 0o600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if len(params) != 1+4+4+1+encryptionKDFSaltSize || params[0] != encryptionKDFVersion {
+		return nil, fmt.Errorf("unsupported or corrupt %s", path)
+	}
+	iterations := binary.BigEndian.Uint32(params[1:5])
+	memory := binary.BigEndian.Uint32(params[5:9])
+	return argon2.IDKey(passphrase, params[10:], iterations, memory, params[9], encryptionKeySize), nil
+}
+
+// RotateEncryptionKey re-encrypts the key registry of the data directory at
+// dir from old_key to new_key. The directory must not be open elsewhere; the
+// data itself is not rewritten.
+func RotateEncryptionKey(dir string, old_key, new_key []byte) (err error) {
+	if len(old_key) == 0 || len(new_key) == 0 {
+		// Without a master key Badger writes plaintext tables, which a new
+		// registry key would not encrypt. Restore a backup instead.
+		return errors.New("both the current and the new encryption key are required")
+	}
+
+	// A read-only open checks old_key and holds a shared lock on the
+	// directory until the new registry is in place: a running server is
+	// refused, and none can start while the registry is rewritten. Unlike a
+	// writable store, it never appends data keys to the registry being
+	// replaced.
+	db, err := badger.Open(badgerOptions(dir, old_key).WithReadOnly(true))
+	if err != nil {
+		return openError(err)
+	}
+	defer func() {
+		if close_err := db.Close(); err == nil {
+			err = close_err
+		}
+	}()
+
+	opt := badger.KeyRegistryOptions{
+		Dir:           dir,
+		ReadOnly:      true,
+		EncryptionKey: old_key,
+	}
+	registry, err := badger.OpenKeyRegistry(opt)
+	if err != nil {
+		return openError(err)
+	}
+	defer registry.Close()
+	opt.EncryptionKey = new_key
+	return badger.WriteKeyRegistry(registry, opt)
+}
+
+// openError turns Badger's key errors into ones the operator can act on.
+func openError(err error) error {
+	switch {
+	case errors.Is(err, badger.ErrEncryptionKeyMismatch):
+		return ErrWrongEncryptionKey
+	case errors.Is(err, badger.ErrInvalidEncryptionKey):
+		return ErrInvalidEncryptionKey
+	}
+	return err
+}
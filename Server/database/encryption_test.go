@@ -0,0 +1,55 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+func TestRotateEncryptionKey(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	old_key := bytes.Repeat([]byte{1}, 32)
+	new_key := bytes.Repeat([]byte{2}, 32)
+
+	s, err := NewBadgerStore(dir, old_key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Username: "alice"}
+	if err := s.PutUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if err := RotateEncryptionKey(dir, old_key, new_key); err == nil {
+		t.Fatal("rotated the key of a directory that is open")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RotateEncryptionKey(dir, new_key, old_key); !errors.Is(err, ErrWrongEncryptionKey) {
+		t.Fatalf("rotating with the wrong current key: %v, want ErrWrongEncryptionKey", err)
+	}
+	if err := RotateEncryptionKey(dir, old_key, new_key); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewBadgerStore(dir, old_key); !errors.Is(err, ErrWrongEncryptionKey) {
+		t.Fatalf("opening with the retired key: %v, want ErrWrongEncryptionKey", err)
+	}
+	s, err = NewBadgerStore(dir, new_key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	loaded, err := s.GetUserByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(loaded.UUID, user.UUID) {
+		t.Fatal("user changed across the key rotation")
+	}
+}
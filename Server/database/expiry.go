@@ -0,0 +1,121 @@
+package database
+
+import (
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// expiringNamespaces hold the records that carry an ExpiresAt, get an expiry
+// index entry and are removed by the janitor.
+var expiringNamespaces = []*keyspace.Namespace{
+	keyspace.Sessions,
+	keyspace.SessionInitTrackers,
+	keyspace.RecoveryTrackers,
+	keyspace.RateLimits,
+	keyspace.MessageNonces,
+}
+
+// expiring reports whether key lies in one of expiringNamespaces.
+func expiring(key []byte) bool {
+	return expiringNamespace(key) != nil
+}
+
+// expiringNamespace is the one of expiringNamespaces key lies in, or nil.
+func expiringNamespace(key []byte) *keyspace.Namespace {
+	for _, ns := range expiringNamespaces {
+		if len(key) >= 2 && key[0] == keyspace.SchemaVersion && key[1] == ns.ID {
+			return ns
+		}
+	}
+	return nil
+}
+
+// storedExpiry returns the expiry of the record currently under key, or the
+// zero time if there is none.
+func storedExpiry(txn *badger.Txn, key []byte) (time.Time, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	var expires_at time.Time
+	err = item.Value(func(val []byte) (err error) {
+		expires_at, err = codec.ExpiresAt(val)
+		return err
+	})
+	return expires_at, err
+}
+
+// getLive decodes the expiring record under key into v. A record past its
+// ExpiresAt is reported as badger.ErrKeyNotFound even while its TTL, which
+// is rounded up to whole seconds, still keeps it readable.
+func getLive(txn *badger.Txn, key []byte, v any) error {
+	item, err := txn.Get(key)
+	if err != nil {
+		return err
+	}
+	return item.Value(func(val []byte) error {
+		expires_at, err := codec.ExpiresAt(val)
+		if err != nil {
+			return err
+		}
+		if !expires_at.IsZero() && time.Now().After(expires_at) {
+			return badger.ErrKeyNotFound
+		}
+		return codec.Unmarshal(val, v)
+	})
+}
+
+// expiringEntry stores val under key with a Badger TTL ending at expires_at.
+// Badger truncates expiry to whole seconds, so one second is added: a record
+// is never hidden before the expiry checks in this package would reject it.
+func expiringEntry(key, val []byte, expires_at time.Time) *badger.Entry {
+	entry := badger.NewEntry(key, val)
+	if expires_at.IsZero() {
+		return entry
+	}
+	ttl := time.Until(expires_at) + time.Second
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	return entry.WithTTL(ttl)
+}
+
+// setExpiring stores an expiring record with its TTL and moves its expiry
+// index entry from the record it replaces, if any, to the new expiry.
+func setExpiring(txn *badger.Txn, key, val []byte, expires_at time.Time) error {
+	previous, err := storedExpiry(txn, key)
+	if err != nil {
+		return err
+	}
+	if !previous.IsZero() {
+		if err := txn.Delete(keyspace.Expiry(previous, key)); err != nil {
+			return err
+		}
+	}
+	if err := txn.SetEntry(expiringEntry(key, val, expires_at)); err != nil {
+		return err
+	}
+	if expires_at.IsZero() {
+		return nil
+	}
+	return txn.Set(keyspace.Expiry(expires_at, key), nil)
+}
+
+// deleteExpiring deletes an expiring record together with its index entry.
+func deleteExpiring(txn *badger.Txn, key []byte) error {
+	previous, err := storedExpiry(txn, key)
+	if err != nil {
+		return err
+	}
+	if !previous.IsZero() {
+		if err := txn.Delete(keyspace.Expiry(previous, key)); err != nil {
+			return err
+		}
+	}
+	return txn.Delete(key)
+}
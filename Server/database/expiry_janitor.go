@@ -2,24 +2,85 @@ package database
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
 	"github.com/MHSarmadi/Umbra/Server/logger"
-	"github.com/MHSarmadi/Umbra/Server/models"
 	"github.com/dgraph-io/badger/v4"
 )
 
+// sweepBatchSize bounds how many index entries one sweep transaction handles,
+// keeping every transaction far below Badger's size limits.
+const sweepBatchSize = 512
+
+// SweepStats describes one pass of SweepExpired.
+type SweepStats struct {
+	// Removed counts the records removed by the name of their namespace.
+	Removed map[string]int
+	// Batches is the number of bounded transactions the pass used.
+	Batches int
+	// Lag is how long the oldest record removed in this pass had been expired,
+	// i.e. how far behind the janitor was when the pass started.
+	Lag time.Duration
+}
+
+func (st *SweepStats) countRemoved(ns *keyspace.Namespace) {
+	if st.Removed == nil {
+		st.Removed = make(map[string]int)
+	}
+	st.Removed[ns.Name]++
+}
+
+// TotalRemoved is the number of records removed from every namespace.
+func (st SweepStats) TotalRemoved() int {
+	total := 0
+	for _, n := range st.Removed {
+		total += n
+	}
+	return total
+}
+
+// RemovedText lists Removed as name=count pairs, one for every expiring
+// namespace in a fixed order, for log lines.
+func (st SweepStats) RemovedText() string {
+	pairs := make([]string, len(expiringNamespaces))
+	for i, ns := range expiringNamespaces {
+		pairs[i] = fmt.Sprintf("%s=%d", ns.Name, st.Removed[ns.Name])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// janitorHeartbeat is when a sweep last succeeded, in Unix nanoseconds.
+var janitorHeartbeat atomic.Int64
+
+// JanitorHeartbeat returns when the expiry janitor last completed a sweep, or
+// the zero time if it has not yet.
+func JanitorHeartbeat() time.Time {
+	ns := janitorHeartbeat.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
 func (s *BadgerStore) StartExpiryJanitor(ctx context.Context, sweepInterval time.Duration) {
+	runExpiryJanitor(ctx, sweepInterval, s.SweepExpired)
+}
+
+func runExpiryJanitor(ctx context.Context, sweepInterval time.Duration, sweep func(context.Context, time.Time) (SweepStats, error)) {
 	if sweepInterval <= 0 {
 		sweepInterval = 1 * time.Minute
 	}
 
 	// Sweep immediately on startup so restarts clean stale data.
-	if removedSessions, removedTrackers, err := s.SweepExpired(ctx, time.Now().UTC()); err != nil {
+	if stats, err := measuredSweep(ctx, time.Now().UTC(), sweep); err != nil {
 		logger.Errorf("expiry janitor initial sweep error: %v", err)
 	} else {
-		logger.Infof("expiry janitor initial sweep removed sessions=%d trackers=%d", removedSessions, removedTrackers)
+		logger.Infof("expiry janitor initial sweep removed %s batches=%d lag=%s", stats.RemovedText(), stats.Batches, stats.Lag)
 	}
 
 	ticker := time.NewTicker(sweepInterval)
@@ -30,75 +91,101 @@ func (s *BadgerStore) StartExpiryJanitor(ctx context.Context, sweepInterval time
 		case <-ctx.Done():
 			return
 		case t := <-ticker.C:
-			removedSessions, removedTrackers, err := s.SweepExpired(ctx, t.UTC())
+			stats, err := measuredSweep(ctx, t.UTC(), sweep)
 			if err != nil {
 				logger.Errorf("expiry janitor sweep error: %v", err)
 				continue
 			}
-			if removedSessions > 0 || removedTrackers > 0 {
-				logger.Infof("expiry janitor removed sessions=%d trackers=%d", removedSessions, removedTrackers)
+			if stats.TotalRemoved() > 0 {
+				logger.Infof("expiry janitor removed %s batches=%d lag=%s", stats.RemovedText(), stats.Batches, stats.Lag)
+			}
+			// Anything expired should be gone within one interval; more than
+			// that means sweeps cannot keep up with the rate of expiry.
+			if stats.Lag > sweepInterval {
+				logger.Errorf("expiry janitor is behind by %s (interval %s)", stats.Lag, sweepInterval)
 			}
 		}
 	}
 }
 
-func (s *BadgerStore) SweepExpired(ctx context.Context, now time.Time) (removedSessions int, removedTrackers int, err error) {
-	err = s.db.Update(func(txn *badger.Txn) error {
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it.Close()
-
-		// Session keys are prefix 0x10 and exactly 25 bytes (1 prefix + 24 UUID bytes).
-		for it.Seek([]byte{0x10}); it.ValidForPrefix([]byte{0x10}); it.Next() {
-			if ctx.Err() != nil {
-				return ctx.Err()
-			}
-
-			item := it.Item()
-			key := item.KeyCopy(nil)
-			if len(key) != 25 {
-				continue
-			}
-
-			var session models.Session
-			if err := item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &session)
-			}); err != nil {
-				continue
-			}
+// SweepExpired walks the expiry index up to now and removes what it points
+// at. Badger already hides expired records through their TTL; the sweep
+// deletes them for good and drops their index entries, in transactions of at
+// most sweepBatchSize entries.
+func (s *BadgerStore) SweepExpired(ctx context.Context, now time.Time) (stats SweepStats, err error) {
+	prefix := keyspace.Expiries.Key()
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
 
-			if !session.ExpiresAt.IsZero() && now.After(session.ExpiresAt) {
-				if err := txn.Delete(key); err != nil {
-					return err
+		var due [][]byte
+		err := s.db.View(func(txn *badger.Txn) error {
+			it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+			defer it.Close()
+			for it.Seek(prefix); it.ValidForPrefix(prefix) && len(due) < sweepBatchSize; it.Next() {
+				expires_at, _, ok := keyspace.SplitExpiry(it.Item().Key())
+				if ok && expires_at.After(now) {
+					break
+				}
+				if ok && stats.Batches == 0 && len(due) == 0 {
+					stats.Lag = now.Sub(expires_at)
 				}
-				removedSessions++
+				due = append(due, it.Item().KeyCopy(nil))
 			}
+			return nil
+		})
+		if err != nil || len(due) == 0 {
+			return stats, err
 		}
 
-		// Session-init tracker keys are prefix 0x12.
-		for it.Seek([]byte{0x12}); it.ValidForPrefix([]byte{0x12}); it.Next() {
-			if ctx.Err() != nil {
-				return ctx.Err()
-			}
-
-			item := it.Item()
-			key := item.KeyCopy(nil)
-
-			var tracker models.SessionInitTracker
-			if err := item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &tracker)
-			}); err != nil {
-				continue
-			}
+		var removed []*keyspace.Namespace
+		err = s.db.Update(func(txn *badger.Txn) error {
+			removed = removed[:0]
+			for _, index_key := range due {
+				if err := txn.Delete(index_key); err != nil {
+					return err
+				}
+				_, key, ok := keyspace.SplitExpiry(index_key)
+				ns := expiringNamespace(key)
+				if !ok || ns == nil {
+					continue
+				}
 
-			if !tracker.ExpiresAt.IsZero() && now.After(tracker.ExpiresAt) {
-				if err := txn.Delete(key); err != nil {
+				item, err := txn.Get(key)
+				if err == nil {
+					var expires_at time.Time
+					if err := item.Value(func(val []byte) (err error) {
+						expires_at, err = codec.ExpiresAt(val)
+						return err
+					}); err == nil && !expires_at.IsZero() && !now.After(expires_at) {
+						// The record was extended after this entry was written
+						// and is indexed again under its new expiry.
+						continue
+					}
+					if err := txn.Delete(key); err != nil {
+						return err
+					}
+				} else if err != badger.ErrKeyNotFound {
 					return err
 				}
-				removedTrackers++
+
+				// A record missing here was hidden by its TTL: setExpiring and
+				// deleteExpiring drop the entries of records they replace.
+				removed = append(removed, ns)
 			}
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+		for _, ns := range removed {
+			stats.countRemoved(ns)
 		}
+		stats.Batches++
 
-		return nil
-	})
-	return removedSessions, removedTrackers, err
+		if len(due) < sweepBatchSize {
+			return stats, nil
+		}
+	}
 }
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/MHSarmadi/Umbra/Server/database/migrations"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Operator access for umbra-admin. None of this is on the Store interface:
+// it walks whole namespaces and is meant for a data directory no server has
+// open.
+
+// ErrNeedsMigration is returned when a read-only open finds a data directory
+// at another migration version than this binary's. Read-only opens cannot
+// migrate, and decoding an older layout would misread it.
+var ErrNeedsMigration = errors.New("data directory is not at this binary's migration version")
+
+// OpenBadgerStoreReadOnly opens the data directory at path without writing
+// to it. Badger takes a shared lock, so this fails while a server has the
+// directory open.
+func OpenBadgerStoreReadOnly(path string, encryption_key []byte) (*BadgerStore, error) {
+	db, err := badger.Open(badgerOptions(path, encryption_key).WithReadOnly(true))
+	if err != nil {
+		return nil, openError(err)
+	}
+	meta, err := migrations.ReadMeta(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if meta.Version != migrations.Latest() {
+		db.Close()
+		return nil, fmt.Errorf("%w: at %d, want %d", ErrNeedsMigration, meta.Version, migrations.Latest())
+	}
+	return &BadgerStore{db: db}, nil
+}
+
+// scan visits every live record of ns in key order. Records Badger already
+// hides through their TTL are skipped.
+func (s *BadgerStore) scan(ctx context.Context, ns *keyspace.Namespace, visit func(key, val []byte) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: ns.Key(), PrefetchValues: true, PrefetchSize: 100})
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			item := it.Item()
+			key := item.KeyCopy(nil)
+			if err := item.Value(func(val []byte) error {
+				return visit(key, val)
+			}); err != nil {
+				return fmt.Errorf("%s %x: %w", ns.Name, ns.Trim(key), err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BadgerStore) ListSessions(ctx context.Context, visit func(*models.Session) error) error {
+	return s.scan(ctx, keyspace.Sessions, func(key, val []byte) error {
+		var session models.Session
+		if err := codec.Unmarshal(val, &session); err != nil {
+			return err
+		}
+		return visit(&session)
+	})
+}
+
+func (s *BadgerStore) ListUsers(ctx context.Context, visit func(*models.User) error) error {
+	return s.scan(ctx, keyspace.Users, func(key, val []byte) error {
+		var user models.User
+		if err := codec.Unmarshal(val, &user); err != nil {
+			return err
+		}
+		return visit(&user)
+	})
+}
+
+func (s *BadgerStore) ListSessionInitTrackers(ctx context.Context, visit func(*models.SessionInitTracker) error) error {
+	return s.scan(ctx, keyspace.SessionInitTrackers, func(key, val []byte) error {
+		var tracker models.SessionInitTracker
+		if err := codec.Unmarshal(val, &tracker); err != nil {
+			return err
+		}
+		return visit(&tracker)
+	})
+}
+
+func (s *BadgerStore) ListRecoveryTrackers(ctx context.Context, visit func(*models.RecoveryTracker) error) error {
+	return s.scan(ctx, keyspace.RecoveryTrackers, func(key, val []byte) error {
+		var tracker models.RecoveryTracker
+		if err := codec.Unmarshal(val, &tracker); err != nil {
+			return err
+		}
+		return visit(&tracker)
+	})
+}
+
+// deleteExisting deletes an expiring record, or returns ErrNotFound if there
+// is none under key.
+func (s *BadgerStore) deleteExisting(key []byte) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(key); err == badger.ErrKeyNotFound {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+		return deleteExpiring(txn, key)
+	})
+}
+
+// DeleteSessionInitTracker forgets the session-init requests counted against
+// an identity, lifting its rate limit.
+func (s *BadgerStore) DeleteSessionInitTracker(ctx context.Context, identity_hash string) error {
+	return s.deleteExisting(keyspace.SessionInitTracker(identity_hash))
+}
+
+// DeleteRecoveryTracker forgets the recovery attempts and cooldowns of every
+// identity on username.
+func (s *BadgerStore) DeleteRecoveryTracker(ctx context.Context, username string) error {
+	prefix := keyspace.RecoveryTrackers.Key(keyspace.RecoveryTrackerPrefix(username))
+	return s.db.Update(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		var keys [][]byte
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		it.Close()
+		if len(keys) == 0 {
+			return ErrNotFound
+		}
+		for _, key := range keys {
+			if err := deleteExpiring(txn, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DisableUser marks the user disabled as of now, keeping an earlier
+// DisabledAt, and revokes every session logged in as them. It returns how
+// many sessions it revoked.
+func (s *BadgerStore) DisableUser(ctx context.Context, uuid []byte, now time.Time) (revoked int, err error) {
+	if _, err := s.UpdateUser(ctx, uuid, func(u *models.User) error {
+		if !u.Disabled() {
+			u.DisabledAt = now.UTC()
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	var bound [][24]byte
+	if err := s.ListSessions(ctx, func(session *models.Session) error {
+		if bytes.Equal(session.UserUUID, uuid) {
+			bound = append(bound, session.UUID)
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	for _, id := range bound {
+		if err := s.DeleteSession(ctx, id); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+type StoreStats struct {
+	// MigrationVersion is the migration the data directory is at.
+	MigrationVersion int
+	// Keys counts the live keys of each namespace, by namespace name.
+	Keys     map[string]int
+	LSMBytes int64
+	// VLogBytes is the size of the value log. Badger refreshes both sizes
+	// about once a minute while open, and on open.
+	VLogBytes int64
+}
+
+func (s *BadgerStore) Stats(ctx context.Context) (StoreStats, error) {
+	meta, err := migrations.ReadMeta(s.db)
+	if err != nil {
+		return StoreStats{}, err
+	}
+	stats := StoreStats{MigrationVersion: meta.Version, Keys: map[string]int{}}
+	stats.LSMBytes, stats.VLogBytes = s.db.Size()
+
+	err = s.db.View(func(txn *badger.Txn) error {
+		for _, ns := range keyspace.Namespaces() {
+			it := txn.NewIterator(badger.IteratorOptions{Prefix: ns.Key()})
+			n := 0
+			for it.Rewind(); it.Valid(); it.Next() {
+				n++
+			}
+			it.Close()
+			stats.Keys[ns.Name] = n
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return stats, err
+}
@@ -0,0 +1,182 @@
+// Package keyspace owns every key the Badger store writes. Each record type
+// gets its own namespace byte, and every key starts with the schema version
+// byte so a later layout can live next to this one while it is migrated.
+//
+//	key = SchemaVersion || namespace || parts...
+package keyspace
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// SchemaVersion is the version byte every key of the current layout starts
+// with. Keys written before the registry existed had no version byte and are
+// treated as version 0.
+const SchemaVersion byte = 1
+
+// SchemaKey holds the layout version of the whole data directory as a single
+// byte. It starts with 0x00 so it can never be mistaken for a versioned key.
+var SchemaKey = []byte{0x00, 's', 'c', 'h', 'e', 'm', 'a'}
+
+// Namespace is one record type's slice of the keyspace.
+type Namespace struct {
+	Name string
+	ID   byte
+}
+
+var registry []*Namespace
+
+func register(name string, id byte) *Namespace {
+	ns := &Namespace{Name: name, ID: id}
+	registry = append(registry, ns)
+	return ns
+}
+
+var (
+	Users               = register("users", 0x10)
+	Usernames           = register("usernames", 0x11)
+	Sessions            = register("sessions", 0x12)
+	SessionInitTrackers = register("session_init_trackers", 0x13)
+	RecoveryTrackers    = register("recovery_trackers", 0x14)
+	Groups              = register("groups", 0x15)
+	GroupMembers        = register("group_members", 0x16)
+	UserGroups          = register("user_groups", 0x17)
+	Messages            = register("messages", 0x18)
+	Expiries            = register("expiries", 0x19)
+	RateLimits          = register("rate_limits", 0x1a)
+	MessageNonces       = register("message_nonces", 0x1b)
+	MessageSequences    = register("message_sequences", 0x1c)
+)
+
+func init() {
+	if err := Validate(); err != nil {
+		panic(err)
+	}
+}
+
+// Validate reports namespaces that share an ID or a name, and IDs that would
+// clash with SchemaKey.
+func Validate() error {
+	ids := make(map[byte]string, len(registry))
+	names := make(map[string]bool, len(registry))
+	for _, ns := range registry {
+		if ns.ID == SchemaKey[0] {
+			return fmt.Errorf("keyspace: namespace %q uses reserved id 0x%02x", ns.Name, ns.ID)
+		}
+		if other, ok := ids[ns.ID]; ok {
+			return fmt.Errorf("keyspace: namespaces %q and %q share id 0x%02x", other, ns.Name, ns.ID)
+		}
+		if names[ns.Name] {
+			return fmt.Errorf("keyspace: namespace name %q registered twice", ns.Name)
+		}
+		ids[ns.ID] = ns.Name
+		names[ns.Name] = true
+	}
+	return nil
+}
+
+// Namespaces returns every registered namespace in registration order.
+func Namespaces() []*Namespace {
+	return append([]*Namespace(nil), registry...)
+}
+
+// Key builds a key in ns from parts.
+func (ns *Namespace) Key(parts ...[]byte) []byte {
+	size := 2
+	for _, part := range parts {
+		size += len(part)
+	}
+	key := make([]byte, 0, size)
+	key = append(key, SchemaVersion, ns.ID)
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
+
+// Trim returns what follows the namespace header of key.
+func (ns *Namespace) Trim(key []byte) []byte {
+	return key[2:]
+}
+
+func User(uuid []byte) []byte {
+	return Users.Key(uuid)
+}
+
+func Username(username string) []byte {
+	return Usernames.Key([]byte(username))
+}
+
+func Session(uuid [24]byte) []byte {
+	return Sessions.Key(uuid[:])
+}
+
+func SessionInitTracker(identity_hash string) []byte {
+	return SessionInitTrackers.Key([]byte(identity_hash))
+}
+
+// RecoveryTracker is where the recovery attempts of one client identity on
+// username are counted. Usernames never hold a zero byte, so
+// RecoveryTrackerPrefix(username) covers every identity of one username.
+func RecoveryTracker(username, identity string) []byte {
+	return RecoveryTrackers.Key(RecoveryTrackerPrefix(username), []byte(identity))
+}
+
+func RecoveryTrackerPrefix(username string) []byte {
+	return append([]byte(username), 0)
+}
+
+func RateLimit(bucket string) []byte {
+	return RateLimits.Key([]byte(bucket))
+}
+
+func Group(uuid []byte) []byte {
+	return Groups.Key(uuid)
+}
+
+// GroupMember is where a membership lives, grouped by group.
+func GroupMember(group_uuid, user_uuid []byte) []byte {
+	return GroupMembers.Key(group_uuid, user_uuid)
+}
+
+// UserGroup is the membership index, grouped by user. It holds no value.
+func UserGroup(user_uuid, group_uuid []byte) []byte {
+	return UserGroups.Key(user_uuid, group_uuid)
+}
+
+// Message places a message in its group's history; cursor orders it.
+func Message(group_uuid, cursor []byte) []byte {
+	return Messages.Key(group_uuid, cursor)
+}
+
+// MessageSequence holds the sequence number of a group's newest message, 8
+// bytes big-endian.
+func MessageSequence(group_uuid []byte) []byte {
+	return MessageSequences.Key(group_uuid)
+}
+
+// MessageNonce is where a nonce a sender used in a group is remembered.
+func MessageNonce(group_uuid, sender_uuid, nonce []byte) []byte {
+	return MessageNonces.Key(group_uuid, sender_uuid, nonce)
+}
+
+// Expiry indexes the record under key by the time it expires, rounded up to
+// the millisecond. Keys sort by time, so the due entries come first. It holds
+// no value.
+func Expiry(expires_at time.Time, key []byte) []byte {
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(expires_at.Add(time.Millisecond-1).UnixMilli()))
+	return Expiries.Key(ms[:], key)
+}
+
+// SplitExpiry takes an Expiry key apart into the expiry time and the key of
+// the indexed record.
+func SplitExpiry(index_key []byte) (expires_at time.Time, key []byte, ok bool) {
+	rest := Expiries.Trim(index_key)
+	if len(rest) < 8 {
+		return time.Time{}, nil, false
+	}
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(rest[:8]))).UTC(), rest[8:], true
+}
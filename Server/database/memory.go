@@ -0,0 +1,605 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+// MemoryStore is a Store held entirely in process memory. Records live under
+// the same keyspace keys and encoding as in BadgerStore, and one mutex plays
+// the part of Badger's transactions. Nothing survives Close.
+type MemoryStore struct {
+	mu sync.Mutex
+	kv map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{kv: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kv = make(map[string][]byte)
+	return nil
+}
+
+// load decodes the value under key into v. An expired session, tracker or
+// other expiring record is not found, as Badger's TTL hides it, though it
+// stays in place for SweepExpired to count. Callers hold s.mu.
+func (s *MemoryStore) load(key []byte, v any) error {
+	val, ok := s.kv[string(key)]
+	if !ok {
+		return ErrNotFound
+	}
+	if expiring(key) {
+		expires_at, err := codec.ExpiresAt(val)
+		if err != nil {
+			return err
+		}
+		if !expires_at.IsZero() && time.Now().After(expires_at) {
+			return ErrNotFound
+		}
+	}
+	return codec.Unmarshal(val, v)
+}
+
+// store encodes v under key. Callers hold s.mu.
+func (s *MemoryStore) store(key []byte, v any) error {
+	val, err := codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.kv[string(key)] = val
+	return nil
+}
+
+func (s *MemoryStore) has(key []byte) bool {
+	_, ok := s.kv[string(key)]
+	return ok
+}
+
+// scan returns the keys under prefix in Badger's byte order, reversed when
+// asked. Callers hold s.mu.
+func (s *MemoryStore) scan(prefix []byte, reverse bool) []string {
+	var keys []string
+	for k := range s.kv {
+		if strings.HasPrefix(k, string(prefix)) {
+			keys = append(keys, k)
+		}
+	}
+	if reverse {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	} else {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+func (s *MemoryStore) PutUser(ctx context.Context, u *models.User) error {
+	if u.Username == "" {
+		return ErrUsernameRequired
+	}
+	if len(u.UUID) == 0 {
+		u.UUID = make([]byte, 32)
+		if _, err := rand.Read(u.UUID); err != nil {
+			return err
+		}
+	}
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now().UTC()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.has(u.KeyByUsername()) {
+		return ErrUsernameTaken
+	}
+	if err := s.store(u.KeyByUUID(), u); err != nil {
+		return err
+	}
+	s.kv[string(u.KeyByUsername())] = append([]byte{}, u.UUID...)
+	return nil
+}
+
+func (s *MemoryStore) GetUserByUUID(ctx context.Context, uuid []byte) (*models.User, error) {
+	u := models.User{
+		UUID: uuid,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(u.KeyByUUID(), &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *MemoryStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	u := models.User{
+		Username: username,
+	}
+	s.mu.Lock()
+	uuid, ok := s.kv[string(u.KeyByUsername())]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.GetUserByUUID(ctx, append([]byte{}, uuid...))
+}
+
+func (s *MemoryStore) UpdateUser(ctx context.Context, uuid []byte, mutate func(*models.User) error) (*models.User, error) {
+	loaded := models.User{
+		UUID: uuid,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(loaded.KeyByUUID(), &loaded); err != nil {
+		return nil, err
+	}
+	username := loaded.Username
+	if err := mutate(&loaded); err != nil {
+		return nil, err
+	}
+	loaded.Username = username
+	if err := s.store(loaded.KeyByUUID(), &loaded); err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
+func (s *MemoryStore) PutSession(ctx context.Context, u *models.Session) error {
+	if u.UUID == [24]byte{} {
+		if _, err := rand.Read(u.UUID[:]); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC()
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = now
+	}
+	if u.ExpiresAt.IsZero() {
+		u.ExpiresAt = now.Add(sessionSlidingTTL).UTC()
+	}
+	if u.LastActivity == 0 {
+		u.LastActivity = now.Unix()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store(u.KeyByUUID(), u)
+}
+
+func (s *MemoryStore) GetSessionByUUID(ctx context.Context, uuid [24]byte) (*models.Session, error) {
+	loaded := models.Session{
+		UUID: uuid,
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(loaded.KeyByUUID(), &loaded); err != nil {
+		return nil, err
+	}
+
+	// Sliding session expiration: any successful fetch extends the TTL.
+	loaded.LastActivity = now.Unix()
+	loaded.ExpiresAt = now.Add(sessionSlidingTTL).UTC()
+	if err := s.store(loaded.KeyByUUID(), &loaded); err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
+func (s *MemoryStore) PeekSessionByUUID(ctx context.Context, uuid [24]byte) (*models.Session, error) {
+	loaded := models.Session{
+		UUID: uuid,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(loaded.KeyByUUID(), &loaded); err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
+func (s *MemoryStore) UpdateSession(ctx context.Context, uuid [24]byte, mutate func(*models.Session) error) (*models.Session, error) {
+	loaded := models.Session{
+		UUID: uuid,
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(loaded.KeyByUUID(), &loaded); err != nil {
+		return nil, err
+	}
+	if err := mutate(&loaded); err != nil {
+		return nil, err
+	}
+	loaded.LastActivity = now.Unix()
+	if err := s.store(loaded.KeyByUUID(), &loaded); err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
+func (s *MemoryStore) DeleteSession(ctx context.Context, uuid [24]byte) error {
+	session := models.Session{
+		UUID: uuid,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.kv, string(session.KeyByUUID()))
+	return nil
+}
+
+func (s *MemoryStore) PutSessionInitTracker(ctx context.Context, t *models.SessionInitTracker) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store(t.Key(), t)
+}
+
+func (s *MemoryStore) GetSessionInitTracker(ctx context.Context, identityHash string) (*models.SessionInitTracker, error) {
+	t := models.SessionInitTracker{
+		IdentityHash: identityHash,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(t.Key(), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *MemoryStore) RegisterSessionInitRequest(ctx context.Context, identityHash string, now time.Time, window time.Duration, maxRequests int, trackerTTL time.Duration) (requestCount int, limited bool, retryAfter time.Duration, err error) {
+	if err := validateSessionInitLimits(window, maxRequests, trackerTTL); err != nil {
+		return 0, false, 0, err
+	}
+
+	tracker := models.SessionInitTracker{
+		IdentityHash: identityHash,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(tracker.Key(), &tracker); err != nil && err != ErrNotFound {
+		return 0, false, 0, err
+	}
+	requestCount, limited, retryAfter = countSessionInitRequest(&tracker, now, window, maxRequests, trackerTTL)
+	if err := s.store(tracker.Key(), &tracker); err != nil {
+		return 0, false, 0, err
+	}
+	return requestCount, limited, retryAfter, nil
+}
+
+func (s *MemoryStore) RegisterRecoveryAttempt(ctx context.Context, identity, username string, now time.Time, window time.Duration, maxRequests int, trackerTTL time.Duration) (limited bool, retryAfter time.Duration, err error) {
+	if err := validateRecoveryLimits(window, maxRequests); err != nil {
+		return false, 0, err
+	}
+
+	tracker := models.RecoveryTracker{
+		Username: username,
+		Identity: identity,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(tracker.Key(), &tracker); err != nil && err != ErrNotFound {
+		return false, 0, err
+	}
+	limited, retryAfter, changed := countRecoveryAttempt(&tracker, now, window, maxRequests, trackerTTL)
+	if changed {
+		if err := s.store(tracker.Key(), &tracker); err != nil {
+			return false, 0, err
+		}
+	}
+	return limited, retryAfter, nil
+}
+
+func (s *MemoryStore) RegisterRecoveryFailure(ctx context.Context, identity, username string, now time.Time, window time.Duration, maxFailures int, cooldown time.Duration) (cooling bool, err error) {
+	if err := validateRecoveryLimits(window, maxFailures); err != nil {
+		return false, err
+	}
+
+	tracker := models.RecoveryTracker{
+		Username: username,
+		Identity: identity,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(tracker.Key(), &tracker); err != nil && err != ErrNotFound {
+		return false, err
+	}
+	cooling = countRecoveryFailure(&tracker, now, window, maxFailures, cooldown)
+	if err := s.store(tracker.Key(), &tracker); err != nil {
+		return false, err
+	}
+	return cooling, nil
+}
+
+func (s *MemoryStore) PutGroup(ctx context.Context, g *models.Group, owner *models.GroupMember) error {
+	if len(g.UUID) == 0 {
+		g.UUID = make([]byte, 32)
+		if _, err := rand.Read(g.UUID); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC()
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = now
+	}
+	owner.GroupUUID = g.UUID
+	if owner.AddedAt.IsZero() {
+		owner.AddedAt = now
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.has(g.KeyByUUID()) {
+		return ErrAlreadyExists
+	}
+	if err := s.store(g.KeyByUUID(), g); err != nil {
+		return err
+	}
+	if err := s.store(owner.Key(), owner); err != nil {
+		return err
+	}
+	s.kv[string(owner.KeyByUser())] = nil
+	return nil
+}
+
+func (s *MemoryStore) GetGroupByUUID(ctx context.Context, uuid []byte) (*models.Group, error) {
+	g := models.Group{
+		UUID: uuid,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(g.KeyByUUID(), &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (s *MemoryStore) UpdateGroup(ctx context.Context, uuid []byte, mutate func(*models.Group) error) (*models.Group, error) {
+	loaded := models.Group{
+		UUID: uuid,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(loaded.KeyByUUID(), &loaded); err != nil {
+		return nil, err
+	}
+	if err := mutate(&loaded); err != nil {
+		return nil, err
+	}
+	loaded.UUID = uuid
+	if err := s.store(loaded.KeyByUUID(), &loaded); err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
+func (s *MemoryStore) DeleteGroup(ctx context.Context, uuid []byte) error {
+	g := models.Group{
+		UUID: uuid,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.has(g.KeyByUUID()) {
+		return ErrNotFound
+	}
+	prefix := keyspace.GroupMembers.Key(uuid)
+	for _, key := range s.scan(prefix, false) {
+		m := models.GroupMember{
+			GroupUUID: uuid,
+			UserUUID:  []byte(key[len(prefix):]),
+		}
+		delete(s.kv, key)
+		delete(s.kv, string(m.KeyByUser()))
+	}
+	delete(s.kv, string(g.KeyByUUID()))
+	return nil
+}
+
+func (s *MemoryStore) PutGroupMember(ctx context.Context, m *models.GroupMember) error {
+	if m.AddedAt.IsZero() {
+		m.AddedAt = time.Now().UTC()
+	}
+	g := models.Group{
+		UUID: m.GroupUUID,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.has(g.KeyByUUID()) {
+		return ErrNotFound
+	}
+	if s.has(m.Key()) {
+		return ErrAlreadyExists
+	}
+	if err := s.store(m.Key(), m); err != nil {
+		return err
+	}
+	s.kv[string(m.KeyByUser())] = nil
+	return nil
+}
+
+func (s *MemoryStore) GetGroupMember(ctx context.Context, group_uuid, user_uuid []byte) (*models.GroupMember, error) {
+	m := models.GroupMember{
+		GroupUUID: group_uuid,
+		UserUUID:  user_uuid,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(m.Key(), &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *MemoryStore) DeleteGroupMember(ctx context.Context, group_uuid, user_uuid []byte) error {
+	m := models.GroupMember{
+		GroupUUID: group_uuid,
+		UserUUID:  user_uuid,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.has(m.Key()) {
+		return ErrNotFound
+	}
+	delete(s.kv, string(m.Key()))
+	delete(s.kv, string(m.KeyByUser()))
+	return nil
+}
+
+func (s *MemoryStore) ListGroupMembers(ctx context.Context, group_uuid []byte) ([]*models.GroupMember, error) {
+	var members []*models.GroupMember
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.scan(keyspace.GroupMembers.Key(group_uuid), false) {
+		var m models.GroupMember
+		if err := codec.Unmarshal(s.kv[key], &m); err != nil {
+			return nil, err
+		}
+		members = append(members, &m)
+	}
+	return members, nil
+}
+
+func (s *MemoryStore) ListUserGroups(ctx context.Context, user_uuid []byte) ([]*models.GroupMember, []*models.Group, error) {
+	var (
+		members []*models.GroupMember
+		groups  []*models.Group
+	)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := keyspace.UserGroups.Key(user_uuid)
+	for _, key := range s.scan(prefix, false) {
+		m := models.GroupMember{
+			GroupUUID: []byte(key[len(prefix):]),
+			UserUUID:  user_uuid,
+		}
+		g := models.Group{
+			UUID: m.GroupUUID,
+		}
+		if err := s.load(m.Key(), &m); err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if err := s.load(g.KeyByUUID(), &g); err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		members = append(members, &m)
+		groups = append(groups, &g)
+	}
+	return members, groups, nil
+}
+
+func (s *MemoryStore) PutMessage(ctx context.Context, m *models.Message, nonce_expires_at time.Time) error {
+	m.UUID = make([]byte, 32)
+	if _, err := rand.Read(m.UUID); err != nil {
+		return err
+	}
+	m.CreatedAt = time.Now().UTC()
+	nonce := models.MessageNonce{
+		GroupUUID:  m.GroupUUID,
+		SenderUUID: m.SenderUUID,
+		Nonce:      m.Nonce,
+		ExpiresAt:  nonce_expires_at.UTC(),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stored models.MessageNonce
+	if err := s.load(nonce.Key(), &stored); err == nil {
+		return ErrMessageReplayed
+	} else if err != nil && err != ErrNotFound {
+		return err
+	}
+	if err := s.store(nonce.Key(), &nonce); err != nil {
+		return err
+	}
+	// The sequence key holds the same 8 bytes as in BadgerStore.
+	seq_key := string(keyspace.MessageSequence(m.GroupUUID))
+	m.Seq = 1
+	if seq, ok := s.kv[seq_key]; ok {
+		m.Seq = binary.BigEndian.Uint64(seq) + 1
+	}
+	s.kv[seq_key] = binary.BigEndian.AppendUint64(nil, m.Seq)
+	return s.store(m.Key(), m)
+}
+
+func (s *MemoryStore) ListMessages(ctx context.Context, group_uuid, before, after []byte, limit int) (messages []*models.Message, more bool, err error) {
+	if limit <= 0 {
+		return nil, false, errors.New("limit must be > 0")
+	}
+	prefix := keyspace.Messages.Key(group_uuid)
+	forward := len(after) > 0
+
+	var bound []byte
+	switch {
+	case forward:
+		bound = append(append([]byte(nil), prefix...), after...)
+	case len(before) > 0:
+		bound = append(append([]byte(nil), prefix...), before...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.scan(prefix, !forward) {
+		// Same as BadgerStore: start strictly past the cursor.
+		if bound != nil {
+			if c := bytes.Compare([]byte(key), bound); (forward && c <= 0) || (!forward && c >= 0) {
+				continue
+			}
+		}
+		if len(messages) == limit {
+			more = true
+			break
+		}
+		var m models.Message
+		if err := codec.Unmarshal(s.kv[key], &m); err != nil {
+			return nil, false, err
+		}
+		messages = append(messages, &m)
+	}
+	if !forward {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+	return messages, more, nil
+}
+
+func (s *MemoryStore) StartExpiryJanitor(ctx context.Context, sweepInterval time.Duration) {
+	runExpiryJanitor(ctx, sweepInterval, s.SweepExpired)
+}
+
+// SweepExpired scans every expiring record; a memory store is small
+// enough that it needs no expiry index.
+func (s *MemoryStore) SweepExpired(ctx context.Context, now time.Time) (stats SweepStats, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ns := range expiringNamespaces {
+		prefix := ns.Key()
+		for _, key := range s.scan(prefix, false) {
+			expires_at, err := codec.ExpiresAt(s.kv[key])
+			if err != nil || expires_at.IsZero() || !now.After(expires_at) {
+				continue
+			}
+			delete(s.kv, key)
+			if lag := now.Sub(expires_at); lag > stats.Lag {
+				stats.Lag = lag
+			}
+			stats.countRemoved(ns)
+		}
+	}
+	stats.Batches = 1
+	return stats, nil
+}
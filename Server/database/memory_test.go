@@ -0,0 +1,295 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+// eachStore runs test against a MemoryStore and a BadgerStore, so the two
+// are held to the same behaviour.
+func eachStore(t *testing.T, test func(t *testing.T, s Store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryStore())
+	})
+	t.Run("badger", func(t *testing.T) {
+		s, err := NewBadgerStore(t.TempDir(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		test(t, s)
+	})
+}
+
+func TestSessionSlidingExpiry(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		expires_at := time.Now().Add(time.Minute).UTC()
+		session := &models.Session{State: models.SessionState("test"), ExpiresAt: expires_at}
+		if err := s.PutSession(ctx, session); err != nil {
+			t.Fatal(err)
+		}
+
+		peeked, err := s.PeekSessionByUUID(ctx, session.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !peeked.ExpiresAt.Equal(expires_at) {
+			t.Fatalf("peek moved expiry to %v, want %v", peeked.ExpiresAt, expires_at)
+		}
+
+		before := time.Now()
+		loaded, err := s.GetSessionByUUID(ctx, session.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.ExpiresAt.Before(before.Add(sessionSlidingTTL)) {
+			t.Fatalf("fetch left expiry at %v, want at least %v", loaded.ExpiresAt, before.Add(sessionSlidingTTL))
+		}
+		peeked, err = s.PeekSessionByUUID(ctx, session.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !peeked.ExpiresAt.Equal(loaded.ExpiresAt) {
+			t.Fatalf("slid expiry not stored: %v, want %v", peeked.ExpiresAt, loaded.ExpiresAt)
+		}
+
+		expired := &models.Session{ExpiresAt: time.Now().Add(-time.Minute).UTC()}
+		if err := s.PutSession(ctx, expired); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.GetSessionByUUID(ctx, expired.UUID); err != ErrNotFound {
+			t.Fatalf("fetching an expired session: %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUpdateSessionKeepsExpiry(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		expires_at := time.Now().Add(time.Minute).UTC()
+		session := &models.Session{State: models.SessionState("before"), ExpiresAt: expires_at}
+		if err := s.PutSession(ctx, session); err != nil {
+			t.Fatal(err)
+		}
+
+		updated, err := s.UpdateSession(ctx, session.UUID, func(s *models.Session) error {
+			s.State = models.SessionState("after")
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.State != "after" || !updated.ExpiresAt.Equal(expires_at) {
+			t.Fatalf("update returned state %q expiry %v, want %q %v", updated.State, updated.ExpiresAt, "after", expires_at)
+		}
+		peeked, err := s.PeekSessionByUUID(ctx, session.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if peeked.State != "after" || !peeked.ExpiresAt.Equal(expires_at) {
+			t.Fatalf("stored state %q expiry %v, want %q %v", peeked.State, peeked.ExpiresAt, "after", expires_at)
+		}
+
+		refused := errors.New("refused")
+		if _, err := s.UpdateSession(ctx, session.UUID, func(*models.Session) error { return refused }); err != refused {
+			t.Fatalf("failing mutate: %v, want %v", err, refused)
+		}
+	})
+}
+
+func TestListMessagesCursors(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		group_uuid := bytes.Repeat([]byte{1}, 32)
+		var cursors [][]byte
+		for i := 0; i < 5; i++ {
+			m := &models.Message{
+				GroupUUID:  group_uuid,
+				SenderUUID: []byte("sender"),
+				Payload:    []byte(fmt.Sprint(i)),
+				Nonce:      []byte(fmt.Sprintf("nonce-%d", i)),
+			}
+			if err := s.PutMessage(ctx, m, time.Now().Add(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			if m.Seq != uint64(i+1) {
+				t.Fatalf("message %d stored as seq %d", i, m.Seq)
+			}
+			cursors = append(cursors, m.Cursor())
+		}
+
+		cases := []struct {
+			name          string
+			before, after []byte
+			limit         int
+			want          [][]byte
+			more          bool
+		}{
+			{"newest", nil, nil, 2, cursors[3:], true},
+			{"all", nil, nil, 5, cursors, false},
+			{"before", cursors[3], nil, 2, cursors[1:3], true},
+			{"before to start", cursors[2], nil, 5, cursors[:2], false},
+			{"after", nil, cursors[1], 2, cursors[2:4], true},
+			{"after to end", nil, cursors[2], 5, cursors[3:], false},
+			{"after newest", nil, cursors[4], 5, nil, false},
+			{"before oldest", cursors[0], nil, 5, nil, false},
+		}
+		for _, tc := range cases {
+			messages, more, err := s.ListMessages(ctx, group_uuid, tc.before, tc.after, tc.limit)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			var got [][]byte
+			for _, m := range messages {
+				got = append(got, m.Cursor())
+			}
+			if len(got) != len(tc.want) || more != tc.more {
+				t.Fatalf("%s: got %d messages more=%v, want %d more=%v", tc.name, len(got), more, len(tc.want), tc.more)
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], tc.want[i]) {
+					t.Fatalf("%s: message %d is %x, want %x", tc.name, i, got[i], tc.want[i])
+				}
+			}
+		}
+
+		if _, _, err := s.ListMessages(ctx, group_uuid, nil, nil, 0); err == nil {
+			t.Fatal("limit 0 accepted")
+		}
+		if messages, _, err := s.ListMessages(ctx, bytes.Repeat([]byte{2}, 32), nil, nil, 5); err != nil || len(messages) != 0 {
+			t.Fatalf("other group: %d messages, %v", len(messages), err)
+		}
+	})
+}
+
+func TestPutMessageConcurrent(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		group_uuid := bytes.Repeat([]byte{1}, 32)
+		const n = 100
+		var wg sync.WaitGroup
+		errs := make(chan error, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m := &models.Message{GroupUUID: group_uuid, SenderUUID: []byte("sender"), Nonce: []byte(fmt.Sprintf("nonce-%d", i))}
+				errs <- s.PutMessage(ctx, m, time.Now().Add(time.Minute))
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		messages, more, err := s.ListMessages(ctx, group_uuid, nil, nil, n)
+		if err != nil || more || len(messages) != n {
+			t.Fatalf("listed %d messages more=%v: %v", len(messages), more, err)
+		}
+		for i, m := range messages {
+			if m.Seq != uint64(i+1) {
+				t.Fatalf("message %d has seq %d", i, m.Seq)
+			}
+		}
+	})
+}
+
+func TestSweepExpired(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		now := time.Now().UTC()
+		for _, expires_at := range []time.Time{now.Add(-time.Minute), now.Add(-time.Second), now.Add(time.Hour)} {
+			if err := s.PutSession(ctx, &models.Session{ExpiresAt: expires_at}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		live := &models.SessionInitTracker{IdentityHash: "live", RequestUnixTS: []int64{now.Unix()}, ExpiresAt: now.Add(time.Hour)}
+		expired := &models.SessionInitTracker{IdentityHash: "expired", RequestUnixTS: []int64{now.Unix()}, ExpiresAt: now.Add(-time.Minute)}
+		for _, tracker := range []*models.SessionInitTracker{live, expired} {
+			if err := s.PutSessionInitTracker(ctx, tracker); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		message := &models.Message{GroupUUID: []byte("group"), SenderUUID: []byte("sender"), Nonce: []byte("nonce")}
+		if err := s.PutMessage(ctx, message, now.Add(-time.Second)); err != nil {
+			t.Fatal(err)
+		}
+
+		// Expired records are hidden before any sweep removes them.
+		if _, err := s.GetSessionInitTracker(ctx, "expired"); err != ErrNotFound {
+			t.Fatalf("expired tracker before sweep: %v, want ErrNotFound", err)
+		}
+
+		stats, err := s.SweepExpired(ctx, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int{"sessions": 2, "session_init_trackers": 1, "message_nonces": 1}; !reflect.DeepEqual(stats.Removed, want) {
+			t.Fatalf("swept %v, want %v", stats.Removed, want)
+		}
+		// The expiry index keeps whole milliseconds.
+		if stats.Lag < time.Minute-time.Millisecond {
+			t.Fatalf("lag %v, want about a minute", stats.Lag)
+		}
+		if _, err := s.GetSessionInitTracker(ctx, "live"); err != nil {
+			t.Fatalf("live tracker after sweep: %v", err)
+		}
+
+		stats, err = s.SweepExpired(ctx, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.TotalRemoved() != 0 {
+			t.Fatalf("second sweep removed %s, want none", stats.RemovedText())
+		}
+	})
+}
+
+func TestExpiredTrackersRestart(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		now := time.Now().UTC()
+		stale := &models.SessionInitTracker{
+			IdentityHash:  "client",
+			RequestUnixTS: []int64{now.Unix(), now.Unix(), now.Unix()},
+			ExpiresAt:     now.Add(-time.Second),
+		}
+		if err := s.PutSessionInitTracker(ctx, stale); err != nil {
+			t.Fatal(err)
+		}
+		count, limited, _, err := s.RegisterSessionInitRequest(ctx, "client", now, time.Minute, 3, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 || limited {
+			t.Fatalf("request after the tracker expired: count=%d limited=%v, want 1 false", count, limited)
+		}
+
+		nonce_expires_at := now.Add(-time.Second)
+		first := &models.Message{GroupUUID: []byte("group"), SenderUUID: []byte("sender"), Nonce: []byte("nonce")}
+		if err := s.PutMessage(ctx, first, nonce_expires_at); err != nil {
+			t.Fatal(err)
+		}
+		again := &models.Message{GroupUUID: []byte("group"), SenderUUID: []byte("sender"), Nonce: []byte("nonce")}
+		if err := s.PutMessage(ctx, again, now.Add(time.Minute)); err != nil {
+			t.Fatalf("nonce reused after it expired: %v", err)
+		}
+		replay := &models.Message{GroupUUID: []byte("group"), SenderUUID: []byte("sender"), Nonce: []byte("nonce")}
+		if err := s.PutMessage(ctx, replay, now.Add(time.Minute)); !errors.Is(err, ErrMessageReplayed) {
+			t.Fatalf("nonce reused while live: %v, want ErrMessageReplayed", err)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/metrics"
+)
+
+var (
+	janitorSweepDuration = metrics.NewHistogram("umbra_janitor_sweep_duration_seconds",
+		"Duration of expiry janitor sweeps.", metrics.DurationBuckets)
+	janitorSweepErrors = metrics.NewCounter("umbra_janitor_sweep_errors_total",
+		"Expiry janitor sweeps that failed.")
+	janitorRemoved = metrics.NewCounterVec("umbra_janitor_removed_total",
+		"Expired records removed by the janitor by keyspace namespace.", "namespace")
+	janitorBatches = metrics.NewCounter("umbra_janitor_batches_total",
+		"Bounded transactions used by janitor sweeps.")
+	janitorLag = metrics.NewGauge("umbra_janitor_lag_seconds",
+		"How long the oldest record removed by the last sweep had been expired.")
+)
+
+// measuredSweep runs sweep and records its duration and stats.
+func measuredSweep(ctx context.Context, now time.Time, sweep func(context.Context, time.Time) (SweepStats, error)) (SweepStats, error) {
+	start := time.Now()
+	stats, err := sweep(ctx, now)
+	janitorSweepDuration.ObserveSince(start)
+	if err != nil {
+		janitorSweepErrors.Inc()
+		return stats, err
+	}
+	for name, n := range stats.Removed {
+		janitorRemoved.With(name).Add(uint64(n))
+	}
+	janitorBatches.Add(uint64(stats.Batches))
+	janitorLag.Set(stats.Lag.Seconds())
+	janitorHeartbeat.Store(time.Now().UnixNano())
+	return stats, nil
+}
+
+// ExportSizeMetrics publishes the on-disk size of the LSM tree and the value
+// log. Badger refreshes these figures about once a minute. Call it once per
+// process.
+func (s *BadgerStore) ExportSizeMetrics() {
+	metrics.NewGaugeFunc("umbra_badger_lsm_bytes", "Size of Badger's LSM tree on disk.", func() float64 {
+		lsm, _ := s.db.Size()
+		return float64(lsm)
+	})
+	metrics.NewGaugeFunc("umbra_badger_vlog_bytes", "Size of Badger's value log on disk.", func() float64 {
+		_, vlog := s.db.Size()
+		return float64(vlog)
+	})
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// recordNamespaces maps every namespace holding codec records to a fresh
+// value of its model.
+var recordNamespaces = []struct {
+	ns  *keyspace.Namespace
+	new func() any
+}{
+	{keyspace.Users, func() any { return &models.User{} }},
+	{keyspace.Sessions, func() any { return &models.Session{} }},
+	{keyspace.SessionInitTrackers, func() any { return &models.SessionInitTracker{} }},
+	{keyspace.RecoveryTrackers, func() any { return &models.RecoveryTracker{} }},
+	{keyspace.Groups, func() any { return &models.Group{} }},
+	{keyspace.GroupMembers, func() any { return &models.GroupMember{} }},
+	{keyspace.Messages, func() any { return &models.Message{} }},
+}
+
+// encodeLegacyRecords rewrites JSON records in the binary codec. Decoding
+// through the current models fills fields added since the record was written
+// with their zero value, which every model treats as the legacy default.
+// Already encoded records are skipped, so the step is safe to repeat.
+func encodeLegacyRecords(db *badger.DB, w *Writer) error {
+	return db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for _, records := range recordNamespaces {
+			prefix := records.ns.Key()
+			for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+				item := it.Item()
+				val, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				if !codec.IsLegacy(val) {
+					continue
+				}
+				record := records.new()
+				if err := codec.Unmarshal(val, record); err != nil {
+					return err
+				}
+				encoded, err := codec.Marshal(record)
+				if err != nil {
+					return err
+				}
+				entry := badger.NewEntry(item.KeyCopy(nil), encoded)
+				entry.ExpiresAt = item.ExpiresAt()
+				if err := w.SetEntry(entry); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// legacyExpiryIndexKey marked a finished backfill before this step existed.
+var legacyExpiryIndexKey = []byte{0x00, 'e', 'x', 'p', 'i', 'r', 'y'}
+
+// backfillExpiryIndex gives sessions and trackers written before the expiry
+// index their Badger TTL and index entry. Rewriting an already indexed record
+// produces the same entries, so the step is safe to repeat.
+func backfillExpiryIndex(db *badger.DB, w *Writer) error {
+	err := db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for _, prefix := range [][]byte{keyspace.Sessions.Key(), keyspace.SessionInitTrackers.Key(), keyspace.RecoveryTrackers.Key()} {
+			for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+				item := it.Item()
+				val, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				expires_at, err := codec.ExpiresAt(val)
+				if err != nil || expires_at.IsZero() {
+					continue
+				}
+				key := item.KeyCopy(nil)
+				entry := badger.NewEntry(key, val)
+				// The second after expires_at, as the store's own TTLs: Badger
+				// must not hide a record before the store would reject it.
+				entry.ExpiresAt = uint64(expires_at.Unix()) + 1
+				if err := w.SetEntry(entry); err != nil {
+					return err
+				}
+				if err := w.Set(keyspace.Expiry(expires_at, key), nil); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return w.Delete(legacyExpiryIndexKey)
+}
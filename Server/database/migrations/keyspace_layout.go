@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// legacyNamespace maps a key written before the keyspace registry to its
+// namespace. Users and sessions shared prefix 0x10 back then and only the key
+// length tells them apart: 24-byte session UUIDs against 32-byte user UUIDs.
+func legacyNamespace(key []byte) *keyspace.Namespace {
+	switch key[0] {
+	case 0x10:
+		switch len(key) {
+		case 1 + 24:
+			return keyspace.Sessions
+		case 1 + 32:
+			return keyspace.Users
+		}
+	case 0x11:
+		return keyspace.Usernames
+	case 0x12:
+		return keyspace.SessionInitTrackers
+	case 0x13:
+		return keyspace.RecoveryTrackers
+	case 0x14:
+		return keyspace.Groups
+	case 0x15:
+		return keyspace.GroupMembers
+	case 0x16:
+		return keyspace.UserGroups
+	case 0x17:
+		return keyspace.Messages
+	}
+	return nil
+}
+
+// migrateKeyspaceLayout moves keys of the unversioned layout into the registry
+// layout and records keyspace.SchemaVersion under keyspace.SchemaKey. Moved
+// keys are gone from the legacy range, so a second run finds nothing to do.
+func migrateKeyspaceLayout(db *badger.DB, w *Writer) error {
+	err := db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Seek([]byte{0x10}); it.Valid(); it.Next() {
+			item := it.Item()
+			key := item.KeyCopy(nil)
+			if key[0] > 0x17 {
+				break
+			}
+			ns := legacyNamespace(key)
+			if ns == nil {
+				continue
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if err := w.Set(ns.Key(key[1:]), val); err != nil {
+				return err
+			}
+			if err := w.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return w.Set(keyspace.SchemaKey, []byte{keyspace.SchemaVersion})
+}
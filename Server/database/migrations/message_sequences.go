@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// numberMessages moves messages keyed by creation millisecond and UUID to
+// keys by their group's sequence number, numbering each group in the order
+// its old keys sort, and records every group's last number. Old keys carry a
+// millisecond timestamp, so they sort after any sequence number of the same
+// group: a repeated run first sees what it already moved and numbers the
+// rest after it.
+func numberMessages(db *badger.DB, w *Writer) error {
+	last := make(map[string]uint64)
+	moved := make(map[string]bool)
+	err := db.View(func(txn *badger.Txn) error {
+		prefix := keyspace.Messages.Key()
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, Prefix: prefix})
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			var m models.Message
+			if err := item.Value(func(val []byte) error {
+				return codec.Unmarshal(val, &m)
+			}); err != nil {
+				return err
+			}
+			group := string(m.GroupUUID)
+			if m.Seq != 0 && bytes.Equal(item.Key(), m.Key()) {
+				last[group] = max(last[group], m.Seq)
+				continue
+			}
+
+			last[group]++
+			m.Seq = last[group]
+			moved[group] = true
+			encoded, err := codec.Marshal(&m)
+			if err != nil {
+				return err
+			}
+			if err := w.Set(m.Key(), encoded); err != nil {
+				return err
+			}
+			if err := w.Delete(item.KeyCopy(nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for group := range moved {
+		if err := w.Set(keyspace.MessageSequence([]byte(group)), binary.BigEndian.AppendUint64(nil, last[group])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestNumberMessages(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Three messages under the old keys, creation millisecond then UUID,
+	// stored out of order.
+	group_uuid := bytes.Repeat([]byte{1}, 32)
+	t0 := time.UnixMilli(1_700_000_000_000).UTC()
+	for _, i := range []int{2, 0, 1} {
+		m := models.Message{UUID: bytes.Repeat([]byte{byte(i)}, 32), GroupUUID: group_uuid, Payload: []byte{byte(i)}, CreatedAt: t0.Add(time.Duration(i) * time.Millisecond)}
+		val, err := codec.Marshal(&m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		old_cursor := binary.BigEndian.AppendUint64(nil, uint64(m.CreatedAt.UnixMilli()))
+		if err := db.Update(func(txn *badger.Txn) error {
+			return txn.Set(keyspace.Message(group_uuid, append(old_cursor, m.UUID...)), val)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Three moves and the group's sequence, then nothing left on a rerun.
+	for run, want_writes := range []int{4, 0} {
+		w := newWriter(db, false)
+		if err := numberMessages(db, w); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.flush(); err != nil {
+			t.Fatal(err)
+		}
+		if w.writes != want_writes {
+			t.Fatalf("run %d: %d writes, want %d", run, w.writes, want_writes)
+		}
+	}
+
+	err = db.View(func(txn *badger.Txn) error {
+		prefix := keyspace.Messages.Key(group_uuid)
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer it.Close()
+		n := 0
+		for it.Rewind(); it.Valid(); it.Next() {
+			var m models.Message
+			if err := it.Item().Value(func(val []byte) error {
+				return codec.Unmarshal(val, &m)
+			}); err != nil {
+				return err
+			}
+			if m.Seq != uint64(n+1) || m.Payload[0] != byte(n) || !bytes.Equal(it.Item().Key(), m.Key()) {
+				t.Fatalf("message %d: seq %d payload %d under %x", n, m.Seq, m.Payload[0], it.Item().Key())
+			}
+			n++
+		}
+		if n != 3 {
+			t.Fatalf("%d messages after the move, want 3", n)
+		}
+
+		item, err := txn.Get(keyspace.MessageSequence(group_uuid))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			if binary.BigEndian.Uint64(val) != 3 {
+				t.Fatalf("group sequence at %d, want 3", binary.BigEndian.Uint64(val))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,205 @@
+// Package migrations upgrades a Badger data directory to the layout and
+// record format this server writes. Steps run in order, each at most once per
+// directory, and the last one applied is recorded under MetaKey.
+//
+// Every step must be idempotent: the version is only recorded after the
+// step's writes are flushed, so a crash in between runs the step again.
+package migrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+	"github.com/dgraph-io/badger/v4"
+)
+
+var ErrTooNew = errors.New("data directory was written by a newer server")
+
+// MetaKey holds the migration state of the whole data directory as JSON. It
+// starts with 0x00 so it can never be mistaken for a versioned key.
+var MetaKey = []byte{0x00, 'm', 'e', 't', 'a'}
+
+// Step is one migration. Version numbers start at 1 and have no gaps.
+type Step struct {
+	Version int
+	Name    string
+	Apply   func(db *badger.DB, w *Writer) error
+}
+
+// Meta is what MetaKey holds.
+type Meta struct {
+	Version int       `json:"version"`
+	Applied []Applied `json:"applied"`
+}
+
+type Applied struct {
+	Version int       `json:"version"`
+	Name    string    `json:"name"`
+	At      time.Time `json:"at"`
+}
+
+// Report describes one pending step: what it changed, or in a dry run what it
+// would have changed.
+type Report struct {
+	Version int
+	Name    string
+	Writes  int
+	Deletes int
+}
+
+type Options struct {
+	// DryRun runs every pending step without writing anything. Each step
+	// then sees the directory as it is, so a step's counts assume the pending
+	// steps before it had nothing to do.
+	DryRun bool
+}
+
+// Latest is the version a fully migrated directory is at.
+func Latest() int {
+	return len(steps)
+}
+
+// ReadMeta returns the migration state of db. A directory without MetaKey is
+// at version 0.
+func ReadMeta(db *badger.DB) (Meta, error) {
+	var meta Meta
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(MetaKey)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &meta)
+		})
+	})
+	if err == badger.ErrKeyNotFound {
+		return Meta{}, nil
+	}
+	return meta, err
+}
+
+// Run applies the steps db has not seen yet and reports on each. It refuses
+// a directory migrated past Latest, or whose key layout is newer than
+// keyspace.SchemaVersion.
+func Run(db *badger.DB, opts Options) ([]Report, error) {
+	meta, err := ReadMeta(db)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Version > Latest() {
+		return nil, fmt.Errorf("%w: migration version %d, this server knows up to %d", ErrTooNew, meta.Version, Latest())
+	}
+	if err := checkSchemaVersion(db); err != nil {
+		return nil, err
+	}
+
+	var reports []Report
+	for _, step := range steps[meta.Version:] {
+		w := newWriter(db, opts.DryRun)
+		err := step.Apply(db, w)
+		if err == nil {
+			err = w.flush()
+		} else {
+			w.cancel()
+		}
+		if err != nil {
+			return reports, fmt.Errorf("migration %d (%s): %w", step.Version, step.Name, err)
+		}
+		reports = append(reports, Report{Version: step.Version, Name: step.Name, Writes: w.writes, Deletes: w.deletes})
+		if opts.DryRun {
+			continue
+		}
+
+		meta.Version = step.Version
+		meta.Applied = append(meta.Applied, Applied{Version: step.Version, Name: step.Name, At: time.Now().UTC()})
+		encoded, err := json.Marshal(&meta)
+		if err != nil {
+			return reports, err
+		}
+		if err := db.Update(func(txn *badger.Txn) error {
+			return txn.Set(MetaKey, encoded)
+		}); err != nil {
+			return reports, err
+		}
+	}
+	return reports, nil
+}
+
+// checkSchemaVersion also guards directories from before MetaKey existed,
+// which only recorded their key layout.
+func checkSchemaVersion(db *badger.DB) error {
+	var version byte
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(keyspace.SchemaKey)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			if len(val) != 1 {
+				return fmt.Errorf("invalid schema version value of %d bytes", len(val))
+			}
+			version = val[0]
+			return nil
+		})
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if version > keyspace.SchemaVersion {
+		return fmt.Errorf("%w: schema version %d, this server knows up to %d", ErrTooNew, version, keyspace.SchemaVersion)
+	}
+	return nil
+}
+
+// Writer batches the writes of one step, or only counts them in a dry run.
+type Writer struct {
+	batch   *badger.WriteBatch
+	writes  int
+	deletes int
+}
+
+func newWriter(db *badger.DB, dry_run bool) *Writer {
+	w := &Writer{}
+	if !dry_run {
+		w.batch = db.NewWriteBatch()
+	}
+	return w
+}
+
+func (w *Writer) Set(key, val []byte) error {
+	return w.SetEntry(badger.NewEntry(key, val))
+}
+
+func (w *Writer) SetEntry(e *badger.Entry) error {
+	w.writes++
+	if w.batch == nil {
+		return nil
+	}
+	return w.batch.SetEntry(e)
+}
+
+func (w *Writer) Delete(key []byte) error {
+	w.deletes++
+	if w.batch == nil {
+		return nil
+	}
+	return w.batch.Delete(key)
+}
+
+func (w *Writer) flush() error {
+	if w.batch == nil {
+		return nil
+	}
+	return w.batch.Flush()
+}
+
+func (w *Writer) cancel() {
+	if w.batch != nil {
+		w.batch.Cancel()
+	}
+}
@@ -0,0 +1,10 @@
+package migrations
+
+// steps is every migration in the order it runs. Append only: a released
+// step is never edited, renumbered or removed.
+var steps = []Step{
+	{Version: 1, Name: "keyspace-layout", Apply: migrateKeyspaceLayout},
+	{Version: 2, Name: "expiry-index", Apply: backfillExpiryIndex},
+	{Version: 3, Name: "binary-records", Apply: encodeLegacyRecords},
+	{Version: 4, Name: "message-sequences", Apply: numberMessages},
+}
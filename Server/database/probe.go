@@ -0,0 +1,64 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// probeKey is what Probe writes and reads back. Like keyspace.SchemaKey it
+// starts with 0x00, outside every namespace, and it expires on its own.
+var probeKey = []byte{0x00, 'p', 'r', 'o', 'b', 'e'}
+
+const probeTTL = 1 * time.Minute
+
+var errProbeMismatch = errors.New("probe read back a different value than it wrote")
+
+func newProbeValue() ([]byte, error) {
+	val := make([]byte, 16)
+	_, err := rand.Read(val)
+	return val, err
+}
+
+func (s *BadgerStore) Probe(ctx context.Context) error {
+	val, err := newProbeValue()
+	if err != nil {
+		return err
+	}
+	if err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(probeKey, val).WithTTL(probeTTL))
+	}); err != nil {
+		return err
+	}
+	return s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(probeKey)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(got []byte) error {
+			if !bytes.Equal(got, val) {
+				return errProbeMismatch
+			}
+			return nil
+		})
+	})
+}
+
+func (s *MemoryStore) Probe(ctx context.Context) error {
+	val, err := newProbeValue()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kv[string(probeKey)] = val
+	defer delete(s.kv, string(probeKey))
+	if !bytes.Equal(s.kv[string(probeKey)], val) {
+		return errProbeMismatch
+	}
+	return nil
+}
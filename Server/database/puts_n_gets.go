@@ -1,12 +1,16 @@
 package database
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
-	"encoding/json"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
 	"github.com/MHSarmadi/Umbra/Server/models"
 	"github.com/dgraph-io/badger/v4"
 )
@@ -14,6 +18,8 @@ import (
 var ErrNotFound = errors.New("not found")
 var ErrAlreadyExists = errors.New("already exists")
 var ErrUsernameRequired = errors.New("username required")
+var ErrUsernameTaken = fmt.Errorf("username taken: %w", ErrAlreadyExists)
+var ErrMessageReplayed = fmt.Errorf("message nonce already used: %w", ErrAlreadyExists)
 
 func (s *BadgerStore) PutUser(ctx context.Context, u *models.User) error {
 	if u.Username == "" {
@@ -28,13 +34,13 @@ func (s *BadgerStore) PutUser(ctx context.Context, u *models.User) error {
 	if u.CreatedAt.IsZero() {
 		u.CreatedAt = time.Now().UTC()
 	}
-	val, err := json.Marshal(u)
+	val, err := codec.Marshal(u)
 	if err != nil {
 		return err
 	}
 	err = s.db.Update(func(txn *badger.Txn) error {
 		if _, err := txn.Get(u.KeyByUsername()); err == nil {
-			return ErrAlreadyExists
+			return ErrUsernameTaken
 		} else if err != badger.ErrKeyNotFound {
 			return err
 		}
@@ -46,6 +52,11 @@ func (s *BadgerStore) PutUser(ctx context.Context, u *models.User) error {
 		}
 		return nil
 	})
+	if errors.Is(err, badger.ErrConflict) {
+		// Only the username key is read, so a conflict means a concurrent
+		// registration claimed the same username first.
+		return ErrUsernameTaken
+	}
 	return err
 }
 
@@ -59,7 +70,7 @@ func (s *BadgerStore) GetUserByUUID(ctx context.Context, uuid []byte) (*models.U
 			return err
 		}
 		return item.Value(func(val []byte) error {
-			return json.Unmarshal(val, &u)
+			return codec.Unmarshal(val, &u)
 		})
 	})
 	if err == badger.ErrKeyNotFound {
@@ -94,9 +105,45 @@ func (s *BadgerStore) GetUserByUsername(ctx context.Context, username string) (*
 	return s.GetUserByUUID(ctx, u.UUID)
 }
 
+// UpdateUser loads, mutates and stores a user in a single transaction. The
+// username index is left alone, so mutate must not rename the user.
+func (s *BadgerStore) UpdateUser(ctx context.Context, uuid []byte, mutate func(*models.User) error) (*models.User, error) {
+	loaded := models.User{
+		UUID: uuid,
+	}
+	err := s.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(loaded.KeyByUUID())
+		if err != nil {
+			return err
+		}
+		if err := item.Value(func(val []byte) error {
+			return codec.Unmarshal(val, &loaded)
+		}); err != nil {
+			return err
+		}
+
+		username := loaded.Username
+		if err := mutate(&loaded); err != nil {
+			return err
+		}
+		loaded.Username = username
+		updated, err := codec.Marshal(&loaded)
+		if err != nil {
+			return err
+		}
+		return txn.Set(loaded.KeyByUUID(), updated)
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
 func (s *BadgerStore) PutSession(ctx context.Context, u *models.Session) error {
-	if len(u.UUID) == 0 {
-		u.UUID = [24]byte(make([]byte, 24))
+	if u.UUID == [24]byte{} {
 		if _, err := rand.Read(u.UUID[:]); err != nil {
 			return err
 		}
@@ -106,22 +153,18 @@ func (s *BadgerStore) PutSession(ctx context.Context, u *models.Session) error {
 		u.CreatedAt = now
 	}
 	if u.ExpiresAt.IsZero() {
-		u.ExpiresAt = now.Add(5 * time.Minute).UTC()
+		u.ExpiresAt = now.Add(sessionSlidingTTL).UTC()
 	}
 	if u.LastActivity == 0 {
 		u.LastActivity = now.Unix()
 	}
-	val, err := json.Marshal(u)
+	val, err := codec.Marshal(u)
 	if err != nil {
 		return err
 	}
-	err = s.db.Update(func(txn *badger.Txn) error {
-		if err := txn.Set(u.KeyByUUID(), val); err != nil {
-			return err
-		}
-		return nil
+	return s.db.Update(func(txn *badger.Txn) error {
+		return setExpiring(txn, u.KeyByUUID(), val, u.ExpiresAt)
 	})
-	return err
 }
 
 func (s *BadgerStore) GetSessionByUUID(ctx context.Context, uuid [24]byte) (*models.Session, error) {
@@ -134,27 +177,98 @@ func (s *BadgerStore) GetSessionByUUID(ctx context.Context, uuid [24]byte) (*mod
 		if err != nil {
 			return err
 		}
-		return item.Value(func(val []byte) error {
-			if err := json.Unmarshal(val, &loaded); err != nil {
+		if err := item.Value(func(val []byte) error {
+			return codec.Unmarshal(val, &loaded)
+		}); err != nil {
+			return err
+		}
+
+		if !loaded.ExpiresAt.IsZero() && now.After(loaded.ExpiresAt) {
+			if err := deleteExpiring(txn, loaded.KeyByUUID()); err != nil {
 				return err
 			}
+			return badger.ErrKeyNotFound
+		}
 
-			if !loaded.ExpiresAt.IsZero() && now.After(loaded.ExpiresAt) {
-				if err := txn.Delete(loaded.KeyByUUID()); err != nil {
-					return err
-				}
-				return badger.ErrKeyNotFound
-			}
+		// Sliding session expiration: any successful fetch extends the TTL.
+		loaded.LastActivity = now.Unix()
+		loaded.ExpiresAt = now.Add(sessionSlidingTTL).UTC()
+		updated, err := codec.Marshal(&loaded)
+		if err != nil {
+			return err
+		}
+		return setExpiring(txn, loaded.KeyByUUID(), updated, loaded.ExpiresAt)
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
+// PeekSessionByUUID loads a session without sliding its expiration, for
+// background checks that must not keep an idle session alive.
+func (s *BadgerStore) PeekSessionByUUID(ctx context.Context, uuid [24]byte) (*models.Session, error) {
+	loaded := models.Session{
+		UUID: uuid,
+	}
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(loaded.KeyByUUID())
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return codec.Unmarshal(val, &loaded)
+		})
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !loaded.ExpiresAt.IsZero() && time.Now().UTC().After(loaded.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return &loaded, nil
+}
+
+// UpdateSession loads the session, applies mutate and persists the result in a
+// single transaction. An error returned by mutate aborts the update untouched.
+func (s *BadgerStore) UpdateSession(ctx context.Context, uuid [24]byte, mutate func(*models.Session) error) (*models.Session, error) {
+	loaded := models.Session{
+		UUID: uuid,
+	}
+	now := time.Now().UTC()
+	err := s.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(loaded.KeyByUUID())
+		if err != nil {
+			return err
+		}
+		if err := item.Value(func(val []byte) error {
+			return codec.Unmarshal(val, &loaded)
+		}); err != nil {
+			return err
+		}
 
-			// Sliding session expiration: any successful fetch extends the TTL.
-			loaded.LastActivity = now.Unix()
-			loaded.ExpiresAt = now.Add(5 * time.Minute).UTC()
-			updated, err := json.Marshal(&loaded)
-			if err != nil {
+		if !loaded.ExpiresAt.IsZero() && now.After(loaded.ExpiresAt) {
+			if err := deleteExpiring(txn, loaded.KeyByUUID()); err != nil {
 				return err
 			}
-			return txn.Set(loaded.KeyByUUID(), updated)
-		})
+			return badger.ErrKeyNotFound
+		}
+
+		if err := mutate(&loaded); err != nil {
+			return err
+		}
+		loaded.LastActivity = now.Unix()
+		updated, err := codec.Marshal(&loaded)
+		if err != nil {
+			return err
+		}
+		return setExpiring(txn, loaded.KeyByUUID(), updated, loaded.ExpiresAt)
 	})
 	if err == badger.ErrKeyNotFound {
 		return nil, ErrNotFound
@@ -165,18 +279,23 @@ func (s *BadgerStore) GetSessionByUUID(ctx context.Context, uuid [24]byte) (*mod
 	return &loaded, nil
 }
 
+func (s *BadgerStore) DeleteSession(ctx context.Context, uuid [24]byte) error {
+	session := models.Session{
+		UUID: uuid,
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return deleteExpiring(txn, session.KeyByUUID())
+	})
+}
+
 func (s *BadgerStore) PutSessionInitTracker(ctx context.Context, t *models.SessionInitTracker) error {
-	val, err := json.Marshal(t)
+	val, err := codec.Marshal(t)
 	if err != nil {
 		return err
 	}
-	err = s.db.Update(func(txn *badger.Txn) error {
-		if err := txn.Set(t.Key(), val); err != nil {
-			return err
-		}
-		return nil
+	return s.db.Update(func(txn *badger.Txn) error {
+		return setExpiring(txn, t.Key(), val, t.ExpiresAt)
 	})
-	return err
 }
 
 func (s *BadgerStore) GetSessionInitTracker(ctx context.Context, identityHash string) (*models.SessionInitTracker, error) {
@@ -184,12 +303,162 @@ func (s *BadgerStore) GetSessionInitTracker(ctx context.Context, identityHash st
 		IdentityHash: identityHash,
 	}
 	err := s.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(t.Key())
+		return getLive(txn, t.Key(), &t)
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *BadgerStore) RegisterSessionInitRequest(ctx context.Context, identityHash string, now time.Time, window time.Duration, maxRequests int, trackerTTL time.Duration) (requestCount int, limited bool, retryAfter time.Duration, err error) {
+	if err := validateSessionInitLimits(window, maxRequests, trackerTTL); err != nil {
+		return 0, false, 0, err
+	}
+
+	tracker := models.SessionInitTracker{
+		IdentityHash: identityHash,
+	}
+
+	err = s.db.Update(func(txn *badger.Txn) error {
+		if err := getLive(txn, tracker.Key(), &tracker); err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		requestCount, limited, retryAfter = countSessionInitRequest(&tracker, now, window, maxRequests, trackerTTL)
+
+		encoded, err := codec.Marshal(&tracker)
+		if err != nil {
+			return err
+		}
+		return setExpiring(txn, tracker.Key(), encoded, tracker.ExpiresAt)
+	})
+	if err != nil {
+		return 0, false, 0, err
+	}
+	return requestCount, limited, retryAfter, nil
+}
+
+// RegisterRecoveryAttempt counts a recovery attempt by identity on username
+// in a sliding window. It is limited while the identity is cooling down after
+// failed proofs or once maxRequests attempts fall inside the window.
+func (s *BadgerStore) RegisterRecoveryAttempt(ctx context.Context, identity, username string, now time.Time, window time.Duration, maxRequests int, trackerTTL time.Duration) (limited bool, retryAfter time.Duration, err error) {
+	if err := validateRecoveryLimits(window, maxRequests); err != nil {
+		return false, 0, err
+	}
+
+	tracker := models.RecoveryTracker{
+		Username: username,
+		Identity: identity,
+	}
+	err = s.updateRecoveryTracker(&tracker, func() bool {
+		var changed bool
+		limited, retryAfter, changed = countRecoveryAttempt(&tracker, now, window, maxRequests, trackerTTL)
+		return changed
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return limited, retryAfter, nil
+}
+
+// RegisterRecoveryFailure counts a failed recovery proof by identity on
+// username. Once maxFailures fall inside window, the identity cannot attempt
+// a recovery of username again until now+cooldown.
+func (s *BadgerStore) RegisterRecoveryFailure(ctx context.Context, identity, username string, now time.Time, window time.Duration, maxFailures int, cooldown time.Duration) (cooling bool, err error) {
+	if err := validateRecoveryLimits(window, maxFailures); err != nil {
+		return false, err
+	}
+
+	tracker := models.RecoveryTracker{
+		Username: username,
+		Identity: identity,
+	}
+	err = s.updateRecoveryTracker(&tracker, func() bool {
+		cooling = countRecoveryFailure(&tracker, now, window, maxFailures, cooldown)
+		return true
+	})
+	if err != nil {
+		return false, err
+	}
+	return cooling, nil
+}
+
+// updateRecoveryTracker loads tracker, if stored, and stores it again when
+// count reports a change.
+func (s *BadgerStore) updateRecoveryTracker(tracker *models.RecoveryTracker, count func() (changed bool)) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		if err := getLive(txn, tracker.Key(), tracker); err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		if !count() {
+			return nil
+		}
+
+		encoded, err := codec.Marshal(tracker)
+		if err != nil {
+			return err
+		}
+		return setExpiring(txn, tracker.Key(), encoded, tracker.ExpiresAt)
+	})
+}
+
+// PutGroup stores a new group together with the membership of its owner, so a
+// group never exists without anyone able to unwrap its secret.
+func (s *BadgerStore) PutGroup(ctx context.Context, g *models.Group, owner *models.GroupMember) error {
+	if len(g.UUID) == 0 {
+		g.UUID = make([]byte, 32)
+		if _, err := rand.Read(g.UUID); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC()
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = now
+	}
+	owner.GroupUUID = g.UUID
+	if owner.AddedAt.IsZero() {
+		owner.AddedAt = now
+	}
+	val, err := codec.Marshal(g)
+	if err != nil {
+		return err
+	}
+	member, err := codec.Marshal(owner)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(g.KeyByUUID()); err == nil {
+			return ErrAlreadyExists
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		if err := txn.Set(g.KeyByUUID(), val); err != nil {
+			return err
+		}
+		if err := txn.Set(owner.Key(), member); err != nil {
+			return err
+		}
+		return txn.Set(owner.KeyByUser(), nil)
+	})
+}
+
+func (s *BadgerStore) GetGroupByUUID(ctx context.Context, uuid []byte) (*models.Group, error) {
+	g := models.Group{
+		UUID: uuid,
+	}
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(g.KeyByUUID())
 		if err != nil {
 			return err
 		}
 		return item.Value(func(val []byte) error {
-			return json.Unmarshal(val, &t)
+			return codec.Unmarshal(val, &g)
 		})
 	})
 	if err == badger.ErrKeyNotFound {
@@ -198,74 +467,358 @@ func (s *BadgerStore) GetSessionInitTracker(ctx context.Context, identityHash st
 	if err != nil {
 		return nil, err
 	}
-	return &t, nil
+	return &g, nil
 }
 
-func (s *BadgerStore) RegisterSessionInitRequest(ctx context.Context, identityHash string, now time.Time, window time.Duration, maxRequests int, trackerTTL time.Duration) (requestCount int, limited bool, retryAfter time.Duration, err error) {
-	if maxRequests <= 0 {
-		return 0, false, 0, errors.New("maxRequests must be > 0")
+func (s *BadgerStore) UpdateGroup(ctx context.Context, uuid []byte, mutate func(*models.Group) error) (*models.Group, error) {
+	loaded := models.Group{
+		UUID: uuid,
 	}
-	if window <= 0 {
-		return 0, false, 0, errors.New("window must be > 0")
+	err := s.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(loaded.KeyByUUID())
+		if err != nil {
+			return err
+		}
+		if err := item.Value(func(val []byte) error {
+			return codec.Unmarshal(val, &loaded)
+		}); err != nil {
+			return err
+		}
+
+		if err := mutate(&loaded); err != nil {
+			return err
+		}
+		loaded.UUID = uuid
+		updated, err := codec.Marshal(&loaded)
+		if err != nil {
+			return err
+		}
+		return txn.Set(loaded.KeyByUUID(), updated)
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrNotFound
 	}
-	if trackerTTL <= 0 {
-		return 0, false, 0, errors.New("trackerTTL must be > 0")
+	if err != nil {
+		return nil, err
 	}
+	return &loaded, nil
+}
 
-	tracker := models.SessionInitTracker{
-		IdentityHash: identityHash,
+// DeleteGroup removes a group and every membership of it, index included.
+func (s *BadgerStore) DeleteGroup(ctx context.Context, uuid []byte) error {
+	g := models.Group{
+		UUID: uuid,
+	}
+	err := s.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(g.KeyByUUID()); err != nil {
+			return err
+		}
+
+		prefix := keyspace.GroupMembers.Key(uuid)
+		var members []models.GroupMember
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			members = append(members, models.GroupMember{
+				GroupUUID: uuid,
+				UserUUID:  it.Item().KeyCopy(nil)[len(prefix):],
+			})
+		}
+		it.Close()
+
+		for _, m := range members {
+			if err := txn.Delete(m.Key()); err != nil {
+				return err
+			}
+			if err := txn.Delete(m.KeyByUser()); err != nil {
+				return err
+			}
+		}
+		return txn.Delete(g.KeyByUUID())
+	})
+	if err == badger.ErrKeyNotFound {
+		return ErrNotFound
 	}
+	return err
+}
 
+// PutGroupMember adds a membership to an existing group. It fails with
+// ErrAlreadyExists if the user is a member already.
+func (s *BadgerStore) PutGroupMember(ctx context.Context, m *models.GroupMember) error {
+	if m.AddedAt.IsZero() {
+		m.AddedAt = time.Now().UTC()
+	}
+	val, err := codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+	g := models.Group{
+		UUID: m.GroupUUID,
+	}
 	err = s.db.Update(func(txn *badger.Txn) error {
-		item, err := txn.Get(tracker.Key())
+		if _, err := txn.Get(g.KeyByUUID()); err != nil {
+			return err
+		}
+		if _, err := txn.Get(m.Key()); err == nil {
+			return ErrAlreadyExists
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		if err := txn.Set(m.Key(), val); err != nil {
+			return err
+		}
+		return txn.Set(m.KeyByUser(), nil)
+	})
+	if err == badger.ErrKeyNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *BadgerStore) GetGroupMember(ctx context.Context, group_uuid, user_uuid []byte) (*models.GroupMember, error) {
+	m := models.GroupMember{
+		GroupUUID: group_uuid,
+		UserUUID:  user_uuid,
+	}
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(m.Key())
 		if err != nil {
-			if err != badger.ErrKeyNotFound {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return codec.Unmarshal(val, &m)
+		})
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *BadgerStore) DeleteGroupMember(ctx context.Context, group_uuid, user_uuid []byte) error {
+	m := models.GroupMember{
+		GroupUUID: group_uuid,
+		UserUUID:  user_uuid,
+	}
+	err := s.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(m.Key()); err != nil {
+			return err
+		}
+		if err := txn.Delete(m.Key()); err != nil {
+			return err
+		}
+		return txn.Delete(m.KeyByUser())
+	})
+	if err == badger.ErrKeyNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *BadgerStore) ListGroupMembers(ctx context.Context, group_uuid []byte) ([]*models.GroupMember, error) {
+	var members []*models.GroupMember
+	err := s.db.View(func(txn *badger.Txn) error {
+		prefix := keyspace.GroupMembers.Key(group_uuid)
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: 100, Prefix: prefix})
+		defer it.Close()
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var m models.GroupMember
+			if err := it.Item().Value(func(val []byte) error {
+				return codec.Unmarshal(val, &m)
+			}); err != nil {
 				return err
 			}
-		} else {
-			if err := item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &tracker)
+			members = append(members, &m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ListUserGroups walks the membership index of a user and returns each
+// membership with its group.
+func (s *BadgerStore) ListUserGroups(ctx context.Context, user_uuid []byte) ([]*models.GroupMember, []*models.Group, error) {
+	var (
+		members []*models.GroupMember
+		groups  []*models.Group
+	)
+	err := s.db.View(func(txn *badger.Txn) error {
+		prefix := keyspace.UserGroups.Key(user_uuid)
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer it.Close()
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			m := models.GroupMember{
+				GroupUUID: it.Item().KeyCopy(nil)[len(prefix):],
+				UserUUID:  user_uuid,
+			}
+			g := models.Group{
+				UUID: m.GroupUUID,
+			}
+			member_item, err := txn.Get(m.Key())
+			if err == badger.ErrKeyNotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err := member_item.Value(func(val []byte) error {
+				return codec.Unmarshal(val, &m)
 			}); err != nil {
 				return err
 			}
+			group_item, err := txn.Get(g.KeyByUUID())
+			if err == badger.ErrKeyNotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err := group_item.Value(func(val []byte) error {
+				return codec.Unmarshal(val, &g)
+			}); err != nil {
+				return err
+			}
+			members = append(members, &m)
+			groups = append(groups, &g)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return members, groups, nil
+}
 
-		windowStart := now.Add(-window).Unix()
-		pruned := make([]int64, 0, len(tracker.RequestUnixTS)+1)
-		for _, ts := range tracker.RequestUnixTS {
-			if ts >= windowStart {
-				pruned = append(pruned, ts)
-			}
+// PutMessage appends a message to its group's history. The UUID, creation
+// time and Seq are always assigned here. Seq is the group's next sequence
+// number, taken in the transaction that stores the message, so messages
+// commit in Seq order and a reader paging forward never has a message land
+// behind its cursor. The sender's nonce is remembered until
+// nonce_expires_at, and a message reusing a remembered nonce fails with
+// ErrMessageReplayed.
+func (s *BadgerStore) PutMessage(ctx context.Context, m *models.Message, nonce_expires_at time.Time) error {
+	m.UUID = make([]byte, 32)
+	if _, err := rand.Read(m.UUID); err != nil {
+		return err
+	}
+	m.CreatedAt = time.Now().UTC()
+	nonce := models.MessageNonce{
+		GroupUUID:  m.GroupUUID,
+		SenderUUID: m.SenderUUID,
+		Nonce:      m.Nonce,
+		ExpiresAt:  nonce_expires_at.UTC(),
+	}
+	nonce_val, err := codec.Marshal(&nonce)
+	if err != nil {
+		return err
+	}
+
+	// Every message of a group bumps its sequence key; without the lock, all
+	// but one of a burst into a group would fail with ErrConflict.
+	seq_key := keyspace.MessageSequence(m.GroupUUID)
+	mu := s.messageLocks.of(seq_key)
+	mu.Lock()
+	defer mu.Unlock()
+	err = s.db.Update(func(txn *badger.Txn) error {
+		var stored models.MessageNonce
+		if err := getLive(txn, nonce.Key(), &stored); err == nil {
+			return ErrMessageReplayed
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		if err := setExpiring(txn, nonce.Key(), nonce_val, nonce.ExpiresAt); err != nil {
+			return err
 		}
 
-		tracker.ExpiresAt = now.Add(trackerTTL).UTC()
-		if len(pruned) >= maxRequests {
-			limited = true
-			tracker.RequestUnixTS = pruned
-			if len(pruned) > 0 {
-				waitSeconds := pruned[0] + int64(window.Seconds()) - now.Unix()
-				if waitSeconds < 1 {
-					waitSeconds = 1
+		m.Seq = 1
+		if item, err := txn.Get(seq_key); err == nil {
+			if err := item.Value(func(val []byte) error {
+				if len(val) != 8 {
+					return fmt.Errorf("message sequence of %d bytes", len(val))
 				}
-				retryAfter = time.Duration(waitSeconds) * time.Second
-			} else {
-				retryAfter = 1 * time.Second
+				m.Seq = binary.BigEndian.Uint64(val) + 1
+				return nil
+			}); err != nil {
+				return err
 			}
-		} else {
-			limited = false
-			pruned = append(pruned, now.Unix())
-			tracker.RequestUnixTS = pruned
-			requestCount = len(pruned)
+		} else if err != badger.ErrKeyNotFound {
+			return err
 		}
-
-		encoded, err := json.Marshal(&tracker)
+		if err := txn.Set(seq_key, binary.BigEndian.AppendUint64(nil, m.Seq)); err != nil {
+			return err
+		}
+		val, err := codec.Marshal(m)
 		if err != nil {
 			return err
 		}
-		return txn.Set(tracker.Key(), encoded)
+		return txn.Set(m.Key(), val)
+	})
+	// With the group locked, only a commit of the same nonce key conflicts
+	// with this transaction.
+	if err == badger.ErrConflict {
+		return ErrMessageReplayed
+	}
+	return err
+}
+
+// ListMessages pages through a group's history. With after set it walks
+// forward from that cursor; otherwise it walks back from before, or from the
+// newest message when before is empty. Either way the page is returned oldest
+// first, and more reports whether the walk stopped because of limit.
+func (s *BadgerStore) ListMessages(ctx context.Context, group_uuid, before, after []byte, limit int) (messages []*models.Message, more bool, err error) {
+	if limit <= 0 {
+		return nil, false, errors.New("limit must be > 0")
+	}
+	prefix := keyspace.Messages.Key(group_uuid)
+	forward := len(after) > 0
+
+	var start, skip []byte
+	switch {
+	case forward:
+		start = append(append([]byte(nil), prefix...), after...)
+		skip = start
+	case len(before) > 0:
+		start = append(append([]byte(nil), prefix...), before...)
+		skip = start
+	default:
+		// Larger than any cursor, so a reverse seek lands on the newest message.
+		start = append(append([]byte(nil), prefix...), bytes.Repeat([]byte{0xff}, 8+1)...)
+	}
+
+	err = s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: limit + 1, Reverse: !forward, Prefix: prefix})
+		defer it.Close()
+		for it.Seek(start); it.ValidForPrefix(prefix); it.Next() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if skip != nil && bytes.Equal(it.Item().Key(), skip) {
+				continue
+			}
+			if len(messages) == limit {
+				more = true
+				return nil
+			}
+			var m models.Message
+			if err := it.Item().Value(func(val []byte) error {
+				return codec.Unmarshal(val, &m)
+			}); err != nil {
+				return err
+			}
+			messages = append(messages, &m)
+		}
+		return nil
 	})
 	if err != nil {
-		return 0, false, 0, err
+		return nil, false, err
 	}
-	return requestCount, limited, retryAfter, nil
+	if !forward {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+	return messages, more, nil
 }
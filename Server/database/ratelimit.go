@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/codec"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// rateLimitAttempts bounds how often UpdateRateLimit retries a transaction
+// that lost a race on its bucket. With updates of a bucket serialized the
+// only race left is the expiry janitor deleting it.
+const rateLimitAttempts = 4
+
+func (s *BadgerStore) UpdateRateLimit(ctx context.Context, bucket string, now time.Time, update func(state []byte) (next []byte, expires_at time.Time)) error {
+	// Badger's optimistic transactions would otherwise fail all but one of
+	// a burst against a bucket with ErrConflict, and a burst is exactly what
+	// a rate limit has to count.
+	mu := s.rateLimitLocks.of([]byte(bucket))
+	mu.Lock()
+	defer mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < rateLimitAttempts; attempt++ {
+		err = s.db.Update(func(txn *badger.Txn) error {
+			stored := models.RateLimitState{Bucket: bucket}
+			item, err := txn.Get(stored.Key())
+			if err == nil {
+				if err := item.Value(func(val []byte) error {
+					return codec.Unmarshal(val, &stored)
+				}); err != nil {
+					return err
+				}
+			} else if err != badger.ErrKeyNotFound {
+				return err
+			}
+			if now.After(stored.ExpiresAt) {
+				stored.State = nil
+			}
+
+			stored.State, stored.ExpiresAt = update(stored.State)
+			encoded, err := codec.Marshal(&stored)
+			if err != nil {
+				return err
+			}
+			return setExpiring(txn, stored.Key(), encoded, stored.ExpiresAt)
+		})
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *MemoryStore) UpdateRateLimit(ctx context.Context, bucket string, now time.Time, update func(state []byte) (next []byte, expires_at time.Time)) error {
+	stored := models.RateLimitState{Bucket: bucket}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(stored.Key(), &stored); err != nil && err != ErrNotFound {
+		return err
+	}
+	if now.After(stored.ExpiresAt) {
+		stored.State = nil
+	}
+	stored.State, stored.ExpiresAt = update(stored.State)
+	return s.store(stored.Key(), &stored)
+}
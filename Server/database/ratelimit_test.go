@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/ratelimit"
+)
+
+func TestUpdateRateLimitConcurrent(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		limiter := ratelimit.New(s, "test", ratelimit.SlidingWindow{Limit: 5, Window: time.Minute})
+		now := time.Now()
+
+		var allowed, failed atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 200; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d, err := limiter.Allow(context.Background(), "198.51.100.7", now)
+				if err != nil {
+					failed.Add(1)
+					return
+				}
+				if d.Allowed {
+					allowed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if failed.Load() != 0 || allowed.Load() != 5 {
+			t.Fatalf("200 concurrent requests on a limit of 5: %d allowed, %d failed", allowed.Load(), failed.Load())
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+// sessionSlidingTTL is how far every successful session fetch pushes the
+// session expiry out.
+const sessionSlidingTTL = 5 * time.Minute
+
+// Store is everything the server keeps. BadgerStore is the durable backend;
+// MemoryStore keeps the same semantics, expiry included, without touching
+// disk, for tests and throwaway development servers.
+type Store interface {
+	PutUser(ctx context.Context, u *models.User) error
+	GetUserByUUID(ctx context.Context, uuid []byte) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	UpdateUser(ctx context.Context, uuid []byte, mutate func(*models.User) error) (*models.User, error)
+
+	PutSession(ctx context.Context, u *models.Session) error
+	GetSessionByUUID(ctx context.Context, uuid [24]byte) (*models.Session, error)
+	PeekSessionByUUID(ctx context.Context, uuid [24]byte) (*models.Session, error)
+	UpdateSession(ctx context.Context, uuid [24]byte, mutate func(*models.Session) error) (*models.Session, error)
+	DeleteSession(ctx context.Context, uuid [24]byte) error
+
+	PutSessionInitTracker(ctx context.Context, t *models.SessionInitTracker) error
+	GetSessionInitTracker(ctx context.Context, identityHash string) (*models.SessionInitTracker, error)
+	RegisterSessionInitRequest(ctx context.Context, identityHash string, now time.Time, window time.Duration, maxRequests int, trackerTTL time.Duration) (requestCount int, limited bool, retryAfter time.Duration, err error)
+	RegisterRecoveryAttempt(ctx context.Context, identity, username string, now time.Time, window time.Duration, maxRequests int, trackerTTL time.Duration) (limited bool, retryAfter time.Duration, err error)
+	RegisterRecoveryFailure(ctx context.Context, identity, username string, now time.Time, window time.Duration, maxFailures int, cooldown time.Duration) (cooling bool, err error)
+	// UpdateRateLimit hands update the state of a rate-limit bucket, nil when
+	// there is none or it expired by now, and stores what update returns
+	// until expires_at. Updates of one bucket are serialized.
+	UpdateRateLimit(ctx context.Context, bucket string, now time.Time, update func(state []byte) (next []byte, expires_at time.Time)) error
+
+	PutGroup(ctx context.Context, g *models.Group, owner *models.GroupMember) error
+	GetGroupByUUID(ctx context.Context, uuid []byte) (*models.Group, error)
+	UpdateGroup(ctx context.Context, uuid []byte, mutate func(*models.Group) error) (*models.Group, error)
+	DeleteGroup(ctx context.Context, uuid []byte) error
+	PutGroupMember(ctx context.Context, m *models.GroupMember) error
+	GetGroupMember(ctx context.Context, group_uuid, user_uuid []byte) (*models.GroupMember, error)
+	DeleteGroupMember(ctx context.Context, group_uuid, user_uuid []byte) error
+	ListGroupMembers(ctx context.Context, group_uuid []byte) ([]*models.GroupMember, error)
+	ListUserGroups(ctx context.Context, user_uuid []byte) ([]*models.GroupMember, []*models.Group, error)
+
+	PutMessage(ctx context.Context, m *models.Message, nonce_expires_at time.Time) error
+	ListMessages(ctx context.Context, group_uuid, before, after []byte, limit int) (messages []*models.Message, more bool, err error)
+
+	SweepExpired(ctx context.Context, now time.Time) (SweepStats, error)
+	StartExpiryJanitor(ctx context.Context, sweepInterval time.Duration)
+	// Probe writes a throwaway value and reads it back, to tell whether the
+	// store is usable right now.
+	Probe(ctx context.Context) error
+	Close() error
+}
+
+var (
+	_ Store = (*BadgerStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
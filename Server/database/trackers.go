@@ -0,0 +1,112 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+// The sliding-window bookkeeping below is shared by every Store backend, so
+// they cannot drift apart on when a caller is limited.
+
+func validateSessionInitLimits(window time.Duration, maxRequests int, trackerTTL time.Duration) error {
+	if err := validateRecoveryLimits(window, maxRequests); err != nil {
+		return err
+	}
+	if trackerTTL <= 0 {
+		return errors.New("trackerTTL must be > 0")
+	}
+	return nil
+}
+
+func validateRecoveryLimits(window time.Duration, maxRequests int) error {
+	if maxRequests <= 0 {
+		return errors.New("maxRequests must be > 0")
+	}
+	if window <= 0 {
+		return errors.New("window must be > 0")
+	}
+	return nil
+}
+
+// pruneWindow keeps the timestamps that still fall inside window.
+func pruneWindow(timestamps []int64, now time.Time, window time.Duration) []int64 {
+	windowStart := now.Add(-window).Unix()
+	pruned := make([]int64, 0, len(timestamps)+1)
+	for _, ts := range timestamps {
+		if ts >= windowStart {
+			pruned = append(pruned, ts)
+		}
+	}
+	return pruned
+}
+
+// windowRetryAfter is how long until the oldest timestamp leaves the window.
+func windowRetryAfter(pruned []int64, now time.Time, window time.Duration) time.Duration {
+	if len(pruned) == 0 {
+		return 1 * time.Second
+	}
+	waitSeconds := pruned[0] + int64(window.Seconds()) - now.Unix()
+	if waitSeconds < 1 {
+		waitSeconds = 1
+	}
+	return time.Duration(waitSeconds) * time.Second
+}
+
+// countSessionInitRequest records a session init request on tracker unless
+// the window is already full. The tracker must be stored either way, since its
+// expiry is pushed out on every call.
+func countSessionInitRequest(tracker *models.SessionInitTracker, now time.Time, window time.Duration, maxRequests int, trackerTTL time.Duration) (requestCount int, limited bool, retryAfter time.Duration) {
+	pruned := pruneWindow(tracker.RequestUnixTS, now, window)
+	tracker.ExpiresAt = now.Add(trackerTTL).UTC()
+	if len(pruned) >= maxRequests {
+		tracker.RequestUnixTS = pruned
+		return 0, true, windowRetryAfter(pruned, now, window)
+	}
+	pruned = append(pruned, now.Unix())
+	tracker.RequestUnixTS = pruned
+	return len(pruned), false, 0
+}
+
+// countRecoveryAttempt records a recovery attempt on tracker. changed is false
+// while the identity is cooling down, in which case nothing needs storing.
+func countRecoveryAttempt(tracker *models.RecoveryTracker, now time.Time, window time.Duration, maxRequests int, trackerTTL time.Duration) (limited bool, retryAfter time.Duration, changed bool) {
+	if now.Before(tracker.CooldownUntil) {
+		return true, tracker.CooldownUntil.Sub(now).Round(time.Second), false
+	}
+
+	pruned := pruneWindow(tracker.RequestUnixTS, now, window)
+	if len(pruned) >= maxRequests {
+		limited = true
+		retryAfter = windowRetryAfter(pruned, now, window)
+	} else {
+		pruned = append(pruned, now.Unix())
+	}
+	tracker.RequestUnixTS = pruned
+	if expiresAt := now.Add(trackerTTL).UTC(); expiresAt.After(tracker.ExpiresAt) {
+		tracker.ExpiresAt = expiresAt
+	}
+	return limited, retryAfter, true
+}
+
+// countRecoveryFailure records a failed recovery proof on tracker. Once
+// maxFailures fall inside window the identity cools down until now+cooldown,
+// and the attempts counted so far are forgotten.
+func countRecoveryFailure(tracker *models.RecoveryTracker, now time.Time, window time.Duration, maxFailures int, cooldown time.Duration) (cooling bool) {
+	failed := append(pruneWindow(tracker.FailedUnixTS, now, window), now.Unix())
+	if len(failed) >= maxFailures {
+		tracker.RequestUnixTS = nil
+		tracker.FailedUnixTS = nil
+		tracker.CooldownUntil = now.Add(cooldown).UTC()
+		cooling = true
+	} else {
+		tracker.FailedUnixTS = failed
+	}
+	for _, expiresAt := range []time.Time{now.Add(window).UTC(), tracker.CooldownUntil} {
+		if expiresAt.After(tracker.ExpiresAt) {
+			tracker.ExpiresAt = expiresAt
+		}
+	}
+	return cooling
+}
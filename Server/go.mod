@@ -3,9 +3,13 @@ module github.com/MHSarmadi/Umbra/Server
 go 1.24.5
 
 require (
+	github.com/MHSarmadi/Umbra/Proto v0.0.0
 	github.com/dgraph-io/badger/v4 v4.1.0
 	github.com/gorilla/mux v1.8.1
+	github.com/olahol/melody v1.4.0
 	github.com/zeebo/blake3 v0.2.4
+	golang.org/x/crypto v0.47.0
+	google.golang.org/protobuf v1.36.9
 )
 
 require (
@@ -15,16 +19,16 @@ require (
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b // indirect
 	github.com/golang/groupcache v0.0.0-20190702054246-869f871628b6 // indirect
-	github.com/golang/protobuf v1.3.1 // indirect
+	github.com/golang/protobuf v1.5.0 // indirect
 	github.com/golang/snappy v0.0.3 // indirect
 	github.com/google/flatbuffers v1.12.1 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/klauspost/compress v1.12.3 // indirect
 	github.com/klauspost/cpuid/v2 v2.0.12 // indirect
-	github.com/olahol/melody v1.4.0 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	go.opencensus.io v0.22.5 // indirect
-	golang.org/x/crypto v0.47.0 // indirect
 	golang.org/x/net v0.48.0 // indirect
 	golang.org/x/sys v0.40.0 // indirect
 )
+
+replace github.com/MHSarmadi/Umbra/Proto => ../Proto
@@ -0,0 +1,165 @@
+// Package health answers liveness and readiness probes. Liveness only says
+// the process is serving; readiness runs every dependency check and turns
+// false for good once the server starts draining.
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/crypto"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+)
+
+// checkTimeout bounds each check, so a wedged dependency fails the probe
+// instead of hanging it.
+const checkTimeout = 2 * time.Second
+
+var errDraining = errors.New("server is draining")
+
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type Checker struct {
+	checks   []Check
+	draining atomic.Bool
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks}
+}
+
+// SetDraining makes every readiness probe fail from now on.
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+}
+
+// Ready runs the checks concurrently and returns each one's error, nil for
+// those that passed.
+func (c *Checker) Ready(ctx context.Context) map[string]error {
+	results := make(map[string]error, len(c.checks)+1)
+	if c.draining.Load() {
+		results["draining"] = errDraining
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			err := runCheck(ctx, check)
+			mu.Lock()
+			results[check.Name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runCheck gives up on a check that ignores ctx; its goroutine finishes in
+// the background.
+func runCheck(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Run(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Liveness answers 200 as long as the process can serve HTTP at all.
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, "ok", nil)
+}
+
+// Readiness answers 200 when every check passes and 503 otherwise. The body
+// names the failing checks but not their errors, which go to the log.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	results := c.Ready(r.Context())
+	status, code := "ok", http.StatusOK
+	checks := make(map[string]string, len(results))
+	for name, err := range results {
+		checks[name] = "ok"
+		if err != nil {
+			status, code = "not ready", http.StatusServiceUnavailable
+			checks[name] = "fail"
+			if err != errDraining {
+				logger.Infof("readiness check %s failed: %v", name, err)
+			}
+		}
+	}
+	writeStatus(w, code, status, checks)
+}
+
+func writeStatus(w http.ResponseWriter, code int, status string, checks map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks,omitempty"`
+	}{status, checks})
+}
+
+// Store writes and reads back a probe value.
+func Store(storage database.Store) Check {
+	return Check{Name: "store", Run: storage.Probe}
+}
+
+// Crypto makes sure the system's randomness source answers and that MACE
+// still decrypts what it encrypts.
+func Crypto() Check {
+	return Check{Name: "crypto", Run: func(ctx context.Context) error {
+		key := make([]byte, 32)
+		plain := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("crypto/rand: %w", err)
+		}
+		if _, err := rand.Read(plain); err != nil {
+			return fmt.Errorf("crypto/rand: %w", err)
+		}
+		cipher, salt, tag := crypto.MACE_Encrypt_AEAD(key, plain, "@HEALTH-CHECK", 1, false)
+		raw, valid, err := crypto.MACE_Decrypt_AEAD(key, cipher, salt, tag, "@HEALTH-CHECK", 1)
+		if err != nil {
+			return fmt.Errorf("mace round trip: %w", err)
+		}
+		if !valid || !bytes.Equal(raw, plain) {
+			return errors.New("mace round trip returned different data")
+		}
+		return nil
+	}}
+}
+
+// Janitor fails when the expiry janitor has not completed a sweep within
+// maxAge, including when it has not completed one yet.
+func Janitor(maxAge time.Duration) Check {
+	return Check{Name: "janitor", Run: func(ctx context.Context) error {
+		last := database.JanitorHeartbeat()
+		if last.IsZero() {
+			return errors.New("no sweep completed yet")
+		}
+		if age := time.Since(last); age > maxAge {
+			return fmt.Errorf("last sweep %s ago, over %s", age.Round(time.Second), maxAge)
+		}
+		return nil
+	}}
+}
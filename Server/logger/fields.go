@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field is one key/value pair of a structured log line. In the text format it
+// is appended as key=value; in the JSON format it becomes a member of the
+// line's object.
+type Field struct {
+	Key   string
+	Value any
+}
+
+func F(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+func logw(level uint8, label, msg string, fields []Field) {
+	if !enabled(level) {
+		return
+	}
+	emit(label, msg, fields)
+}
+
+func Error(msg string, fields ...Field) {
+	logw(LevelError, "ERROR", msg, fields)
+}
+
+func Info(msg string, fields ...Field) {
+	logw(LevelInfo, "INFO", msg, fields)
+}
+
+func Debug(msg string, fields ...Field) {
+	logw(LevelDebug, "DEBUG", msg, fields)
+}
+
+func Verbose(msg string, fields ...Field) {
+	logw(LevelVerb, "VERBOSE", msg, fields)
+}
+
+func Trace(msg string, fields ...Field) {
+	logw(LevelTrace, "TRACE", msg, fields)
+}
+
+func emit(label, msg string, fields []Field) {
+	if jsonFormat.Load() {
+		log.Print(jsonLine(time.Now().UTC(), label, msg, fields))
+		return
+	}
+	var b strings.Builder
+	b.WriteString("[")
+	b.WriteString(label)
+	b.WriteString("] ")
+	b.WriteString(msg)
+	for _, f := range fields {
+		b.WriteString(" ")
+		b.WriteString(f.Key)
+		b.WriteString("=")
+		b.WriteString(textValue(f.Value))
+	}
+	log.Print(b.String())
+}
+
+// textValue quotes values that would otherwise run into the next field.
+func textValue(v any) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// jsonLine renders time, level and msg first, then the fields in order. A
+// field that does not marshal is logged as its fmt representation.
+func jsonLine(t time.Time, label, msg string, fields []Field) string {
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	writeJSON(&b, t.Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, strings.ToLower(label))
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, msg)
+	for _, f := range fields {
+		b.WriteString(",")
+		writeJSON(&b, f.Key)
+		b.WriteString(":")
+		writeJSON(&b, f.Value)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func writeJSON(b *bytes.Buffer, v any) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(v))
+	}
+	b.Write(encoded)
+}
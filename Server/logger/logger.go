@@ -1,20 +1,17 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// LogVerbosity controls which logs are emitted.
-// 0 = production silent mode (no logs).
-// Higher values enable more logs.
-const LogVerbosity uint8 = 5
-
 const (
 	LevelError uint8 = 1
 	LevelInfo  uint8 = 2
@@ -23,27 +20,82 @@ const (
 	LevelTrace uint8 = 5
 )
 
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config selects where logs are written, how many of them and in what form.
+type Config struct {
+	// Dir holds one log file per UTC day. Empty logs to stdout only.
+	Dir string
+	// Verbosity controls which logs are emitted.
+	// 0 = production silent mode (no logs).
+	// Higher values enable more logs, up to LevelTrace.
+	// SetVerbosity changes it at runtime.
+	Verbosity uint8
+	// Format is FormatText or FormatJSON, one object per line.
+	Format string
+	// Daily files are gzipped once CompressAfterDays old and removed once
+	// DeleteAfterDays old; 0 keeps them as they are.
+	CompressAfterDays int
+	DeleteAfterDays   int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Dir:       "Logs",
+		Verbosity: LevelInfo,
+		Format:    FormatText,
+	}
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Verbosity > LevelTrace {
+		errs = append(errs, fmt.Errorf("log verbosity %d is above the highest level %d", c.Verbosity, LevelTrace))
+	}
+	if c.Format != FormatText && c.Format != FormatJSON {
+		errs = append(errs, fmt.Errorf("unknown log format %q; use %s or %s", c.Format, FormatText, FormatJSON))
+	}
+	if c.CompressAfterDays < 0 || c.DeleteAfterDays < 0 {
+		errs = append(errs, errors.New("log retention days cannot be negative"))
+	}
+	if c.CompressAfterDays > 0 && c.DeleteAfterDays > 0 && c.DeleteAfterDays <= c.CompressAfterDays {
+		errs = append(errs, errors.New("logs would be deleted before they are compressed"))
+	}
+	return errors.Join(errs...)
+}
+
 var (
 	writerMu sync.Mutex
 	writer   *dateFileWriter
+
+	verbosity  atomic.Uint32
+	jsonFormat atomic.Bool
 )
 
+func init() {
+	verbosity.Store(uint32(LevelInfo))
+}
+
 type dateFileWriter struct {
-	mu      sync.Mutex
-	logDir  string
-	curDay  string
-	curFile *os.File
+	mu        sync.Mutex
+	logDir    string
+	curDay    string
+	curFile   *os.File
+	retention retention
 }
 
-func newDateFileWriter(logDir string) (*dateFileWriter, error) {
+func newDateFileWriter(logDir string, retention retention) (*dateFileWriter, error) {
 	if err := os.MkdirAll(logDir, 0o755); err != nil {
 		return nil, err
 	}
-	return &dateFileWriter{logDir: logDir}, nil
+	return &dateFileWriter{logDir: logDir, retention: retention}, nil
 }
 
 func (w *dateFileWriter) ensureFileLocked() error {
-	day := time.Now().UTC().Format("2006-01-02")
+	day := time.Now().UTC().Format(dayLayout)
 	if w.curFile != nil && w.curDay == day {
 		return nil
 	}
@@ -62,6 +114,10 @@ func (w *dateFileWriter) ensureFileLocked() error {
 	}
 	w.curFile = f
 	w.curDay = day
+
+	// A new day leaves yesterday's file behind, so that is when older files
+	// come due. Run outside the lock: logging must not wait on gzip.
+	go w.retention.apply(w.logDir, day)
 	return nil
 }
 
@@ -88,22 +144,29 @@ func (w *dateFileWriter) Close() error {
 	return err
 }
 
-func Init(logDir string) error {
+func Init(cfg Config) error {
 	writerMu.Lock()
 	defer writerMu.Unlock()
 
-	if LogVerbosity == 0 {
-		log.SetOutput(io.Discard)
-		return nil
+	verbosity.Store(uint32(cfg.Verbosity))
+	jsonFormat.Store(cfg.Format == FormatJSON)
+	if jsonFormat.Load() {
+		// Every line carries its own timestamp.
+		log.SetFlags(0)
+	} else {
+		log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC)
 	}
 
-	w, err := newDateFileWriter(logDir)
+	if cfg.Dir == "" {
+		log.SetOutput(os.Stdout)
+		return nil
+	}
+	w, err := newDateFileWriter(cfg.Dir, retention{compressAfterDays: cfg.CompressAfterDays, deleteAfterDays: cfg.DeleteAfterDays})
 	if err != nil {
 		return err
 	}
 
 	writer = w
-	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC)
 	log.SetOutput(io.MultiWriter(os.Stdout, writer))
 	return nil
 }
@@ -120,18 +183,32 @@ func Close() error {
 	return err
 }
 
+// SetVerbosity changes which logs are emitted from now on.
+func SetVerbosity(v uint8) error {
+	if v > LevelTrace {
+		return fmt.Errorf("log verbosity %d is above the highest level %d", v, LevelTrace)
+	}
+	verbosity.Store(uint32(v))
+	return nil
+}
+
+func Verbosity() uint8 {
+	return uint8(verbosity.Load())
+}
+
 func enabled(level uint8) bool {
-	if LogVerbosity == 0 {
+	v := Verbosity()
+	if v == 0 {
 		return false
 	}
-	return level <= LogVerbosity
+	return level <= v
 }
 
 func logf(level uint8, label, format string, args ...any) {
 	if !enabled(level) {
 		return
 	}
-	log.Printf("[%s] %s", label, fmt.Sprintf(format, args...))
+	emit(label, fmt.Sprintf(format, args...), nil)
 }
 
 func Errorf(format string, args ...any) {
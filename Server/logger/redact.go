@@ -0,0 +1,21 @@
+package logger
+
+import "strconv"
+
+// Secrets never reach a log line, at any verbosity. The helpers keep what is
+// useful for debugging, the length, and nothing derived from the content:
+// even a short hash of a six-digit captcha solution gives it away.
+
+// Redact stands in for secret bytes such as tokens, keys and souls.
+func Redact(secret []byte) string {
+	return redacted(len(secret))
+}
+
+// RedactString stands in for a secret string such as a passphrase.
+func RedactString(secret string) string {
+	return redacted(len(secret))
+}
+
+func redacted(n int) string {
+	return "<redacted " + strconv.Itoa(n) + " bytes>"
+}
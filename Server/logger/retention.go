@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+// retention ages out the daily files of a log directory. A file's age is the
+// number of days between its day and today, so today's file is never touched.
+type retention struct {
+	compressAfterDays int
+	deleteAfterDays   int
+}
+
+func (r retention) apply(dir, today string) {
+	if r.compressAfterDays == 0 && r.deleteAfterDays == 0 {
+		return
+	}
+	now, err := time.Parse(dayLayout, today)
+	if err != nil {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		Errorf("log retention could not list %s: %v", dir, err)
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		day, compressed, ok := strings.Cut(name, ".log")
+		if !ok || (compressed != "" && compressed != ".gz") {
+			continue
+		}
+		date, err := time.Parse(dayLayout, day)
+		if err != nil {
+			continue
+		}
+		age := int(now.Sub(date) / (24 * time.Hour))
+		path := filepath.Join(dir, name)
+
+		switch {
+		case r.deleteAfterDays > 0 && age >= r.deleteAfterDays:
+			if err := os.Remove(path); err != nil {
+				Errorf("log retention could not delete %s: %v", path, err)
+			}
+		case r.compressAfterDays > 0 && age >= r.compressAfterDays && compressed == "":
+			if err := compressFile(path); err != nil {
+				Errorf("log retention could not compress %s: %v", path, err)
+			}
+		}
+	}
+}
+
+// compressFile replaces path with path.gz. The archive is written under a
+// temporary name first, so a crash never leaves a truncated .gz behind.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
@@ -3,62 +3,182 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/MHSarmadi/Umbra/Server/config"
 	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/health"
 	"github.com/MHSarmadi/Umbra/Server/logger"
 	"github.com/MHSarmadi/Umbra/Server/web"
 )
 
+var subcommands = map[string]func(args []string) error{
+	"rotate-key": rotateKey,
+	"migrate":    migrate,
+}
+
 func main() {
-	if err := logger.Init("Logs"); err != nil {
-		panic(err)
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+				os.Exit(1)
+			}
+			return
+		}
 	}
-	defer logger.Close()
 
-	s, err := database.NewBadgerStore("./data")
+	restore := flag.String("restore", "", "comma-separated backup files, oldest first, to load into an empty data directory before starting")
+	print_config := flag.Bool("print-config", false, "print the effective configuration, secrets redacted, and exit")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	// The passphrase now lives in cfg only; child processes need not see it.
+	os.Unsetenv(dbPassphraseEnv)
 	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *print_config {
+		out, err := cfg.Print()
+		if err != nil {
+			panic(err)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
+	if err := logger.Init(cfg.Log); err != nil {
 		panic(err)
 	}
+	defer logger.Close()
+
+	var s database.Store
+	if cfg.Storage.Memory {
+		s = database.NewMemoryStore()
+		logger.Infof("using ephemeral in-memory store")
+	} else {
+		key, err := database.LoadEncryptionKey(cfg.Storage.DataDir, cfg.Storage.KeyFile, []byte(cfg.Storage.Passphrase))
+		if err != nil {
+			panic(err)
+		}
+		if *restore != "" {
+			if err := restoreBackups(cfg.Storage.DataDir, key, strings.Split(*restore, ",")); err != nil {
+				panic(err)
+			}
+		}
+		badgerStore, err := database.NewBadgerStore(cfg.Storage.DataDir, key)
+		if err != nil {
+			panic(err)
+		}
+		if len(key) > 0 {
+			logger.Infof("data directory encrypted at rest")
+		}
+		badgerStore.ExportSizeMetrics()
+		s = badgerStore
+	}
 	defer s.Close()
 
 	mainCtx, cancelMain := context.WithCancel(context.Background())
 	defer cancelMain()
 
-	go s.StartExpiryJanitor(mainCtx, 1*time.Minute)
+	go s.StartExpiryJanitor(mainCtx, cfg.Storage.JanitorInterval)
+
+	// A healthy janitor sweeps every interval; allow a slow sweep or two.
+	checker := health.NewChecker(health.Store(s), health.Crypto(), health.Janitor(3*cfg.Storage.JanitorInterval))
 
-	srv := web.NewServer(mainCtx, "localhost:8888", s)
-	logger.Infof("server starting on %s", "localhost:8888")
+	srv, err := web.NewServer(mainCtx, cfg.Server, cfg.Session, cfg.RateLimit, s, checker)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.Server.TLSCertFile != "" {
+		logger.Infof("server starting with tls on %s", cfg.Server.Address)
+	} else {
+		logger.Infof("server starting on %s", cfg.Server.Address)
+	}
 
 	serverErrCh := make(chan error, 1)
 	go func() {
 		serverErrCh <- srv.Run()
 	}()
 
+	var admin *web.Server
+	if cfg.Server.AdminAddress != "" {
+		admin = web.NewAdminServer(mainCtx, cfg.Server, s, checker)
+		logger.Infof("admin server starting on %s", cfg.Server.AdminAddress)
+		go func() {
+			if err := admin.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Errorf("admin server failed: %v", err)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	select {
-	case <-quit:
-		logger.Infof("shutdown signal received")
-	case err := <-serverErrCh:
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logger.Errorf("server failed: %v", err)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+wait:
+	for {
+		select {
+		case <-hup:
+			if err := srv.ReloadCertificates(); err != nil {
+				logger.Errorf("tls certificate reload failed, keeping the current one: %v", err)
+			} else if cfg.Server.TLSCertFile != "" {
+				logger.Infof("tls certificate reloaded on SIGHUP")
+			}
+		case <-quit:
+			logger.Infof("shutdown signal received")
+			break wait
+		case err := <-serverErrCh:
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Errorf("server failed: %v", err)
+			}
+			cancelMain()
+			return
 		}
-		cancelMain()
-		return
 	}
-	cancelMain()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Requests keep being served normally while the server drains, so the
+	// main context is only cancelled once it has stopped.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.DrainDelay+10*time.Second)
 	defer cancel()
 
-	if err := srv.ShutDown(ctx); err != nil {
+	if cfg.Server.DrainDelay > 0 {
+		logger.Infof("draining for %s", cfg.Server.DrainDelay)
+	}
+	err = srv.ShutDown(ctx)
+	if admin != nil {
+		if err := admin.ShutDown(ctx); err != nil {
+			logger.Errorf("admin shutdown error: %v", err)
+		}
+	}
+	cancelMain()
+	if err != nil {
 		logger.Errorf("shutdown error: %v", err)
 		return
 	}
 	logger.Infof("server stopped gracefully")
 }
+
+func restoreBackups(dir string, key []byte, paths []string) error {
+	backups := make([]io.Reader, 0, len(paths))
+	for _, path := range paths {
+		f, err := os.Open(strings.TrimSpace(path))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		backups = append(backups, f)
+	}
+	if err := database.RestoreBadgerStore(dir, key, backups...); err != nil {
+		return err
+	}
+	logger.Infof("restored %d backup file(s) into %s", len(paths), dir)
+	return nil
+}
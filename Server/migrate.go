@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/database/migrations"
+)
+
+// migrate implements `server migrate`: it brings a stopped server's data
+// directory up to date, or with -dry-run only lists what would change.
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	data := fs.String("data", "./data", "data directory to migrate")
+	key_file := fs.String("db-key-file", "", "key file of an encrypted directory; or set "+dbPassphraseEnv)
+	dry_run := fs.Bool("dry-run", false, "report pending migrations without applying them")
+	fs.Parse(args)
+
+	key, err := database.LoadEncryptionKey(*data, *key_file, []byte(os.Getenv(dbPassphraseEnv)))
+	if err != nil {
+		return err
+	}
+
+	if *dry_run {
+		from, reports, err := database.PlanMigrations(*data, key)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("data directory at migration %d, server at %d\n", from, migrations.Latest())
+		for _, report := range reports {
+			fmt.Printf("would apply %d (%s): writes=%d deletes=%d\n", report.Version, report.Name, report.Writes, report.Deletes)
+		}
+		return nil
+	}
+
+	s, err := database.NewBadgerStore(*data, key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("data directory at migration %d\n", migrations.Latest())
+	return s.Close()
+}
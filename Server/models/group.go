@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+)
 
 type Group struct {
 	UUID                      []byte    `json:"uuid"`
@@ -12,3 +16,39 @@ type Group struct {
 	CreatorUUID               []byte    `json:"creator_uuid"`
 	CreatedAt                 time.Time `json:"created_at"`
 }
+
+func (g *Group) KeyByUUID() []byte {
+	return keyspace.Group(g.UUID)
+}
+
+type GroupRole string
+
+const (
+	GroupRoleOwner  GroupRole = "owner"
+	GroupRoleMember GroupRole = "member"
+)
+
+// GroupMember holds the group secret wrapped to one member's X25519 key. The
+// wrapping happens on the client: WrapXPublicKey is the ephemeral key the
+// secret was wrapped with, so only the member's soul can unwrap it.
+type GroupMember struct {
+	GroupUUID         []byte    `json:"group_uuid"`
+	UserUUID          []byte    `json:"user_uuid"`
+	Role              GroupRole `json:"role"`
+	WrapXPublicKey    []byte    `json:"wrap_x_pub_key"`
+	WrappedSecret     []byte    `json:"wrapped_secret"`
+	WrappedSecretSalt []byte    `json:"wrapped_secret_salt"`
+	WrappedSecretTag  []byte    `json:"wrapped_secret_tag"`
+	AddedBy           []byte    `json:"added_by"`
+	AddedAt           time.Time `json:"added_at"`
+}
+
+// Key is where the membership itself lives, grouped by group.
+func (m *GroupMember) Key() []byte {
+	return keyspace.GroupMember(m.GroupUUID, m.UserUUID)
+}
+
+// KeyByUser is the membership index, grouped by user. It holds no value.
+func (m *GroupMember) KeyByUser() []byte {
+	return keyspace.UserGroup(m.UserUUID, m.GroupUUID)
+}
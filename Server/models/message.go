@@ -1,13 +1,36 @@
 package models
 
-import "time"
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+)
 
 type Message struct {
-	UUID            []byte    `json:"uuid"`
-	GroupUUID       []byte    `json:"group_uuid"`
-	XPublicKey      []byte    `json:"x_pub_key"`
-	Payload         []byte    `json:"payload"`
-	SenderUUID      []byte    `json:"sender_uuid"`
-	SenderSignature []byte    `json:"sender_signature"`
-	CreatedAt       time.Time `json:"created_at"`
+	UUID            []byte `json:"uuid"`
+	GroupUUID       []byte `json:"group_uuid"`
+	XPublicKey      []byte `json:"x_pub_key"`
+	Payload         []byte `json:"payload"`
+	SenderUUID      []byte `json:"sender_uuid"`
+	SenderSignature []byte `json:"sender_signature"`
+	// Nonce and SentAt are chosen and signed by the sender, so a captured
+	// message cannot be stored a second time.
+	Nonce     []byte    `json:"nonce"`
+	SentAt    time.Time `json:"sent_at"`
+	CreatedAt time.Time `json:"created_at"`
+	// Seq numbers the messages of a group from 1 in the order they were
+	// stored.
+	Seq uint64 `json:"seq"`
+}
+
+// Cursor is the position of the message within its group: Seq, 8 bytes
+// big-endian. Keys sort by it, so a group's history reads in the order it
+// was stored.
+func (m *Message) Cursor() []byte {
+	return binary.BigEndian.AppendUint64(nil, m.Seq)
+}
+
+func (m *Message) Key() []byte {
+	return keyspace.Message(m.GroupUUID, m.Cursor())
 }
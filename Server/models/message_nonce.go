@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+)
+
+// MessageNonce remembers a nonce a sender used in a group for as long as a
+// message carrying it would still be accepted.
+type MessageNonce struct {
+	GroupUUID  []byte    `json:"group_uuid"`
+	SenderUUID []byte    `json:"sender_uuid"`
+	Nonce      []byte    `json:"nonce"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (n *MessageNonce) Key() []byte {
+	return keyspace.MessageNonce(n.GroupUUID, n.SenderUUID, n.Nonce)
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+)
+
+// RateLimitState is what a store keeps for one rate-limit bucket. State is
+// opaque here; package ratelimit owns its layout.
+type RateLimitState struct {
+	Bucket    string    `json:"bucket"`
+	State     []byte    `json:"state"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (t *RateLimitState) Key() []byte {
+	return keyspace.RateLimit(t.Bucket)
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+)
+
+// RecoveryTracker throttles account recovery per username and client
+// identity, so nobody can spend the budget of an owner recovering from
+// elsewhere. CooldownUntil is set once the identity fails enough proofs, which
+// stops it guessing recovery keys without locking anyone else out. The tracker
+// with an empty Identity counts every identity on the username together,
+// against a higher ceiling.
+type RecoveryTracker struct {
+	Username      string    `json:"username"`
+	Identity      string    `json:"identity"`
+	RequestUnixTS []int64   `json:"request_unix_ts"`
+	FailedUnixTS  []int64   `json:"failed_unix_ts"`
+	CooldownUntil time.Time `json:"cooldown_until"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+func (t *RecoveryTracker) Key() []byte {
+	return keyspace.RecoveryTracker(t.Username, t.Identity)
+}
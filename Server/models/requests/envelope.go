@@ -0,0 +1,17 @@
+package models_requests
+
+type EnvelopeEncoded struct {
+	SessionUUID string `json:"session_id"`
+	Nonce       string `json:"nonce"`
+	Timestamp   string `json:"timestamp_unix_millisec"`
+	Payload     string `json:"payload"`
+	Signature   string `json:"signature"`
+}
+
+type EnvelopeDecoded struct {
+	SessionUUID []byte
+	Nonce       []byte
+	Timestamp   []byte
+	Payload     []byte
+	Signature   []byte
+}
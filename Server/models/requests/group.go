@@ -0,0 +1,55 @@
+package models_requests
+
+type WrappedGroupSecretEncoded struct {
+	XPublicKey string `json:"x_pub_key"`
+	Cipher     string `json:"cipher"`
+	Salt       string `json:"salt"`
+	Tag        string `json:"tag"`
+}
+
+type WrappedGroupSecretDecoded struct {
+	XPublicKey []byte
+	Cipher     []byte
+	Salt       []byte
+	Tag        []byte
+}
+
+type GroupCreateRequestEncoded struct {
+	XPublicKey                string                    `json:"x_pub_key"`
+	EPublicKey                string                    `json:"e_pub_key"`
+	EncipheredEntranceKey     string                    `json:"enciphered_entrance_key"`
+	EncipheredEntranceKeySalt string                    `json:"enciphered_entrance_key_salt"`
+	EncipheredEntranceKeyTag  string                    `json:"enciphered_entrance_key_tag"`
+	Secret                    WrappedGroupSecretEncoded `json:"secret"`
+}
+
+type GroupCreateRequestDecoded struct {
+	XPublicKey                []byte
+	EPublicKey                []byte
+	EncipheredEntranceKey     []byte
+	EncipheredEntranceKeySalt []byte
+	EncipheredEntranceKeyTag  []byte
+	Secret                    WrappedGroupSecretDecoded
+}
+
+type GroupMemberAddRequestEncoded struct {
+	GroupUUID string                    `json:"group_uuid"`
+	UserUUID  string                    `json:"user_uuid"`
+	Secret    WrappedGroupSecretEncoded `json:"secret"`
+}
+
+type GroupMemberAddRequestDecoded struct {
+	GroupUUID []byte
+	UserUUID  []byte
+	Secret    WrappedGroupSecretDecoded
+}
+
+type GroupMemberRemoveRequestEncoded struct {
+	GroupUUID string `json:"group_uuid"`
+	UserUUID  string `json:"user_uuid"`
+}
+
+type GroupMemberRemoveRequestDecoded struct {
+	GroupUUID []byte
+	UserUUID  []byte
+}
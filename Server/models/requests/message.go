@@ -0,0 +1,33 @@
+package models_requests
+
+type ChatMessageSendRequestEncoded struct {
+	GroupUUID       string `json:"group_uuid"`
+	XPublicKey      string `json:"x_pub_key"`
+	Payload         string `json:"payload"`
+	Nonce           string `json:"nonce"`
+	SentAt          string `json:"sent_at_unix_millisec"`
+	SenderSignature string `json:"sender_signature"`
+}
+
+type ChatMessageSendRequestDecoded struct {
+	GroupUUID       []byte
+	XPublicKey      []byte
+	Payload         []byte
+	Nonce           []byte
+	SentAt          int64
+	SenderSignature []byte
+}
+
+type ChatHistoryRequestEncoded struct {
+	GroupUUID string `json:"group_uuid"`
+	Before    string `json:"before"`
+	After     string `json:"after"`
+	Limit     uint32 `json:"limit"`
+}
+
+type ChatHistoryRequestDecoded struct {
+	GroupUUID []byte
+	Before    []byte
+	After     []byte
+	Limit     uint32
+}
@@ -0,0 +1,11 @@
+package models_requests
+
+type SessionActivateRequestEncoded struct {
+	SessionUUID string `json:"session_id"`
+	Proof       string `json:"proof"`
+}
+
+type SessionActivateRequestDecoded struct {
+	SessionUUID []byte
+	Proof       []byte
+}
@@ -0,0 +1,11 @@
+package models_requests
+
+type SessionPoWRequestEncoded struct {
+	SessionUUID string `json:"session_id"`
+	Nonce       string `json:"nonce"`
+}
+
+type SessionPoWRequestDecoded struct {
+	SessionUUID []byte
+	Nonce       []byte
+}
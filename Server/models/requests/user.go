@@ -0,0 +1,106 @@
+package models_requests
+
+import "github.com/MHSarmadi/Umbra/Server/models"
+
+// SoulKDFEncoded is the JSON shape of a soul KDF; only the salt is base64.
+type SoulKDFEncoded struct {
+	Version     uint8  `json:"version"`
+	Salt        string `json:"salt"`
+	MemoryKiB   uint32 `json:"memory_kib"`
+	Iterations  uint32 `json:"iterations"`
+	Parallelism uint8  `json:"parallelism"`
+}
+
+type UserRegisterRequestEncoded struct {
+	Username           string         `json:"username"`
+	XPublicKey         string         `json:"x_pub_key"`
+	EPublicKey         string         `json:"e_pub_key"`
+	EncipheredSoul     string         `json:"enciphered_soul"`
+	EncipheredSoulSalt string         `json:"enciphered_soul_salt"`
+	EncipheredSoulTag  string         `json:"enciphered_soul_tag"`
+	SoulRecovery       string         `json:"soul_recovery"`
+	SoulRecoverySalt   string         `json:"soul_recovery_salt"`
+	SoulRecoveryTag    string         `json:"soul_recovery_tag"`
+	Signature          string         `json:"signature"`
+	SoulKDF            SoulKDFEncoded `json:"soul_kdf"`
+}
+
+type UserRegisterRequestDecoded struct {
+	Username           string
+	XPublicKey         []byte
+	EPublicKey         []byte
+	EncipheredSoul     []byte
+	EncipheredSoulSalt []byte
+	EncipheredSoulTag  []byte
+	SoulRecovery       []byte
+	SoulRecoverySalt   []byte
+	SoulRecoveryTag    []byte
+	Signature          []byte
+	SoulKDF            models.SoulKDF
+}
+
+type UserLoginRequestEncoded struct {
+	Username string `json:"username"`
+}
+
+type UserLoginProofRequestEncoded struct {
+	Username  string `json:"username"`
+	Signature string `json:"signature"`
+}
+
+type UserLoginProofRequestDecoded struct {
+	Username  string
+	Signature []byte
+}
+
+type UserSoulRequestEncoded struct {
+	EncipheredSoul     string         `json:"enciphered_soul"`
+	EncipheredSoulSalt string         `json:"enciphered_soul_salt"`
+	EncipheredSoulTag  string         `json:"enciphered_soul_tag"`
+	SoulRecovery       string         `json:"soul_recovery"`
+	SoulRecoverySalt   string         `json:"soul_recovery_salt"`
+	SoulRecoveryTag    string         `json:"soul_recovery_tag"`
+	SoulKDF            SoulKDFEncoded `json:"soul_kdf"`
+}
+
+type UserSoulRequestDecoded struct {
+	EncipheredSoul     []byte
+	EncipheredSoulSalt []byte
+	EncipheredSoulTag  []byte
+	SoulRecovery       []byte
+	SoulRecoverySalt   []byte
+	SoulRecoveryTag    []byte
+	SoulKDF            models.SoulKDF
+}
+
+type UserRecoveryRequestEncoded struct {
+	Username string `json:"username"`
+}
+
+type UserRecoveryResetRequestEncoded struct {
+	Username           string         `json:"username"`
+	Signature          string         `json:"signature"`
+	EncipheredSoul     string         `json:"enciphered_soul"`
+	EncipheredSoulSalt string         `json:"enciphered_soul_salt"`
+	EncipheredSoulTag  string         `json:"enciphered_soul_tag"`
+	SoulRecovery       string         `json:"soul_recovery"`
+	SoulRecoverySalt   string         `json:"soul_recovery_salt"`
+	SoulRecoveryTag    string         `json:"soul_recovery_tag"`
+	SoulKDF            SoulKDFEncoded `json:"soul_kdf"`
+}
+
+type UserRecoveryResetRequestDecoded struct {
+	Username           string
+	Signature          []byte
+	EncipheredSoul     []byte
+	EncipheredSoulSalt []byte
+	EncipheredSoulTag  []byte
+	SoulRecovery       []byte
+	SoulRecoverySalt   []byte
+	SoulRecoveryTag    []byte
+	SoulKDF            models.SoulKDF
+}
+
+type UserLookupRequestEncoded struct {
+	Username string `json:"username"`
+}
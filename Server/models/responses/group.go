@@ -0,0 +1,78 @@
+package models_responses
+
+import "github.com/MHSarmadi/Umbra/Proto/umbrapb"
+
+type WrappedGroupSecretEncoded struct {
+	XPublicKey string `json:"x_pub_key"`
+	Cipher     string `json:"cipher"`
+	Salt       string `json:"salt"`
+	Tag        string `json:"tag"`
+}
+
+type GroupEncoded struct {
+	UUID                      string `json:"uuid"`
+	XPublicKey                string `json:"x_pub_key"`
+	EPublicKey                string `json:"e_pub_key"`
+	EncipheredEntranceKey     string `json:"enciphered_entrance_key"`
+	EncipheredEntranceKeySalt string `json:"enciphered_entrance_key_salt"`
+	EncipheredEntranceKeyTag  string `json:"enciphered_entrance_key_tag"`
+	CreatorUUID               string `json:"creator_uuid"`
+	CreatedAt                 string `json:"created_at_unix_millisec"`
+}
+
+type GroupMembershipEncoded struct {
+	Group  GroupEncoded              `json:"group"`
+	Role   string                    `json:"role"`
+	Secret WrappedGroupSecretEncoded `json:"secret"`
+}
+
+func GroupMembershipFromProto(pb *umbrapb.GroupMembership) GroupMembershipEncoded {
+	group, secret := pb.GetGroup(), pb.GetSecret()
+	return GroupMembershipEncoded{
+		Group: GroupEncoded{
+			UUID:                      b64(group.GetUuid()),
+			XPublicKey:                b64(group.GetXPubKey()),
+			EPublicKey:                b64(group.GetEPubKey()),
+			EncipheredEntranceKey:     b64(group.GetEncipheredEntranceKey()),
+			EncipheredEntranceKeySalt: b64(group.GetEncipheredEntranceKeySalt()),
+			EncipheredEntranceKeyTag:  b64(group.GetEncipheredEntranceKeyTag()),
+			CreatorUUID:               b64(group.GetCreatorUuid()),
+			CreatedAt:                 b64Uint64(uint64(group.GetCreatedAtUnixMillisec())),
+		},
+		Role: pb.GetRole(),
+		Secret: WrappedGroupSecretEncoded{
+			XPublicKey: b64(secret.GetXPubKey()),
+			Cipher:     b64(secret.GetCipher()),
+			Salt:       b64(secret.GetSalt()),
+			Tag:        b64(secret.GetTag()),
+		},
+	}
+}
+
+type GroupCreateResponseEncoded struct {
+	Status string `json:"status"`
+	UUID   string `json:"uuid"`
+}
+
+func GroupCreateResponseFromProto(pb *umbrapb.GroupCreateResponse) GroupCreateResponseEncoded {
+	return GroupCreateResponseEncoded{
+		Status: pb.GetStatus(),
+		UUID:   b64(pb.GetUuid()),
+	}
+}
+
+type GroupListResponseEncoded struct {
+	Status string                   `json:"status"`
+	Groups []GroupMembershipEncoded `json:"groups"`
+}
+
+func GroupListResponseFromProto(pb *umbrapb.GroupListResponse) GroupListResponseEncoded {
+	groups := make([]GroupMembershipEncoded, 0, len(pb.GetGroups()))
+	for _, membership := range pb.GetGroups() {
+		groups = append(groups, GroupMembershipFromProto(membership))
+	}
+	return GroupListResponseEncoded{
+		Status: pb.GetStatus(),
+		Groups: groups,
+	}
+}
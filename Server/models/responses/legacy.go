@@ -0,0 +1,248 @@
+package models_responses
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/models"
+)
+
+// The types in this package are the legacy JSON shapes of the umbrapb
+// responses, kept so existing frontends keep working while they move to
+// ProtoBuf. Byte fields are unpadded standard base64 and 64-bit integers are
+// base64 of their 8 big-endian bytes, exactly as before the schema existed.
+
+var b64 = base64.RawStdEncoding.EncodeToString
+
+func b64Uint64(v uint64) string {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return b64(buf[:])
+}
+
+type StatusResponseEncoded struct {
+	Status string `json:"status"`
+}
+
+func StatusResponseFromProto(pb *umbrapb.StatusResponse) StatusResponseEncoded {
+	return StatusResponseEncoded{
+		Status: pb.GetStatus(),
+	}
+}
+
+type SessionInitResponseEncoded struct {
+	Status                 string `json:"status"`
+	ServerEdPubKey         string `json:"server_ed_pubkey"`
+	ServerXPubKey          string `json:"server_x_pubkey"`
+	ServerXPubKeySignature string `json:"server_x_pubkey_sign"`
+	Payload                string `json:"payload"`
+	Signature              string `json:"signature"`
+	ExpiresAt              string `json:"expiry_unix_millisec"`
+}
+
+func SessionInitResponseFromProto(pb *umbrapb.SessionInitResponse) SessionInitResponseEncoded {
+	return SessionInitResponseEncoded{
+		Status:                 pb.GetStatus(),
+		ServerEdPubKey:         b64(pb.GetServerEdPubkey()),
+		ServerXPubKey:          b64(pb.GetServerXPubkey()),
+		ServerXPubKeySignature: b64(pb.GetServerXPubkeySign()),
+		Payload:                b64(pb.GetPayload()),
+		Signature:              b64(pb.GetSignature()),
+		ExpiresAt:              b64Uint64(pb.GetExpiryUnixMillisec()),
+	}
+}
+
+type SessionInitPayloadEncoded struct {
+	SessionUUID               string               `json:"session_id"`
+	CaptchaChallenge          string               `json:"captcha_challenge"`
+	PoWChallenge              string               `json:"pow_challenge"`
+	PowParams                 models.PowParamsType `json:"pow_params"`
+	PoWSalt                   string               `json:"pow_salt"`
+	SessionToken              string               `json:"session_token_ciphered"`
+	SessionTokenCipherKeySalt string               `json:"session_token_cipher_key_salt"`
+}
+
+func SessionInitPayloadFromProto(pb *umbrapb.SessionInitPayload) SessionInitPayloadEncoded {
+	return SessionInitPayloadEncoded{
+		SessionUUID:      b64(pb.GetSessionId()),
+		CaptchaChallenge: b64(pb.GetCaptchaChallenge()),
+		PoWChallenge:     b64(pb.GetPowChallenge()),
+		PowParams: models.PowParamsType{
+			MemoryMB:    uint(pb.GetPowParams().GetMemoryMb()),
+			Iterations:  uint(pb.GetPowParams().GetIterations()),
+			Parallelism: uint(pb.GetPowParams().GetParallelism()),
+		},
+		PoWSalt:                   b64(pb.GetPowSalt()),
+		SessionToken:              b64(pb.GetSessionTokenCiphered()),
+		SessionTokenCipherKeySalt: b64(pb.GetSessionTokenCipherKeySalt()),
+	}
+}
+
+type SessionPoWResponseEncoded struct {
+	Status          string `json:"status"`
+	ActivationNonce string `json:"activation_nonce"`
+}
+
+func SessionPoWResponseFromProto(pb *umbrapb.SessionPoWResponse) SessionPoWResponseEncoded {
+	return SessionPoWResponseEncoded{
+		Status:          pb.GetStatus(),
+		ActivationNonce: b64(pb.GetActivationNonce()),
+	}
+}
+
+type SessionPingResponseEncoded struct {
+	State     string `json:"state"`
+	ExpiresAt string `json:"expiry_unix_millisec"`
+}
+
+func SessionPingResponseFromProto(pb *umbrapb.SessionPingResponse) SessionPingResponseEncoded {
+	return SessionPingResponseEncoded{
+		State:     pb.GetState(),
+		ExpiresAt: b64Uint64(pb.GetExpiryUnixMillisec()),
+	}
+}
+
+type EnvelopeResponseEncoded struct {
+	Status    string `json:"status"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+func EnvelopeResponseFromProto(pb *umbrapb.EnvelopeResponse) EnvelopeResponseEncoded {
+	return EnvelopeResponseEncoded{
+		Status:    pb.GetStatus(),
+		Payload:   b64(pb.GetPayload()),
+		Signature: b64(pb.GetSignature()),
+	}
+}
+
+type UserRegisterResponseEncoded struct {
+	Status string `json:"status"`
+	UUID   string `json:"uuid"`
+}
+
+func UserRegisterResponseFromProto(pb *umbrapb.UserRegisterResponse) UserRegisterResponseEncoded {
+	return UserRegisterResponseEncoded{
+		Status: pb.GetStatus(),
+		UUID:   b64(pb.GetUuid()),
+	}
+}
+
+type SoulKDFEncoded struct {
+	Version     uint8  `json:"version"`
+	Salt        string `json:"salt"`
+	MemoryKiB   uint32 `json:"memory_kib"`
+	Iterations  uint32 `json:"iterations"`
+	Parallelism uint8  `json:"parallelism"`
+}
+
+func SoulKDFFromProto(pb *umbrapb.SoulKDF) SoulKDFEncoded {
+	return SoulKDFEncoded{
+		Version:     uint8(pb.GetVersion()),
+		Salt:        b64(pb.GetSalt()),
+		MemoryKiB:   pb.GetMemoryKib(),
+		Iterations:  pb.GetIterations(),
+		Parallelism: uint8(pb.GetParallelism()),
+	}
+}
+
+type UserKDFResponseEncoded struct {
+	Status  string         `json:"status"`
+	SoulKDF SoulKDFEncoded `json:"soul_kdf"`
+}
+
+func UserKDFResponseFromProto(pb *umbrapb.UserKDFResponse) UserKDFResponseEncoded {
+	return UserKDFResponseEncoded{
+		Status:  pb.GetStatus(),
+		SoulKDF: SoulKDFFromProto(pb.GetSoulKdf()),
+	}
+}
+
+type UserLoginResponseEncoded struct {
+	Status             string          `json:"status"`
+	UUID               string          `json:"uuid"`
+	EncipheredSoul     string          `json:"enciphered_soul"`
+	EncipheredSoulSalt string          `json:"enciphered_soul_salt"`
+	EncipheredSoulTag  string          `json:"enciphered_soul_tag"`
+	SoulKDF            SoulKDFEncoded  `json:"soul_kdf"`
+	UpgradeRequired    bool            `json:"upgrade_required"`
+	UpgradeKDF         *SoulKDFEncoded `json:"upgrade_kdf,omitempty"`
+}
+
+func UserLoginResponseFromProto(pb *umbrapb.UserLoginResponse) UserLoginResponseEncoded {
+	encoded := UserLoginResponseEncoded{
+		Status:             pb.GetStatus(),
+		UUID:               b64(pb.GetUuid()),
+		EncipheredSoul:     b64(pb.GetEncipheredSoul()),
+		EncipheredSoulSalt: b64(pb.GetEncipheredSoulSalt()),
+		EncipheredSoulTag:  b64(pb.GetEncipheredSoulTag()),
+		SoulKDF:            SoulKDFFromProto(pb.GetSoulKdf()),
+		UpgradeRequired:    pb.GetUpgradeRequired(),
+	}
+	if pb.GetUpgradeKdf() != nil {
+		upgrade_kdf := SoulKDFFromProto(pb.GetUpgradeKdf())
+		encoded.UpgradeKDF = &upgrade_kdf
+	}
+	return encoded
+}
+
+type UserRecoveryResponseEncoded struct {
+	Status             string         `json:"status"`
+	UUID               string         `json:"uuid"`
+	EncipheredSoul     string         `json:"enciphered_soul"`
+	EncipheredSoulSalt string         `json:"enciphered_soul_salt"`
+	EncipheredSoulTag  string         `json:"enciphered_soul_tag"`
+	SoulRecovery       string         `json:"soul_recovery"`
+	SoulRecoverySalt   string         `json:"soul_recovery_salt"`
+	SoulRecoveryTag    string         `json:"soul_recovery_tag"`
+	NewSoulKDF         SoulKDFEncoded `json:"new_soul_kdf"`
+}
+
+func UserRecoveryResponseFromProto(pb *umbrapb.UserRecoveryResponse) UserRecoveryResponseEncoded {
+	return UserRecoveryResponseEncoded{
+		Status:             pb.GetStatus(),
+		UUID:               b64(pb.GetUuid()),
+		EncipheredSoul:     b64(pb.GetEncipheredSoul()),
+		EncipheredSoulSalt: b64(pb.GetEncipheredSoulSalt()),
+		EncipheredSoulTag:  b64(pb.GetEncipheredSoulTag()),
+		SoulRecovery:       b64(pb.GetSoulRecovery()),
+		SoulRecoverySalt:   b64(pb.GetSoulRecoverySalt()),
+		SoulRecoveryTag:    b64(pb.GetSoulRecoveryTag()),
+		NewSoulKDF:         SoulKDFFromProto(pb.GetNewSoulKdf()),
+	}
+}
+
+type UserRecoveryResetResponseEncoded struct {
+	Status           string `json:"status"`
+	UUID             string `json:"uuid"`
+	SoulRecovery     string `json:"soul_recovery"`
+	SoulRecoverySalt string `json:"soul_recovery_salt"`
+	SoulRecoveryTag  string `json:"soul_recovery_tag"`
+}
+
+func UserRecoveryResetResponseFromProto(pb *umbrapb.UserRecoveryResetResponse) UserRecoveryResetResponseEncoded {
+	return UserRecoveryResetResponseEncoded{
+		Status:           pb.GetStatus(),
+		UUID:             b64(pb.GetUuid()),
+		SoulRecovery:     b64(pb.GetSoulRecovery()),
+		SoulRecoverySalt: b64(pb.GetSoulRecoverySalt()),
+		SoulRecoveryTag:  b64(pb.GetSoulRecoveryTag()),
+	}
+}
+
+type UserLookupResponseEncoded struct {
+	Status     string `json:"status"`
+	UUID       string `json:"uuid"`
+	XPublicKey string `json:"x_pub_key"`
+	EPublicKey string `json:"e_pub_key"`
+}
+
+func UserLookupResponseFromProto(pb *umbrapb.UserLookupResponse) UserLookupResponseEncoded {
+	return UserLookupResponseEncoded{
+		Status:     pb.GetStatus(),
+		UUID:       b64(pb.GetUuid()),
+		XPublicKey: b64(pb.GetXPubKey()),
+		EPublicKey: b64(pb.GetEPubKey()),
+	}
+}
@@ -0,0 +1,59 @@
+package models_responses
+
+import "github.com/MHSarmadi/Umbra/Proto/umbrapb"
+
+type ChatMessageEncoded struct {
+	UUID            string `json:"uuid"`
+	GroupUUID       string `json:"group_uuid"`
+	XPublicKey      string `json:"x_pub_key"`
+	Payload         string `json:"payload"`
+	SenderUUID      string `json:"sender_uuid"`
+	SenderSignature string `json:"sender_signature"`
+	Nonce           string `json:"nonce"`
+	SentAt          string `json:"sent_at_unix_millisec"`
+	CreatedAt       string `json:"created_at_unix_millisec"`
+}
+
+func ChatMessageFromProto(pb *umbrapb.ChatMessage) ChatMessageEncoded {
+	return ChatMessageEncoded{
+		UUID:            b64(pb.GetUuid()),
+		GroupUUID:       b64(pb.GetGroupUuid()),
+		XPublicKey:      b64(pb.GetXPubKey()),
+		Payload:         b64(pb.GetPayload()),
+		SenderUUID:      b64(pb.GetSenderUuid()),
+		SenderSignature: b64(pb.GetSenderSignature()),
+		Nonce:           b64(pb.GetNonce()),
+		SentAt:          b64Uint64(uint64(pb.GetSentAtUnixMillisec())),
+		CreatedAt:       b64Uint64(uint64(pb.GetCreatedAtUnixMillisec())),
+	}
+}
+
+type ChatMessageSendResponseEncoded struct {
+	Status  string             `json:"status"`
+	Message ChatMessageEncoded `json:"message"`
+}
+
+func ChatMessageSendResponseFromProto(pb *umbrapb.ChatMessageSendResponse) ChatMessageSendResponseEncoded {
+	return ChatMessageSendResponseEncoded{
+		Status:  pb.GetStatus(),
+		Message: ChatMessageFromProto(pb.GetMessage()),
+	}
+}
+
+type ChatHistoryResponseEncoded struct {
+	Status     string               `json:"status"`
+	Messages   []ChatMessageEncoded `json:"messages"`
+	NextCursor string               `json:"next_cursor"`
+}
+
+func ChatHistoryResponseFromProto(pb *umbrapb.ChatHistoryResponse) ChatHistoryResponseEncoded {
+	messages := make([]ChatMessageEncoded, 0, len(pb.GetMessages()))
+	for _, message := range pb.GetMessages() {
+		messages = append(messages, ChatMessageFromProto(message))
+	}
+	return ChatHistoryResponseEncoded{
+		Status:     pb.GetStatus(),
+		Messages:   messages,
+		NextCursor: b64(pb.GetNextCursor()),
+	}
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+)
 
 type PowParamsType struct {
 	MemoryMB    uint `json:"memory_mb"`
@@ -8,9 +12,19 @@ type PowParamsType struct {
 	Parallelism uint `json:"parallelism"`
 }
 
+type SessionState string
+
+const (
+	SessionStatePending SessionState = "pending"
+	SessionStateActive  SessionState = "active"
+)
+
 type Session struct {
 	UUID [24]byte `json:"uuid"`
 
+	State       SessionState `json:"state"`
+	ActivatedAt time.Time    `json:"activated_at"`
+
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 
@@ -29,8 +43,25 @@ type Session struct {
 	PoWParams    PowParamsType `json:"pow_params"`
 	PoWSalt      [12]byte      `json:"pow_salt"`
 	PoWSolution  []byte        `json:"pow_solution"`
+	PoWAttempted bool          `json:"pow_attempted"`
+
+	ActivationNonce    []byte `json:"activation_nonce"`
+	ActivationAttempts uint8  `json:"activation_attempts"`
+
+	UserUUID []byte `json:"user_uuid"` // set once the client proved it holds a user soul
+}
+
+func (u *Session) PoWSolved() bool {
+	return len(u.PoWSolution) > 0
+}
+
+// IsActive reports whether the session finished both the PoW and the captcha
+// handshake. Sessions stored before the lifecycle existed have no state and
+// are treated as pending.
+func (u *Session) IsActive() bool {
+	return u.State == SessionStateActive
 }
 
 func (u *Session) KeyByUUID() []byte {
-	return append([]byte{0x10}, u.UUID[:]...)
+	return keyspace.Session(u.UUID)
 }
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+)
 
 type SessionInitTracker struct {
 	IdentityHash  string    `json:"identity_hash"`
@@ -9,5 +13,5 @@ type SessionInitTracker struct {
 }
 
 func (t *SessionInitTracker) Key() []byte {
-	return append([]byte{0x12}, []byte(t.IdentityHash)...)
+	return keyspace.SessionInitTracker(t.IdentityHash)
 }
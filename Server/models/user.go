@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/database/keyspace"
+)
 
 type User struct {
 	UUID               []byte    `json:"uuid"`
@@ -13,12 +17,31 @@ type User struct {
 	SoulRecovery       []byte    `json:"soul_recovery"`
 	SoulRecoverySalt   []byte    `json:"soul_recovery_salt"`
 	SoulRecoveryTag    []byte    `json:"soul_recovery_tag"`
+	SoulKDF            SoulKDF   `json:"soul_kdf"`
 	CreatedAt          time.Time `json:"created_at"`
+	DisabledAt         time.Time `json:"disabled_at,omitempty"`
+}
+
+// Disabled reports whether an operator has disabled the account. A disabled
+// user can neither log in, recover nor act through an existing session.
+func (u *User) Disabled() bool {
+	return !u.DisabledAt.IsZero()
+}
+
+// SoulKDF describes how the soul key was derived from the password. Version 0
+// is the legacy single BLAKE3 hash and carries no parameters; every later
+// version is Argon2id with the listed salt and cost.
+type SoulKDF struct {
+	Version     uint8  `json:"version"`
+	Salt        []byte `json:"salt,omitempty"`
+	MemoryKiB   uint32 `json:"memory_kib,omitempty"`
+	Iterations  uint32 `json:"iterations,omitempty"`
+	Parallelism uint8  `json:"parallelism,omitempty"`
 }
 
 func (u *User) KeyByUUID() []byte {
-	return append([]byte{0x10}, u.UUID...)
+	return keyspace.User(u.UUID)
 }
 func (u *User) KeyByUsername() []byte {
-	return append([]byte{0x11}, []byte(u.Username)...)
+	return keyspace.Username(u.Username)
 }
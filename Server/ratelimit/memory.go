@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often Memory drops expired buckets.
+const memorySweepInterval = time.Minute
+
+// Memory keeps buckets in process memory. It writes nothing to disk and costs
+// no store transaction per request, but every bucket is forgotten on restart
+// and each server process counts on its own.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]memoryBucket
+	nextSweep time.Time
+}
+
+type memoryBucket struct {
+	state      []byte
+	expires_at time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]memoryBucket)}
+}
+
+func (m *Memory) UpdateRateLimit(ctx context.Context, bucket string, now time.Time, update func(state []byte) (next []byte, expires_at time.Time)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.After(m.nextSweep) {
+		for key, b := range m.buckets {
+			if now.After(b.expires_at) {
+				delete(m.buckets, key)
+			}
+		}
+		m.nextSweep = now.Add(memorySweepInterval)
+	}
+
+	var state []byte
+	if b, ok := m.buckets[bucket]; ok && !now.After(b.expires_at) {
+		state = b.state
+	}
+	next, expires_at := update(state)
+	m.buckets[bucket] = memoryBucket{state: next, expires_at: expires_at}
+	return nil
+}
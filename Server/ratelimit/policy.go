@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// State tags, so a bucket left over from a policy of the other kind starts
+// afresh instead of being misread.
+const (
+	tagSlidingWindow byte = 'w'
+	tagTokenBucket   byte = 'b'
+)
+
+// SlidingWindow allows Limit requests per Window. It approximates a true
+// sliding window from two fixed ones: the previous window's count weighs in
+// by how much of it still overlaps the sliding window.
+type SlidingWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+type windowState struct {
+	start      int64 // unix nanoseconds the current window began at
+	prev, curr uint64
+}
+
+func decodeWindow(state []byte) (windowState, bool) {
+	if len(state) == 0 || state[0] != tagSlidingWindow {
+		return windowState{}, false
+	}
+	var s windowState
+	rest := state[1:]
+	var n int
+	if s.start, n = binary.Varint(rest); n <= 0 {
+		return windowState{}, false
+	}
+	rest = rest[n:]
+	if s.prev, n = binary.Uvarint(rest); n <= 0 {
+		return windowState{}, false
+	}
+	rest = rest[n:]
+	if s.curr, n = binary.Uvarint(rest); n <= 0 {
+		return windowState{}, false
+	}
+	return s, true
+}
+
+func (s windowState) encode() []byte {
+	buf := []byte{tagSlidingWindow}
+	buf = binary.AppendVarint(buf, s.start)
+	buf = binary.AppendUvarint(buf, s.prev)
+	return binary.AppendUvarint(buf, s.curr)
+}
+
+func (p SlidingWindow) apply(state []byte, now time.Time) ([]byte, time.Time, Decision) {
+	window := int64(p.Window)
+	s, ok := decodeWindow(state)
+	if !ok {
+		s = windowState{start: now.UnixNano()}
+	}
+	if passed := (now.UnixNano() - s.start) / window; passed > 0 {
+		if passed == 1 {
+			s.prev = s.curr
+		} else {
+			s.prev = 0
+		}
+		s.curr = 0
+		s.start += passed * window
+	}
+
+	elapsed := time.Duration(now.UnixNano() - s.start)
+	weight := 1 - float64(elapsed)/float64(p.Window)
+	estimate := float64(s.prev)*weight + float64(s.curr)
+
+	d := Decision{Limit: p.Limit}
+	if estimate+1 <= float64(p.Limit) {
+		s.curr++
+		d.Allowed = true
+		d.Remaining = int(float64(p.Limit) - estimate - 1)
+	} else {
+		d.RetryAfter = p.retryAfter(s, elapsed)
+	}
+
+	start := time.Unix(0, s.start)
+	switch {
+	case s.curr > 0:
+		d.Reset = start.Add(2 * p.Window).Sub(now)
+	case s.prev > 0:
+		d.Reset = start.Add(p.Window).Sub(now)
+	}
+	return s.encode(), start.Add(2 * p.Window), d
+}
+
+// retryAfter is how long until the estimate leaves room for one more request:
+// either later in this window, as the previous one's weight decays, or in
+// the next one, once this window's count has decayed enough.
+func (p SlidingWindow) retryAfter(s windowState, elapsed time.Duration) time.Duration {
+	room := float64(p.Limit-1) - float64(s.curr)
+	if s.prev > 0 && room >= 0 {
+		at := time.Duration(float64(p.Window) * (1 - room/float64(s.prev)))
+		if at < p.Window {
+			return at - elapsed
+		}
+	}
+	var at time.Duration
+	if s.curr > 0 {
+		at = time.Duration(float64(p.Window) * (1 - float64(p.Limit-1)/float64(s.curr)))
+	}
+	return p.Window - elapsed + max(at, 0)
+}
+
+func (p SlidingWindow) Header() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int64(math.Ceil(p.Window.Seconds())))
+}
+
+func (p SlidingWindow) String() string {
+	return fmt.Sprintf("window:%d/%s", p.Limit, formatDuration(p.Window))
+}
+
+// TokenBucket refills Rate tokens every Per, up to Burst, and lets a request
+// through for each whole token. A zero Burst means Rate.
+type TokenBucket struct {
+	Rate  int
+	Per   time.Duration
+	Burst int
+}
+
+func (p TokenBucket) burst() int {
+	if p.Burst == 0 {
+		return p.Rate
+	}
+	return p.Burst
+}
+
+// interval is how long one token takes to refill.
+func (p TokenBucket) interval() time.Duration {
+	return p.Per / time.Duration(p.Rate)
+}
+
+func (p TokenBucket) apply(state []byte, now time.Time) ([]byte, time.Time, Decision) {
+	burst := float64(p.burst())
+	interval := p.interval()
+
+	tokens, last := burst, now.UnixNano()
+	if len(state) > 9 && state[0] == tagTokenBucket {
+		if v, n := binary.Varint(state[9:]); n > 0 {
+			tokens, last = math.Float64frombits(binary.BigEndian.Uint64(state[1:9])), v
+		}
+	}
+	if elapsed := now.UnixNano() - last; elapsed > 0 {
+		tokens = math.Min(burst, tokens+float64(elapsed)/float64(interval))
+	}
+
+	d := Decision{Limit: p.burst()}
+	if tokens >= 1 {
+		tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = time.Duration((1 - tokens) * float64(interval))
+	}
+	d.Remaining = int(tokens)
+	d.Reset = time.Duration((burst - tokens) * float64(interval))
+
+	buf := make([]byte, 9, 9+binary.MaxVarintLen64)
+	buf[0] = tagTokenBucket
+	binary.BigEndian.PutUint64(buf[1:], math.Float64bits(tokens))
+	buf = binary.AppendVarint(buf, now.UnixNano())
+	// A full bucket is the same as no bucket, so the state can go then.
+	return buf, now.Add(d.Reset), d
+}
+
+func (p TokenBucket) Header() string {
+	return fmt.Sprintf("%d;w=%d;burst=%d", p.Rate, int64(math.Ceil(p.Per.Seconds())), p.burst())
+}
+
+func (p TokenBucket) String() string {
+	if p.Burst == 0 {
+		return fmt.Sprintf("bucket:%d/%s", p.Rate, formatDuration(p.Per))
+	}
+	return fmt.Sprintf("bucket:%d/%s:%d", p.Rate, formatDuration(p.Per), p.Burst)
+}
+
+// formatDuration drops the zero units time.Duration.String leaves in, so 10m
+// prints as 10m rather than 10m0s.
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// step is one request against a policy, at an offset from the first.
+type step struct {
+	at         time.Duration
+	allowed    bool
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+func runSteps(t *testing.T, p Policy, steps []step) {
+	t.Helper()
+	t0 := time.Unix(1000, 0)
+	var state []byte
+	for i, s := range steps {
+		next, _, d := p.apply(state, t0.Add(s.at))
+		state = next
+		if d.Allowed != s.allowed || d.Remaining != s.remaining || d.Reset != s.reset || d.RetryAfter != s.retryAfter {
+			t.Fatalf("step %d at +%s: got allowed=%v remaining=%d reset=%s retry_after=%s, want %v %d %s %s",
+				i, s.at, d.Allowed, d.Remaining, d.Reset, d.RetryAfter, s.allowed, s.remaining, s.reset, s.retryAfter)
+		}
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	p := SlidingWindow{Limit: 3, Window: 10 * time.Second}
+	runSteps(t, p, []step{
+		{0, true, 2, 20 * time.Second, 0},
+		{time.Second, true, 1, 19 * time.Second, 0},
+		{2 * time.Second, true, 0, 18 * time.Second, 0},
+		// Full: the estimate has room again once this window's count of 3
+		// weighs in at no more than 2, a third into the next window.
+		{3 * time.Second, false, 0, 17 * time.Second, 7*time.Second + 3333333333},
+		// Rolled over: the previous window's 3 still weigh 0.7.
+		{13 * time.Second, false, 0, 7 * time.Second, 333333333},
+		{14 * time.Second, true, 0, 16 * time.Second, 0},
+		// Two windows on, the previous count no longer overlaps at all.
+		{35 * time.Second, true, 2, 15 * time.Second, 0},
+	})
+}
+
+func TestSlidingWindowState(t *testing.T) {
+	p := SlidingWindow{Limit: 1, Window: time.Minute}
+	t0 := time.Unix(1000, 0)
+	state, expires_at, d := p.apply(nil, t0)
+	if !d.Allowed || !expires_at.Equal(t0.Add(2*time.Minute)) {
+		t.Fatalf("fresh window: allowed=%v expires_at=%s", d.Allowed, expires_at)
+	}
+	if _, _, d := p.apply(state, t0.Add(time.Second)); d.Allowed {
+		t.Fatal("second request of a window of one allowed")
+	}
+
+	bucket_state, _, _ := TokenBucket{Rate: 1, Per: time.Minute}.apply(nil, t0)
+	if _, _, d := p.apply(bucket_state, t0.Add(time.Second)); !d.Allowed {
+		t.Fatal("token bucket state not treated as a fresh window")
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	p := TokenBucket{Rate: 2, Per: time.Second, Burst: 4}
+	runSteps(t, p, []step{
+		{0, true, 3, 500 * time.Millisecond, 0},
+		{0, true, 2, time.Second, 0},
+		{0, true, 1, 1500 * time.Millisecond, 0},
+		{0, true, 0, 2 * time.Second, 0},
+		{0, false, 0, 2 * time.Second, 500 * time.Millisecond},
+		{250 * time.Millisecond, false, 0, 1750 * time.Millisecond, 250 * time.Millisecond},
+		{500 * time.Millisecond, true, 0, 2 * time.Second, 0},
+		// A long idle refills no further than the burst.
+		{time.Hour, true, 3, 500 * time.Millisecond, 0},
+	})
+
+	t0 := time.Unix(1000, 0)
+	_, expires_at, d := TokenBucket{Rate: 2, Per: time.Second}.apply(nil, t0)
+	if d.Limit != 2 || d.Remaining != 1 || !expires_at.Equal(t0.Add(500*time.Millisecond)) {
+		t.Fatalf("zero burst: limit=%d remaining=%d expires_at=%s, want 2 1 %s", d.Limit, d.Remaining, expires_at, t0.Add(500*time.Millisecond))
+	}
+}
+
+func TestPolicyHeader(t *testing.T) {
+	for _, tc := range []struct {
+		policy Policy
+		header string
+	}{
+		{SlidingWindow{Limit: 20, Window: time.Minute}, "20;w=60"},
+		{SlidingWindow{Limit: 1, Window: 1500 * time.Millisecond}, "1;w=2"},
+		{TokenBucket{Rate: 5, Per: time.Second, Burst: 20}, "5;w=1;burst=20"},
+		{TokenBucket{Rate: 5, Per: time.Second}, "5;w=1;burst=5"},
+	} {
+		if got := tc.policy.Header(); got != tc.header {
+			t.Errorf("%s header %q, want %q", tc.policy, got, tc.header)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package ratelimit counts requests per key against a policy, a sliding
+// window or a token bucket, and keeps the per-key state in a Backend.
+//
+// A policy's state is a few bytes per key whatever the limit, unlike the
+// session-init and recovery trackers, which keep one timestamp per request
+// because their counts feed the proof-of-work cost and the recovery cooldown.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Backend keeps the state of every bucket. database.Store implements it, so
+// buckets can live next to the rest of the data; Memory keeps them in
+// process.
+type Backend interface {
+	// UpdateRateLimit hands update the state of bucket, nil when there is
+	// none or it expired by now, and stores what update returns until
+	// expires_at. Updates of one bucket must be serialized.
+	UpdateRateLimit(ctx context.Context, bucket string, now time.Time, update func(state []byte) (next []byte, expires_at time.Time)) error
+}
+
+// Decision is the outcome of one request against a policy.
+type Decision struct {
+	Allowed bool
+	// Limit is the most requests the policy lets through at once.
+	Limit int
+	// Remaining is how many more requests would be allowed right now.
+	Remaining int
+	// Reset is how long until the bucket is back at Limit.
+	Reset time.Duration
+	// RetryAfter is how long a denied client should wait; zero if allowed.
+	RetryAfter time.Duration
+}
+
+// Policy is SlidingWindow or TokenBucket.
+type Policy interface {
+	// apply counts one request at now against state, nil for a fresh
+	// bucket, and returns the next state, when that state can be forgotten
+	// and the decision. A denied request is not counted.
+	apply(state []byte, now time.Time) (next []byte, expires_at time.Time, d Decision)
+	// Header renders the policy for the RateLimit-Policy response header.
+	Header() string
+	String() string
+}
+
+// Limiter applies one policy to keys of one namespace, so the same client key
+// counts separately on every route.
+type Limiter struct {
+	backend Backend
+	name    string
+	policy  Policy
+}
+
+func New(backend Backend, name string, policy Policy) *Limiter {
+	return &Limiter{backend: backend, name: name, policy: policy}
+}
+
+func (l *Limiter) Policy() Policy {
+	return l.policy
+}
+
+// Allow counts a request from key at now.
+func (l *Limiter) Allow(ctx context.Context, key string, now time.Time) (Decision, error) {
+	var d Decision
+	err := l.backend.UpdateRateLimit(ctx, l.name+"\x00"+key, now, func(state []byte) ([]byte, time.Time) {
+		next, expires_at, decision := l.policy.apply(state, now)
+		d = decision
+		return next, expires_at
+	})
+	return d, err
+}
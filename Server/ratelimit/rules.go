@@ -0,0 +1,211 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyKind is what a rule counts requests by.
+type KeyKind string
+
+const (
+	// KeyIP counts by client identity, which puts an IPv6 client's whole
+	// network in one bucket, or by the networks the rule's prefixes give.
+	KeyIP KeyKind = "ip"
+	// KeySession counts by the session of the request envelope.
+	KeySession KeyKind = "session"
+	// KeyUser counts by the user the session is logged in as, and by the
+	// session while it is not logged in.
+	KeyUser KeyKind = "user"
+)
+
+const (
+	BackendStore  = "store"
+	BackendMemory = "memory"
+)
+
+// Config selects where buckets live and which routes are limited. With
+// BackendStore, buckets live in the data store and survive restarts; with
+// BackendMemory they stay in process.
+type Config struct {
+	Backend string
+	Rules   Rules
+}
+
+func DefaultConfig() Config {
+	return Config{Backend: BackendStore}
+}
+
+func (c Config) Validate() error {
+	if c.Backend != BackendStore && c.Backend != BackendMemory {
+		return fmt.Errorf("unknown rate limit backend %q; use %s or %s", c.Backend, BackendStore, BackendMemory)
+	}
+	return c.Rules.validate()
+}
+
+// Rule limits one route, named by its path template, by one kind of key.
+type Rule struct {
+	Route string
+	Key   KeyKind
+	// IPv4Prefix and IPv6Prefix make a KeyIP rule count whole networks;
+	// zero counts single addresses.
+	IPv4Prefix int
+	IPv6Prefix int
+	Policy     Policy
+}
+
+// AddressKey is the key addr counts under for a KeyIP rule.
+func (r Rule) AddressKey(addr netip.Addr) string {
+	addr = addr.Unmap()
+	bits := r.IPv6Prefix
+	if addr.Is4() {
+		bits = r.IPv4Prefix
+	}
+	if bits == 0 {
+		return addr.String()
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return addr.String()
+	}
+	return prefix.String()
+}
+
+// String renders the rule the way Rules reads it:
+//
+//	ROUTE KEY POLICY
+//
+// KEY is ip, ip/V4BITS/V6BITS, session or user. POLICY is window:LIMIT/WINDOW
+// or bucket:RATE/PER with an optional :BURST.
+func (r Rule) String() string {
+	key := string(r.Key)
+	if r.Key == KeyIP && (r.IPv4Prefix != 0 || r.IPv6Prefix != 0) {
+		key = fmt.Sprintf("ip/%d/%d", r.IPv4Prefix, r.IPv6Prefix)
+	}
+	return r.Route + " " + key + " " + r.Policy.String()
+}
+
+// Rules is the per-route configuration. As text it is a list of rules
+// separated by semicolons, for example
+//
+//	/user/login ip/24/64 window:20/1m; /group/messages/send user bucket:5/1s:20
+type Rules []Rule
+
+func (rs Rules) String() string {
+	parts := make([]string, len(rs))
+	for i, r := range rs {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (rs Rules) MarshalText() ([]byte, error) {
+	return []byte(rs.String()), nil
+}
+
+func (rs *Rules) UnmarshalText(text []byte) error {
+	var parsed Rules
+	for _, entry := range strings.Split(string(text), ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		rule, err := parseRule(entry)
+		if err != nil {
+			return fmt.Errorf("rate limit rule %q: %w", strings.TrimSpace(entry), err)
+		}
+		parsed = append(parsed, rule)
+	}
+	if err := parsed.validate(); err != nil {
+		return err
+	}
+	*rs = parsed
+	return nil
+}
+
+func (rs Rules) validate() error {
+	seen := make(map[string]bool, len(rs))
+	for _, r := range rs {
+		id := r.Route + " " + string(r.Key)
+		if seen[id] {
+			return fmt.Errorf("rate limit rule for %s by %s given twice", r.Route, r.Key)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func parseRule(entry string) (Rule, error) {
+	fields := strings.Fields(entry)
+	if len(fields) != 3 {
+		return Rule{}, errors.New("want ROUTE KEY POLICY")
+	}
+	rule := Rule{Route: fields[0]}
+	if !strings.HasPrefix(rule.Route, "/") {
+		return Rule{}, errors.New("route must be a path template starting with /")
+	}
+
+	switch key := fields[1]; {
+	case key == string(KeySession), key == string(KeyUser), key == string(KeyIP):
+		rule.Key = KeyKind(key)
+	case strings.HasPrefix(key, "ip/"):
+		v4, v6, ok := strings.Cut(strings.TrimPrefix(key, "ip/"), "/")
+		var err4, err6 error
+		rule.IPv4Prefix, err4 = strconv.Atoi(v4)
+		rule.IPv6Prefix, err6 = strconv.Atoi(v6)
+		if !ok || err4 != nil || err6 != nil {
+			return Rule{}, fmt.Errorf("key %q: want ip/V4BITS/V6BITS", key)
+		}
+		if rule.IPv4Prefix < 0 || rule.IPv4Prefix > 32 || rule.IPv6Prefix < 0 || rule.IPv6Prefix > 128 {
+			return Rule{}, fmt.Errorf("key %q: prefix lengths out of range", key)
+		}
+		rule.Key = KeyIP
+	default:
+		return Rule{}, fmt.Errorf("unknown key %q; use ip, ip/V4BITS/V6BITS, session or user", key)
+	}
+
+	policy, err := parsePolicy(fields[2])
+	if err != nil {
+		return Rule{}, err
+	}
+	rule.Policy = policy
+	return rule, nil
+}
+
+func parsePolicy(text string) (Policy, error) {
+	kind, spec, _ := strings.Cut(text, ":")
+	count, rest, ok := strings.Cut(spec, "/")
+	if !ok {
+		return nil, fmt.Errorf("policy %q: want window:LIMIT/WINDOW or bucket:RATE/PER[:BURST]", text)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("policy %q: count must be a positive integer", text)
+	}
+
+	switch kind {
+	case "window":
+		window, err := time.ParseDuration(rest)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("policy %q: window must be a positive duration", text)
+		}
+		return SlidingWindow{Limit: n, Window: window}, nil
+	case "bucket":
+		per_text, burst_text, has_burst := strings.Cut(rest, ":")
+		per, err := time.ParseDuration(per_text)
+		if err != nil || per < time.Duration(n) {
+			return nil, fmt.Errorf("policy %q: period must be a positive duration", text)
+		}
+		p := TokenBucket{Rate: n, Per: per}
+		if has_burst {
+			if p.Burst, err = strconv.Atoi(burst_text); err != nil || p.Burst <= 0 {
+				return nil, fmt.Errorf("policy %q: burst must be a positive integer", text)
+			}
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown policy %q; use window or bucket", kind)
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestRulesText(t *testing.T) {
+	const text = "/user/login ip/24/64 window:20/1m; /group/messages/send user bucket:5/1s:20; /session/ping session bucket:1/2s; /user/recover ip window:3/1h30m"
+	var rules Rules
+	if err := rules.UnmarshalText([]byte(" ; " + text + ";")); err != nil {
+		t.Fatal(err)
+	}
+	want := Rules{
+		{Route: "/user/login", Key: KeyIP, IPv4Prefix: 24, IPv6Prefix: 64, Policy: SlidingWindow{Limit: 20, Window: time.Minute}},
+		{Route: "/group/messages/send", Key: KeyUser, Policy: TokenBucket{Rate: 5, Per: time.Second, Burst: 20}},
+		{Route: "/session/ping", Key: KeySession, Policy: TokenBucket{Rate: 1, Per: 2 * time.Second}},
+		{Route: "/user/recover", Key: KeyIP, Policy: SlidingWindow{Limit: 3, Window: 90 * time.Minute}},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("parsed %d rules, want %d", len(rules), len(want))
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Fatalf("rule %d parsed to %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+
+	marshaled, err := rules.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(marshaled) != text {
+		t.Fatalf("marshaled to %q, want %q", marshaled, text)
+	}
+	var again Rules
+	if err := again.UnmarshalText(marshaled); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != text {
+		t.Fatalf("round trip gave %q", again)
+	}
+
+	var empty Rules
+	if err := empty.UnmarshalText([]byte(" ; ")); err != nil || empty != nil {
+		t.Fatalf("blank rules: %v %v", empty, err)
+	}
+}
+
+func TestRulesTextErrors(t *testing.T) {
+	for _, text := range []string{
+		"/a ip",
+		"/a ip window:1/1s extra",
+		"a ip window:1/1s",
+		"/a host window:1/1s",
+		"/a ip/24 window:1/1s",
+		"/a ip/x/64 window:1/1s",
+		"/a ip/33/64 window:1/1s",
+		"/a ip/24/129 window:1/1s",
+		"/a ip window:1",
+		"/a ip window:0/1s",
+		"/a ip window:-1/1s",
+		"/a ip window:1/0s",
+		"/a ip window:1/soon",
+		"/a ip bucket:5/1ns",
+		"/a ip bucket:5/1s:0",
+		"/a ip bucket:5/1s:x",
+		"/a ip leaky:1/1s",
+		"/a ip window:1/1s; /a ip/24/64 bucket:1/1s",
+	} {
+		rules := Rules{{Route: "/kept", Key: KeyIP, Policy: SlidingWindow{Limit: 1, Window: time.Second}}}
+		if err := rules.UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("%q accepted", text)
+		} else if len(rules) != 1 || rules[0].Route != "/kept" {
+			t.Errorf("%q replaced the rules on error", text)
+		}
+	}
+
+	// The same route may be limited by different keys.
+	var rules Rules
+	if err := rules.UnmarshalText([]byte("/a ip window:1/1s; /a session window:1/1s")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddressKey(t *testing.T) {
+	rule := Rule{Key: KeyIP, IPv4Prefix: 24, IPv6Prefix: 48}
+	for addr, want := range map[string]string{
+		"198.51.100.7":        "198.51.100.0/24",
+		"::ffff:198.51.100.7": "198.51.100.0/24",
+		"2001:db8:1:2::7":     "2001:db8:1::/48",
+	} {
+		if got := rule.AddressKey(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("%s keyed as %s, want %s", addr, got, want)
+		}
+	}
+	if got := (Rule{Key: KeyIP}).AddressKey(netip.MustParseAddr("::ffff:198.51.100.7")); got != "198.51.100.7" {
+		t.Errorf("single address keyed as %s", got)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/MHSarmadi/Umbra/Server/database"
+)
+
+// Passphrases are taken from the environment rather than flags so they do not
+// show up in the process list or shell history.
+const (
+	dbPassphraseEnv    = "UMBRA_DB_PASSPHRASE"
+	dbNewPassphraseEnv = "UMBRA_DB_NEW_PASSPHRASE"
+)
+
+// rotateKey implements `server rotate-key`: it re-encrypts the key registry of
+// a stopped server's data directory under a new master key.
+func rotateKey(args []string) error {
+	fs := flag.NewFlagSet("rotate-key", flag.ExitOnError)
+	data := fs.String("data", "./data", "data directory to rotate")
+	key_file := fs.String("db-key-file", "", "current key file; or set "+dbPassphraseEnv)
+	new_key_file := fs.String("new-db-key-file", "", "new key file; or set "+dbNewPassphraseEnv)
+	fs.Parse(args)
+
+	old_key, err := database.LoadEncryptionKey(*data, *key_file, []byte(os.Getenv(dbPassphraseEnv)))
+	if err != nil {
+		return fmt.Errorf("current key: %w", err)
+	}
+	new_key, err := database.LoadEncryptionKey(*data, *new_key_file, []byte(os.Getenv(dbNewPassphraseEnv)))
+	if err != nil {
+		return fmt.Errorf("new key: %w", err)
+	}
+	if err := database.RotateEncryptionKey(*data, old_key, new_key); err != nil {
+		return err
+	}
+	fmt.Println("encryption key rotated; start the server with the new key")
+	return nil
+}
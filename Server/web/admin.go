@@ -0,0 +1,48 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/controllers"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/health"
+	"github.com/MHSarmadi/Umbra/Server/metrics"
+	"github.com/gorilla/mux"
+)
+
+// NewAdminServer builds the operator listener. It must not be reachable from
+// the internet: nothing on it is authenticated.
+func NewAdminServer(ctx context.Context, cfg Config, storage database.Store, checker *health.Checker) *Server {
+	r := buildAdminRouter(ctx, storage, checker)
+	handler := chainMiddlewares(r, RecoveryMiddleware, RequestLoggerMiddleware)
+
+	srv := &http.Server{
+		Addr:              cfg.AdminAddress,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		// No write timeout: backups stream for as long as the store is large.
+		IdleTimeout: 60 * time.Second,
+	}
+
+	return &Server{httpServer: srv}
+}
+
+func buildAdminRouter(ctx context.Context, storage database.Store, checker *health.Checker) *mux.Router {
+	r := mux.NewRouter().StrictSlash(true)
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+
+	c := controllers.NewAdminController(ctx, storage)
+
+	r.HandleFunc("/backup", c.AdminBackup).Methods(http.MethodGet)
+	r.HandleFunc("/snapshot", c.AdminSnapshot).Methods(http.MethodGet)
+	r.HandleFunc("/log-level", c.AdminLogLevel).Methods(http.MethodGet, http.MethodPut)
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	r.HandleFunc("/healthz", checker.Liveness).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", checker.Readiness).Methods(http.MethodGet)
+
+	return r
+}
@@ -0,0 +1,225 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Proto/umbrapb"
+	"github.com/MHSarmadi/Umbra/Server/controllers"
+	"github.com/MHSarmadi/Umbra/Server/crypto"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	models_requests "github.com/MHSarmadi/Umbra/Server/models/requests"
+	"github.com/MHSarmadi/Umbra/Server/wire"
+)
+
+const (
+	maxEnvelopeBodyBytes = 1 << 20
+	envelopeNonceSize    = 16
+	envelopeClockSkew    = 30 * time.Second
+	maxTrackedNonces     = 1024
+)
+
+var (
+	b64  = base64.RawStdEncoding.EncodeToString
+	db64 = base64.RawStdEncoding.DecodeString
+)
+
+var (
+	errSessionNotActive = errors.New("session not active")
+	errEnvelopeReplay   = errors.New("envelope nonce replayed")
+	errTooManyNonces    = errors.New("too many envelopes in flight")
+)
+
+// envelopeMixin binds the envelope header and route into the payload key, so
+// a ciphertext cannot be moved to another session, request or endpoint.
+func envelopeMixin(r *http.Request, env *models_requests.EnvelopeDecoded) []byte {
+	mixin := make([]byte, 0, len(env.SessionUUID)+len(env.Nonce)+len(env.Timestamp)+len(r.Method)+1+len(r.URL.Path))
+	mixin = append(mixin, env.SessionUUID...)
+	mixin = append(mixin, env.Nonce...)
+	mixin = append(mixin, env.Timestamp...)
+	mixin = append(mixin, r.Method...)
+	mixin = append(mixin, ' ')
+	mixin = append(mixin, r.URL.Path...)
+	return mixin
+}
+
+// envelopeMiddleware authenticates a post-handshake request envelope, rejects
+// replays through Session.LastNonces and hands the decrypted payload to the
+// next handler as the request body.
+func envelopeMiddleware(ctx context.Context, storage database.Store) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var (
+				err          error
+				body_encoded models_requests.EnvelopeEncoded
+				body_decoded models_requests.EnvelopeDecoded
+			)
+			if wire.IsProtobuf(r) {
+				r.Body = http.MaxBytesReader(w, r.Body, maxEnvelopeBodyBytes)
+				var body_pb umbrapb.Envelope
+				if err := wire.DecodeProto(r.Body, &body_pb); err != nil {
+					logger.Debugf("envelope rejected: malformed protobuf body remote=%s err=%v", r.RemoteAddr, err)
+					http.Error(w, "invalid request envelope", http.StatusBadRequest)
+					return
+				}
+				body_decoded = models_requests.EnvelopeDecoded{
+					SessionUUID: body_pb.GetSessionId(),
+					Nonce:       body_pb.GetNonce(),
+					Timestamp:   binary.BigEndian.AppendUint64(nil, body_pb.GetTimestampUnixMillisec()),
+					Payload:     body_pb.GetPayload(),
+					Signature:   body_pb.GetSignature(),
+				}
+			} else {
+				if r.Method == http.MethodGet {
+					// GET requests (the websocket upgrade in particular) cannot carry a
+					// body from browsers, so their envelope travels in the query string.
+					query := r.URL.Query()
+					body_encoded = models_requests.EnvelopeEncoded{
+						SessionUUID: query.Get("session_id"),
+						Nonce:       query.Get("nonce"),
+						Timestamp:   query.Get("timestamp_unix_millisec"),
+						Payload:     query.Get("payload"),
+						Signature:   query.Get("signature"),
+					}
+				} else {
+					r.Body = http.MaxBytesReader(w, r.Body, maxEnvelopeBodyBytes)
+					if err := wire.DecodeJSON(r.Body, &body_encoded); err != nil {
+						logger.Debugf("envelope rejected: malformed json body remote=%s err=%v", r.RemoteAddr, err)
+						http.Error(w, "invalid request envelope", http.StatusBadRequest)
+						return
+					}
+				}
+
+				if body_decoded.SessionUUID, err = db64(body_encoded.SessionUUID); err != nil {
+					http.Error(w, "invalid session_id base64 encoding", http.StatusBadRequest)
+					return
+				} else if body_decoded.Nonce, err = db64(body_encoded.Nonce); err != nil {
+					http.Error(w, "invalid nonce base64 encoding", http.StatusBadRequest)
+					return
+				} else if body_decoded.Timestamp, err = db64(body_encoded.Timestamp); err != nil {
+					http.Error(w, "invalid timestamp base64 encoding", http.StatusBadRequest)
+					return
+				} else if body_decoded.Payload, err = db64(body_encoded.Payload); err != nil {
+					http.Error(w, "invalid payload base64 encoding", http.StatusBadRequest)
+					return
+				} else if body_decoded.Signature, err = db64(body_encoded.Signature); err != nil {
+					http.Error(w, "invalid signature base64 encoding", http.StatusBadRequest)
+					return
+				}
+			}
+
+			if len(body_decoded.SessionUUID) != 24 || len(body_decoded.Nonce) != envelopeNonceSize || len(body_decoded.Timestamp) != 8 || len(body_decoded.Signature) != 64 {
+				logger.Debugf("envelope rejected: invalid field lengths session_id=%d nonce=%d timestamp=%d signature=%d", len(body_decoded.SessionUUID), len(body_decoded.Nonce), len(body_decoded.Timestamp), len(body_decoded.Signature))
+				http.Error(w, "invalid envelope field length", http.StatusBadRequest)
+				return
+			} else if len(body_decoded.Payload) < 12+16+64 {
+				// payload is salt (12 bytes) || tag (16 bytes) || at least one padded block
+				http.Error(w, "invalid payload length", http.StatusBadRequest)
+				return
+			}
+
+			now := time.Now().UTC()
+			timestamp := time.UnixMilli(int64(binary.BigEndian.Uint64(body_decoded.Timestamp))).UTC()
+			if skew := now.Sub(timestamp); skew > envelopeClockSkew || skew < -envelopeClockSkew {
+				logger.Debugf("envelope rejected: timestamp outside clock skew window skew_ms=%d", skew.Milliseconds())
+				http.Error(w, "envelope timestamp out of range", http.StatusUnauthorized)
+				return
+			}
+
+			session, err := storage.GetSessionByUUID(ctx, [24]byte(body_decoded.SessionUUID))
+			if errors.Is(err, database.ErrNotFound) {
+				http.Error(w, "session not found", http.StatusUnauthorized)
+				return
+			} else if err != nil {
+				logger.Errorf("envelope failed loading session: %v", err)
+				http.Error(w, "could not load session", http.StatusInternalServerError)
+				return
+			} else if !session.IsActive() {
+				logger.Debugf("envelope rejected: session not active state=%q", session.State)
+				http.Error(w, "session not active", http.StatusForbidden)
+				return
+			}
+
+			mixin := envelopeMixin(r, &body_decoded)
+			if !crypto.Verify(session.ClientEdPubKey[:], append(append([]byte(nil), mixin...), body_decoded.Payload...), body_decoded.Signature) {
+				logger.Debugf("envelope rejected: signature verification failed")
+				http.Error(w, "invalid envelope signature", http.StatusUnauthorized)
+				return
+			}
+
+			shared_key, err := controllers.SessionSharedKey(session)
+			if err != nil {
+				logger.Errorf("envelope failed computing shared key: %v", err)
+				http.Error(w, "could not compute shared secret", http.StatusInternalServerError)
+				return
+			}
+			payload_salt := body_decoded.Payload[:12]
+			payload_tag := body_decoded.Payload[12 : 12+16]
+			payload_ciphered := body_decoded.Payload[12+16:]
+			plain, valid, err := crypto.MACE_Decrypt_MIXIN_AEAD(shared_key, payload_ciphered, mixin, payload_salt, payload_tag, "@REQUEST-ENVELOPE", 4)
+			if !valid || err != nil {
+				logger.Debugf("envelope rejected: payload authentication failed valid=%t err=%v", valid, err)
+				http.Error(w, "invalid envelope payload", http.StatusUnauthorized)
+				return
+			}
+
+			// Record the nonce only after the envelope authenticated, so forged
+			// envelopes cannot fill the replay window.
+			nonceKey := b64(body_decoded.Nonce)
+			session, err = storage.UpdateSession(ctx, session.UUID, func(s *models.Session) error {
+				if !s.IsActive() {
+					return errSessionNotActive
+				}
+				horizon := now.Add(-envelopeClockSkew).Unix() - 1
+				for k, ts := range s.LastNonces {
+					if ts < horizon {
+						delete(s.LastNonces, k)
+					}
+				}
+				if _, seen := s.LastNonces[nonceKey]; seen {
+					return errEnvelopeReplay
+				}
+				if len(s.LastNonces) >= maxTrackedNonces {
+					return errTooManyNonces
+				}
+				if s.LastNonces == nil {
+					s.LastNonces = make(map[string]int64)
+				}
+				s.LastNonces[nonceKey] = timestamp.Unix()
+				return nil
+			})
+			switch {
+			case errors.Is(err, database.ErrNotFound):
+				http.Error(w, "session not found", http.StatusUnauthorized)
+				return
+			case errors.Is(err, errSessionNotActive):
+				http.Error(w, "session not active", http.StatusForbidden)
+				return
+			case errors.Is(err, errEnvelopeReplay):
+				logger.Infof("envelope rejected: replayed nonce")
+				http.Error(w, "envelope replayed", http.StatusUnauthorized)
+				return
+			case errors.Is(err, errTooManyNonces):
+				logger.Infof("envelope rejected: nonce window full")
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			case err != nil:
+				logger.Errorf("envelope failed recording nonce: %v", err)
+				http.Error(w, "could not update session", http.StatusInternalServerError)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(plain))
+			r.ContentLength = int64(len(plain))
+			next.ServeHTTP(w, r.WithContext(controllers.WithEnvelope(r.Context(), session, body_decoded.Nonce, shared_key)))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/metrics"
+	"github.com/gorilla/mux"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec("umbra_http_requests_total",
+		"HTTP requests by route template, method and status code.", "route", "method", "code")
+	httpRequestDuration = metrics.NewHistogramVec("umbra_http_request_duration_seconds",
+		"HTTP request latency by route template.", metrics.DurationBuckets, "route")
+)
+
+// metricsMiddleware labels requests with the route template they match, not
+// the raw path, so the number of series stays bounded.
+func metricsMiddleware(router *mux.Router) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := "unmatched"
+			var match mux.RouteMatch
+			if router.Match(r, &match) && match.Route != nil {
+				if tmpl, err := match.Route.GetPathTemplate(); err == nil {
+					route = tmpl
+				}
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			start := time.Now()
+			next.ServeHTTP(recorder, r)
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			httpRequests.With(route, r.Method, strconv.Itoa(recorder.status)).Inc()
+			httpRequestDuration.With(route).ObserveSince(start)
+		})
+	}
+}
@@ -84,6 +84,7 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.Header().Set("Access-Control-Max-Age", "3600")
+		w.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusNoContent)
@@ -98,6 +99,10 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					// Deliberate abort of a response already under way.
+					panic(rec)
+				}
 				logger.Errorf("panic recovered method=%s path=%s remote=%s panic=%v", r.Method, r.URL.Path, r.RemoteAddr, rec)
 				http.Error(w, "internal server error", http.StatusInternalServerError)
 			}
@@ -115,14 +120,14 @@ func RequestLoggerMiddleware(next http.Handler) http.Handler {
 		if recorder.status == 0 {
 			recorder.status = http.StatusOK
 		}
-		logger.Debugf(
-			"%s %s from [%s] status=%d bytes=%d duration_ms=%d",
-			r.Method,
-			r.RequestURI,
-			r.RemoteAddr,
-			recorder.status,
-			recorder.bytes,
-			duration.Milliseconds(),
+		logger.Debug(
+			"request",
+			logger.F("method", r.Method),
+			logger.F("uri", r.RequestURI),
+			logger.F("remote", r.RemoteAddr),
+			logger.F("status", recorder.status),
+			logger.F("bytes", recorder.bytes),
+			logger.F("duration_ms", duration.Milliseconds()),
 		)
 	})
 }
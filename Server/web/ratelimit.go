@@ -0,0 +1,190 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/clientip"
+	"github.com/MHSarmadi/Umbra/Server/controllers"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/metrics"
+	"github.com/MHSarmadi/Umbra/Server/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+var rateLimited = metrics.NewCounterVec("umbra_rate_limited_total",
+	"Requests turned away by a rate limit by route template and key.", "route", "key")
+
+// rateLimits applies the per-route rules. Address rules run in front of the
+// request envelope, so a flood is turned away before any signature check or
+// decryption; session and user rules run behind it, once the session is
+// known. A backend failure lets the request through: an outage of the limiter
+// should not become an outage of the server.
+type rateLimits struct {
+	routes  map[string][]routeLimit
+	clients *clientip.Resolver
+}
+
+type routeLimit struct {
+	rule    ratelimit.Rule
+	limiter *ratelimit.Limiter
+}
+
+func newRateLimits(cfg ratelimit.Config, storage database.Store, clients *clientip.Resolver) *rateLimits {
+	var backend ratelimit.Backend = storage
+	if cfg.Backend == ratelimit.BackendMemory {
+		backend = ratelimit.NewMemory()
+	}
+	l := &rateLimits{routes: make(map[string][]routeLimit), clients: clients}
+	for _, rule := range cfg.Rules {
+		limiter := ratelimit.New(backend, rule.Route+" "+string(rule.Key), rule.Policy)
+		l.routes[rule.Route] = append(l.routes[rule.Route], routeLimit{rule: rule, limiter: limiter})
+	}
+	return l
+}
+
+// checkRoutes reports rules naming a route the router does not serve, which
+// would otherwise never apply.
+func (l *rateLimits) checkRoutes(router *mux.Router) error {
+	served := map[string]bool{}
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			served[tmpl] = true
+		}
+		return nil
+	})
+	var unknown []string
+	for route := range l.routes {
+		if !served[route] {
+			unknown = append(unknown, route)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("rate limit rules name routes that do not exist: %v", unknown)
+	}
+	return nil
+}
+
+// byAddress applies the KeyIP rules of the matched route.
+func (l *rateLimits) byAddress(next http.Handler) http.Handler {
+	return l.middleware(next, true)
+}
+
+// bySession applies the KeySession and KeyUser rules; it belongs behind the
+// envelope middleware.
+func (l *rateLimits) bySession(next http.Handler) http.Handler {
+	return l.middleware(next, false)
+}
+
+func (l *rateLimits) middleware(next http.Handler, by_address bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tmpl, _ := route.GetPathTemplate()
+
+		now := time.Now()
+		var (
+			tightest  *ratelimit.Decision
+			policy    ratelimit.Policy
+			denied_by ratelimit.KeyKind
+		)
+		for _, limit := range l.routes[tmpl] {
+			if (limit.rule.Key == ratelimit.KeyIP) != by_address {
+				continue
+			}
+			key, ok := l.key(r, limit.rule)
+			if !ok {
+				continue
+			}
+			d, err := limit.limiter.Allow(r.Context(), key, now)
+			if err != nil {
+				logger.Errorf("rate limit %s by %s failed, letting the request through: %v", tmpl, limit.rule.Key, err)
+				continue
+			}
+			if tightest == nil || tighter(d, *tightest) {
+				tightest, policy = &d, limit.limiter.Policy()
+				if !d.Allowed {
+					denied_by = limit.rule.Key
+				}
+			}
+		}
+		if tightest == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writeRateLimitHeaders(w, *tightest, policy)
+		if !tightest.Allowed {
+			rateLimited.With(tmpl, string(denied_by)).Inc()
+			logger.Debugf("request rate limited route=%s key=%s retry_after=%s", tmpl, denied_by, tightest.RetryAfter)
+			w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(tightest.RetryAfter), 10))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// key is what r counts under for rule, or false when the rule does not apply
+// to r, such as a session rule on a request without an envelope.
+func (l *rateLimits) key(r *http.Request, rule ratelimit.Rule) (string, bool) {
+	switch rule.Key {
+	case ratelimit.KeyIP:
+		addr, ok := l.clients.Addr(r)
+		if !ok {
+			return r.RemoteAddr, true
+		}
+		if rule.IPv4Prefix == 0 && rule.IPv6Prefix == 0 {
+			return l.clients.Identity(addr), true
+		}
+		return rule.AddressKey(addr), true
+	case ratelimit.KeySession, ratelimit.KeyUser:
+		session, ok := controllers.EnvelopeSession(r)
+		if !ok {
+			return "", false
+		}
+		if rule.Key == ratelimit.KeyUser && len(session.UserUUID) > 0 {
+			return "user:" + b64(session.UserUUID), true
+		}
+		return "session:" + b64(session.UUID[:]), true
+	}
+	return "", false
+}
+
+// tighter orders decisions for the headers: a denial over an allowance, the
+// longer wait among denials and the fewer remaining among allowances.
+func tighter(a, b ratelimit.Decision) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+// writeRateLimitHeaders sets the RateLimit-* headers, unless a rule checked
+// earlier in the chain already reported fewer remaining requests.
+func writeRateLimitHeaders(w http.ResponseWriter, d ratelimit.Decision, policy ratelimit.Policy) {
+	h := w.Header()
+	if earlier, err := strconv.Atoi(h.Get("RateLimit-Remaining")); err == nil && earlier < d.Remaining && d.Allowed {
+		return
+	}
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(d.Reset), 10))
+	h.Set("RateLimit-Policy", policy.Header())
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,158 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/clientip"
+	"github.com/MHSarmadi/Umbra/Server/controllers"
+	"github.com/MHSarmadi/Umbra/Server/models"
+	"github.com/MHSarmadi/Umbra/Server/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+// newLimitedRouter serves /limited behind rules, laid out like buildRouter:
+// address rules for every route, session rules behind an envelope, which
+// here carries a fixed session.
+func newLimitedRouter(t *testing.T, rules string) *mux.Router {
+	t.Helper()
+	cfg := ratelimit.Config{Backend: ratelimit.BackendMemory}
+	if err := cfg.Rules.UnmarshalText([]byte(rules)); err != nil {
+		t.Fatal(err)
+	}
+	limits := newRateLimits(cfg, nil, clientip.New(nil, "", 64))
+
+	session := &models.Session{}
+	copy(session.UUID[:], "rate-limited-session....")
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	router := mux.NewRouter()
+	router.Use(limits.byAddress)
+	router.Handle("/limited", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limits.bySession(ok).ServeHTTP(w, r.WithContext(controllers.WithEnvelope(r.Context(), session, nil, nil)))
+	}))
+	router.Handle("/open", ok)
+	if err := limits.checkRoutes(router); err != nil {
+		t.Fatal(err)
+	}
+	return router
+}
+
+func get(router http.Handler, path, remote string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.RemoteAddr = remote
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func wantHeaders(t *testing.T, w *httptest.ResponseRecorder, limit, remaining, policy string) {
+	t.Helper()
+	h := w.Header()
+	if h.Get("RateLimit-Limit") != limit || h.Get("RateLimit-Remaining") != remaining || h.Get("RateLimit-Policy") != policy {
+		t.Fatalf("headers limit=%q remaining=%q policy=%q, want %q %q %q",
+			h.Get("RateLimit-Limit"), h.Get("RateLimit-Remaining"), h.Get("RateLimit-Policy"), limit, remaining, policy)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	router := newLimitedRouter(t, "/limited ip window:2/1m")
+	const client = "198.51.100.7:4000"
+
+	w := get(router, "/limited", client)
+	if w.Code != http.StatusOK {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	wantHeaders(t, w, "2", "1", "2;w=60")
+	if reset := w.Header().Get("RateLimit-Reset"); reset != "120" {
+		t.Fatalf("RateLimit-Reset %q, want 120", reset)
+	}
+	if w.Header().Get("Retry-After") != "" {
+		t.Fatal("allowed request has a Retry-After")
+	}
+
+	if w = get(router, "/limited", client); w.Code != http.StatusOK {
+		t.Fatalf("second request: %d", w.Code)
+	}
+	wantHeaders(t, w, "2", "0", "2;w=60")
+
+	w = get(router, "/limited", client)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request: %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	wantHeaders(t, w, "2", "0", "2;w=60")
+	// Room returns once the window's two requests weigh in at one, half
+	// way into the next window.
+	if retry, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retry < 89 || retry > 90 {
+		t.Fatalf("Retry-After %q, want 90", w.Header().Get("Retry-After"))
+	}
+
+	if w = get(router, "/limited", "[2001:db8::1]:4000"); w.Code != http.StatusOK {
+		t.Fatalf("another client: %d", w.Code)
+	}
+	// The whole /64 of an IPv6 client shares its bucket.
+	get(router, "/limited", "[2001:db8::2]:4000")
+	if w = get(router, "/limited", "[2001:db8::3]:4000"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request from one /64: %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	if w = get(router, "/open", client); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Fatalf("unlimited route: %d with RateLimit-Limit %q", w.Code, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimitHeaderPrecedence(t *testing.T) {
+	// The session rule is the tighter one and reports over the address rule.
+	router := newLimitedRouter(t, "/limited ip window:10/1m; /limited session window:2/1m")
+	w := get(router, "/limited", "198.51.100.7:4000")
+	wantHeaders(t, w, "2", "1", "2;w=60")
+
+	// The address rule is the tighter one and keeps its headers.
+	router = newLimitedRouter(t, "/limited ip window:2/1m; /limited session window:10/1m")
+	w = get(router, "/limited", "198.51.100.7:4000")
+	wantHeaders(t, w, "2", "1", "2;w=60")
+	w = get(router, "/limited", "198.51.100.8:4000")
+	wantHeaders(t, w, "2", "1", "2;w=60")
+
+	// A session rule denying a request the address rule allowed reports
+	// the denial.
+	router = newLimitedRouter(t, "/limited ip window:10/1m; /limited session bucket:1/1m")
+	get(router, "/limited", "198.51.100.7:4000")
+	w = get(router, "/limited", "198.51.100.7:4000")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("session over its limit: %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	wantHeaders(t, w, "1", "0", "1;w=60;burst=1")
+	if w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("Retry-After %q, want 60", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestTighter(t *testing.T) {
+	allowed := func(remaining int) ratelimit.Decision {
+		return ratelimit.Decision{Allowed: true, Remaining: remaining}
+	}
+	denied := func(retry_after time.Duration) ratelimit.Decision {
+		return ratelimit.Decision{RetryAfter: retry_after}
+	}
+	for _, tc := range []struct {
+		a, b ratelimit.Decision
+		want bool
+	}{
+		{denied(time.Second), allowed(0), true},
+		{allowed(0), denied(time.Second), false},
+		{denied(2 * time.Second), denied(time.Second), true},
+		{denied(time.Second), denied(2 * time.Second), false},
+		{allowed(1), allowed(2), true},
+		{allowed(2), allowed(1), false},
+		{allowed(1), allowed(1), false},
+	} {
+		if got := tighter(tc.a, tc.b); got != tc.want {
+			t.Errorf("tighter(%+v, %+v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -6,12 +6,14 @@ import (
 
 	"github.com/MHSarmadi/Umbra/Server/controllers"
 	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/health"
 	"github.com/gorilla/mux"
 )
 
-func buildRouter(ctx context.Context, storage *database.BadgerStore) *mux.Router {
+func buildRouter(ctx context.Context, cfg controllers.Config, storage database.Store, checker *health.Checker, limits *rateLimits) *mux.Router {
 	r := mux.NewRouter().StrictSlash(true)
 	r.Use(mux.CORSMethodMiddleware(r))
+	r.Use(limits.byAddress)
 	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 	})
@@ -19,17 +21,46 @@ func buildRouter(ctx context.Context, storage *database.BadgerStore) *mux.Router
 		http.Error(w, "not found", http.StatusNotFound)
 	})
 
-	c := controllers.NewController(ctx, storage)
+	c := controllers.NewController(ctx, cfg, storage)
+	open_envelope := envelopeMiddleware(ctx, storage)
+	envelope := func(next http.Handler) http.Handler {
+		return open_envelope(limits.bySession(next))
+	}
 
 	demo := r.PathPrefix("/demo").Subrouter()
 	demo.HandleFunc("/captcha", c.DemoCaptcha).Methods(http.MethodGet)
 
 	r.HandleFunc("/hello-world", c.HelloWorld).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/healthz", checker.Liveness).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", checker.Readiness).Methods(http.MethodGet)
 
 	session := r.PathPrefix("/session").Subrouter()
 	session.HandleFunc("/init", c.SessionInit).Methods(http.MethodPost)
+	session.HandleFunc("/pow", c.SessionPoW).Methods(http.MethodPost)
+	session.HandleFunc("/activate", c.SessionActivate).Methods(http.MethodPost)
+	session.Handle("/ping", envelope(http.HandlerFunc(c.SessionPing))).Methods(http.MethodPost)
 
-	r.HandleFunc("/ws", c.WS).Methods(http.MethodGet)
+	user := r.PathPrefix("/user").Subrouter()
+	user.Use(mux.MiddlewareFunc(envelope))
+	user.HandleFunc("/kdf", c.UserKDF).Methods(http.MethodPost)
+	user.HandleFunc("/register", c.UserRegister).Methods(http.MethodPost)
+	user.HandleFunc("/login", c.UserLogin).Methods(http.MethodPost)
+	user.HandleFunc("/login/proof", c.UserLoginProof).Methods(http.MethodPost)
+	user.HandleFunc("/soul", c.UserSoul).Methods(http.MethodPost)
+	user.HandleFunc("/lookup", c.UserLookup).Methods(http.MethodPost)
+	user.HandleFunc("/recovery", c.UserRecovery).Methods(http.MethodPost)
+	user.HandleFunc("/recovery/reset", c.UserRecoveryReset).Methods(http.MethodPost)
+
+	group := r.PathPrefix("/group").Subrouter()
+	group.Use(mux.MiddlewareFunc(envelope))
+	group.HandleFunc("/create", c.GroupCreate).Methods(http.MethodPost)
+	group.HandleFunc("/list", c.GroupList).Methods(http.MethodPost)
+	group.HandleFunc("/members/add", c.GroupMemberAdd).Methods(http.MethodPost)
+	group.HandleFunc("/members/remove", c.GroupMemberRemove).Methods(http.MethodPost)
+	group.HandleFunc("/messages/send", c.GroupMessageSend).Methods(http.MethodPost)
+	group.HandleFunc("/messages/history", c.GroupMessageHistory).Methods(http.MethodPost)
+
+	r.Handle("/ws", envelope(http.HandlerFunc(c.WS))).Methods(http.MethodGet)
 
 	return r
 }
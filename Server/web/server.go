@@ -2,36 +2,146 @@ package web
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/MHSarmadi/Umbra/Server/clientip"
+	"github.com/MHSarmadi/Umbra/Server/controllers"
 	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/health"
+	"github.com/MHSarmadi/Umbra/Server/logger"
+	"github.com/MHSarmadi/Umbra/Server/ratelimit"
 )
 
+// Config holds the listeners. An empty AdminAddress disables the admin
+// listener. With TLSCertFile and TLSKeyFile set, Address serves TLS, and a
+// non-empty HTTPRedirectAddress sends plain HTTP there.
+type Config struct {
+	Address      string
+	AdminAddress string
+
+	TLSCertFile         string
+	TLSKeyFile          string
+	TLSMinVersion       string
+	HTTPRedirectAddress string
+
+	// DrainDelay is how long ShutDown keeps serving with /readyz failing, so
+	// load balancers stop sending traffic before connections close.
+	DrainDelay time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Address:       "localhost:8888",
+		AdminAddress:  "localhost:8889",
+		TLSMinVersion: "1.2",
+		DrainDelay:    5 * time.Second,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.Address == "" {
+		return errors.New("server address is required")
+	}
+	if c.AdminAddress == c.Address {
+		return errors.New("the admin listener needs its own address")
+	}
+	if c.DrainDelay < 0 {
+		return errors.New("drain delay cannot be negative")
+	}
+	return c.validateTLS()
+}
+
 type Server struct {
 	httpServer *http.Server
+	redirect   *http.Server
+	certs      *certReloader
+
+	checker    *health.Checker
+	drainDelay time.Duration
 }
 
-func NewServer(ctx context.Context, address string, storage *database.BadgerStore) *Server {
-	r := buildRouter(ctx, storage)
-	handler := chainMiddlewares(r, RecoveryMiddleware, RequestLoggerMiddleware, CORSMiddleware)
+func NewServer(ctx context.Context, cfg Config, controllers_cfg controllers.Config, limits_cfg ratelimit.Config, storage database.Store, checker *health.Checker) (*Server, error) {
+	limits := newRateLimits(limits_cfg, storage, clientip.New(controllers_cfg.TrustedProxies, controllers_cfg.ProxyHeader, controllers_cfg.IdentityIPv6Prefix))
+	r := buildRouter(ctx, controllers_cfg, storage, checker, limits)
+	if err := limits.checkRoutes(r); err != nil {
+		return nil, err
+	}
+	handler := chainMiddlewares(r, metricsMiddleware(r), RecoveryMiddleware, RequestLoggerMiddleware, CORSMiddleware)
 
 	srv := &http.Server{
-		Addr:              address,
+		Addr:              cfg.Address,
 		Handler:           handler,
 		ReadTimeout:       10 * time.Second,
 		ReadHeaderTimeout: 10 * time.Second,
 		WriteTimeout:      10 * time.Second,
 		IdleTimeout:       60 * time.Second,
 	}
+	s := &Server{httpServer: srv, checker: checker, drainDelay: cfg.DrainDelay}
 
-	return &Server{httpServer: srv}
+	if cfg.TLSCertFile != "" {
+		certs, err := newCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		go certs.watch(ctx, certPollInterval)
+		srv.TLSConfig = tlsConfig(cfg, certs)
+		s.certs = certs
+		if cfg.HTTPRedirectAddress != "" {
+			s.redirect = newRedirectServer(cfg)
+		}
+	}
+	return s, nil
 }
 
 func (s *Server) Run() error {
-	return s.httpServer.ListenAndServe()
+	l, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve is Run on a listener the caller already holds.
+func (s *Server) Serve(l net.Listener) error {
+	if s.redirect != nil {
+		go func() {
+			if err := s.redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Errorf("http redirect listener failed: %v", err)
+			}
+		}()
+	}
+	if s.certs != nil {
+		return s.httpServer.ServeTLS(l, "", "")
+	}
+	return s.httpServer.Serve(l)
+}
+
+// ReloadCertificates rereads the TLS certificate and key. Connections keep
+// running; new handshakes use the new certificate.
+func (s *Server) ReloadCertificates() error {
+	if s.certs == nil {
+		return nil
+	}
+	return s.certs.Reload()
 }
 
+// ShutDown first fails readiness for the drain delay, then stops accepting
+// connections and waits for the open ones to finish.
 func (s *Server) ShutDown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	if s.checker != nil {
+		s.checker.SetDraining()
+		select {
+		case <-time.After(s.drainDelay):
+		case <-ctx.Done():
+		}
+	}
+	var errs []error
+	if s.redirect != nil {
+		errs = append(errs, s.redirect.Shutdown(ctx))
+	}
+	errs = append(errs, s.httpServer.Shutdown(ctx))
+	return errors.Join(errs...)
 }
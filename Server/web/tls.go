@@ -0,0 +1,174 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/logger"
+)
+
+// certPollInterval is how often the certificate files are checked for
+// changes, so a renewal is picked up without a SIGHUP.
+const certPollInterval = 30 * time.Second
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// certReloader serves the certificate in certFile and keyFile and swaps in a
+// new one when the files change. Handshakes under way keep the certificate
+// they started with; established connections are not affected at all.
+type certReloader struct {
+	certFile, keyFile string
+
+	cert atomic.Pointer[tls.Certificate]
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the files again. On error the current certificate stays.
+func (c *certReloader) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mod_time, err := c.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %w", err)
+	}
+	c.cert.Store(&cert)
+	c.modTime = mod_time
+	return nil
+}
+
+// latestModTime is the newer of the two files' modification times; renewals
+// rarely replace both at the same instant.
+func (c *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (c *certReloader) changed() bool {
+	mod_time, err := c.latestModTime()
+	if err != nil {
+		// Mid-replacement; the next poll will see the finished files.
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !mod_time.Equal(c.modTime)
+}
+
+func (c *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !c.changed() {
+				continue
+			}
+			if err := c.Reload(); err != nil {
+				logger.Errorf("tls certificate reload failed, keeping the current one: %v", err)
+				continue
+			}
+			logger.Infof("tls certificate reloaded after file change")
+		}
+	}
+}
+
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+func tlsConfig(cfg Config, certs *certReloader) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tlsVersions[cfg.TLSMinVersion],
+		GetCertificate: certs.GetCertificate,
+	}
+}
+
+// newRedirectServer answers plain HTTP on cfg.HTTPRedirectAddress with a
+// permanent redirect to the same path on the TLS listener.
+func newRedirectServer(cfg Config) *http.Server {
+	_, tls_port, _ := net.SplitHostPort(cfg.Address)
+	return &http.Server{
+		Addr:              cfg.HTTPRedirectAddress,
+		Handler:           redirectHandler(tls_port),
+		ReadTimeout:       10 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+}
+
+func redirectHandler(tls_port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+		if host == "" {
+			http.Error(w, "missing host", http.StatusBadRequest)
+			return
+		}
+		if tls_port != "" && tls_port != "443" {
+			host = net.JoinHostPort(host, tls_port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+func (c Config) validateTLS() error {
+	var errs []error
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("tls needs both a certificate and a key file"))
+	}
+	if _, ok := tlsVersions[c.TLSMinVersion]; !ok {
+		errs = append(errs, fmt.Errorf("unsupported minimum tls version %q; use 1.2 or 1.3", c.TLSMinVersion))
+	}
+	if c.HTTPRedirectAddress != "" {
+		if c.TLSCertFile == "" {
+			errs = append(errs, errors.New("the http redirect needs tls to redirect to"))
+		}
+		if c.HTTPRedirectAddress == c.Address || c.HTTPRedirectAddress == c.AdminAddress {
+			errs = append(errs, errors.New("the http redirect needs its own address"))
+		}
+	}
+	return errors.Join(errs...)
+}
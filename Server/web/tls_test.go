@@ -0,0 +1,218 @@
+package web
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MHSarmadi/Umbra/Server/controllers"
+	"github.com/MHSarmadi/Umbra/Server/database"
+	"github.com/MHSarmadi/Umbra/Server/health"
+	"github.com/MHSarmadi/Umbra/Server/ratelimit"
+)
+
+// writeSelfSigned writes a certificate for 127.0.0.1 with the given serial to
+// dir and returns the file paths and the parsed certificate.
+func writeSelfSigned(t *testing.T, dir string, serial int64) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "umbra test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key_der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key_der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile, cert
+}
+
+// bumpModTime makes a rewrite visible even on filesystems with coarse
+// timestamps.
+func bumpModTime(t *testing.T, paths ...string) {
+	t.Helper()
+	later := time.Now().Add(time.Minute)
+	for _, path := range paths {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func servedSerial(t *testing.T, c *certReloader) int64 {
+	t.Helper()
+	cert, err := c.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.SerialNumber.Int64()
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeSelfSigned(t, dir, 1)
+	c, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := servedSerial(t, c); got != 1 {
+		t.Fatalf("serving serial %d, want 1", got)
+	}
+	if c.changed() {
+		t.Fatal("unchanged files reported as changed")
+	}
+
+	writeSelfSigned(t, dir, 2)
+	bumpModTime(t, certFile, keyFile)
+	if !c.changed() {
+		t.Fatal("rewritten files not reported as changed")
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := servedSerial(t, c); got != 2 {
+		t.Fatalf("serving serial %d after reload, want 2", got)
+	}
+
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err == nil {
+		t.Fatal("reload of a broken certificate succeeded")
+	}
+	if got := servedSerial(t, c); got != 2 {
+		t.Fatalf("serving serial %d after failed reload, want 2", got)
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	certFile, keyFile, cert := writeSelfSigned(t, t.TempDir(), 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := DefaultConfig()
+	cfg.TLSCertFile = certFile
+	cfg.TLSKeyFile = keyFile
+	cfg.TLSMinVersion = "1.3"
+	cfg.DrainDelay = 0
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	storage := database.NewMemoryStore()
+	srv, err := NewServer(ctx, cfg, controllers.DefaultConfig(), ratelimit.DefaultConfig(), storage, health.NewChecker(health.Store(storage)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.ShutDown(ctx)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	url := "https://" + l.Addr().String() + "/hello-world"
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+	if resp.TLS.Version != tls.VersionTLS13 {
+		t.Fatalf("negotiated tls version %x, want 1.3", resp.TLS.Version)
+	}
+
+	old := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MaxVersion: tls.VersionTLS12}}}
+	if resp, err := old.Get(url); err == nil {
+		resp.Body.Close()
+		t.Fatal("tls 1.2 client accepted with minimum version 1.3")
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	cases := []struct {
+		port, host, target, want string
+	}{
+		{"8443", "example.com:8080", "/session/init?x=1", "https://example.com:8443/session/init?x=1"},
+		{"443", "example.com", "/", "https://example.com/"},
+		{"443", "[::1]:80", "/a", "https://[::1]/a"},
+		{"443", "[::1]", "/a", "https://[::1]/a"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		r.Host = tc.host
+		w := httptest.NewRecorder()
+		redirectHandler(tc.port).ServeHTTP(w, r)
+		if w.Code != http.StatusPermanentRedirect {
+			t.Fatalf("%s: status %d, want 308", tc.host, w.Code)
+		}
+		if got := w.Header().Get("Location"); got != tc.want {
+			t.Fatalf("%s: redirected to %q, want %q", tc.host, got, tc.want)
+		}
+	}
+}
+
+func TestConfigValidateTLS(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TLSCertFile = "cert.pem"
+	if cfg.Validate() == nil {
+		t.Fatal("certificate without key accepted")
+	}
+	cfg = DefaultConfig()
+	cfg.TLSMinVersion = "1.0"
+	if cfg.Validate() == nil {
+		t.Fatal("tls 1.0 accepted as minimum")
+	}
+	cfg = DefaultConfig()
+	cfg.HTTPRedirectAddress = ":80"
+	if cfg.Validate() == nil {
+		t.Fatal("http redirect without tls accepted")
+	}
+}
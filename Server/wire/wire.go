@@ -0,0 +1,99 @@
+package wire
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// During the transition to ProtoBuf every endpoint speaks both encodings. A
+// request is treated as ProtoBuf when it says so in Content-Type, in Accept or,
+// for bodiless websocket upgrades, with ?encoding=protobuf. Everything else
+// stays on the legacy JSON shapes the existing frontends use.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var ErrTrailingData = errors.New("unexpected extra json values")
+
+func mediaType(header string) string {
+	mt, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+// IsProtobuf reports whether the request body is ProtoBuf encoded.
+func IsProtobuf(r *http.Request) bool {
+	return mediaType(r.Header.Get("Content-Type")) == ContentTypeProtobuf
+}
+
+// WantsProtobuf reports whether the response should be ProtoBuf encoded.
+func WantsProtobuf(r *http.Request) bool {
+	if IsProtobuf(r) || r.URL.Query().Get("encoding") == "protobuf" {
+		return true
+	}
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType(strings.TrimSpace(accepted)) == ContentTypeProtobuf {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeJSON strictly decodes exactly one JSON value from body.
+func DecodeJSON(body io.Reader, dst any) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return err
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return ErrTrailingData
+	}
+	return nil
+}
+
+// DecodeProto reads the whole body and unmarshals it into dst. Callers are
+// expected to have bounded body with http.MaxBytesReader.
+func DecodeProto(body io.Reader, dst proto.Message) error {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(raw, dst)
+}
+
+// Marshal encodes the ProtoBuf message or the legacy JSON value, depending
+// on asProto.
+func Marshal(asProto bool, pb proto.Message, legacy any) ([]byte, error) {
+	if asProto {
+		return proto.Marshal(pb)
+	}
+	return json.Marshal(legacy)
+}
+
+// Write sends pb as ProtoBuf when the client asked for it, otherwise legacy
+// as JSON.
+func Write(w http.ResponseWriter, r *http.Request, status int, pb proto.Message, legacy any) error {
+	if WantsProtobuf(r) {
+		encoded, err := proto.Marshal(pb)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", ContentTypeProtobuf)
+		w.WriteHeader(status)
+		_, err = w.Write(encoded)
+		return err
+	}
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(legacy)
+}